@@ -0,0 +1,167 @@
+#!/bin/bash
+
+# ============================================
+# Trading Pipeline - Integration Test
+# ============================================
+# Plays a scripted price sequence from a mock Binance through ingestion,
+# processing and the API, then checks /api/stats against the price,
+# session high/low and moving average the script should produce. The API
+# takes tick sizes from the mock too.
+#
+# Needs Go, g++, python3 and NATS: nats-server on PATH, or NATS_URL
+# pointing at a running server. No database or exchange access is used.
+# Run from project root: ./scripts/integration.sh
+# ============================================
+
+# Config
+SCRIPT_DIR="$(cd "$(dirname "$0")" && pwd)"
+PROJECT_DIR="$(dirname "$SCRIPT_DIR")"
+WORK_DIR="$(mktemp -d)"
+MOCK_PORT=19443
+API_PORT=18080
+BASE_URL="http://localhost:$API_PORT"
+MA_WINDOW=20
+PRICES="100,101,102,101.5,103,104,103.5,102,101,100.5,99,98.5,99.5,100,101,102.5,103,104.5,105,104,103,102,101.5,101,100,99.5,100.5,101.5,102,102.5"
+PIDS=()
+
+# Colors
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+YELLOW='\033[1;33m'
+CYAN='\033[0;36m'
+NC='\033[0m' # No Color
+
+# Cleanup on exit
+cleanup() {
+    for pid in "${PIDS[@]}"; do
+        kill "$pid" 2>/dev/null
+    done
+    wait 2>/dev/null
+    rm -rf "$WORK_DIR"
+}
+trap cleanup EXIT
+
+fail() {
+    echo -e "${RED}FAIL: $1${NC}"
+    for log in "$WORK_DIR"/*.log; do
+        echo -e "${YELLOW}--- $(basename "$log")${NC}"
+        tail -n 20 "$log"
+    done
+    exit 1
+}
+
+# Build every service into the work directory
+build() {
+    echo -e "${YELLOW}Building services...${NC}"
+    (cd "$PROJECT_DIR/services/ingestion" && go build -o "$WORK_DIR/ingestion" . && go build -o "$WORK_DIR/mockbinance" ./cmd/mockbinance) || fail "ingestion build"
+    (cd "$PROJECT_DIR/services/api" && go build -o "$WORK_DIR/api" .) || fail "api build"
+
+    # The processor links against the C++ library next to its sources
+    cp -r "$PROJECT_DIR/services/processing" "$WORK_DIR/processing-src"
+    (cd "$WORK_DIR/processing-src" &&
+        g++ -shared -fPIC -o libprocess.so process.cpp -lpthread &&
+        CGO_ENABLED=1 go build -o processing . &&
+        cp processing libprocess.so "$WORK_DIR/") || fail "processing build"
+}
+
+# Use NATS_URL if given, otherwise start a private nats-server
+start_nats() {
+    if [ -n "$NATS_URL" ]; then
+        echo -e "${GREEN}Using NATS at $NATS_URL${NC}"
+        return
+    fi
+    command -v nats-server > /dev/null || fail "nats-server not found; install it or set NATS_URL"
+    nats-server -p 14222 > "$WORK_DIR/nats.log" 2>&1 &
+    PIDS+=($!)
+    NATS_URL="nats://localhost:14222"
+    sleep 1
+}
+
+start_pipeline() {
+    echo -e "${YELLOW}Starting mock exchange and pipeline...${NC}"
+    "$WORK_DIR/mockbinance" -addr ":$MOCK_PORT" -prices "$PRICES" -interval 100ms > "$WORK_DIR/mockbinance.log" 2>&1 &
+    PIDS+=($!)
+
+    NATS_URL="$NATS_URL" MA_WINDOWS="$MA_WINDOW" STATE_FILE="$WORK_DIR/state.json" \
+        LD_LIBRARY_PATH="$WORK_DIR" "$WORK_DIR/processing" > "$WORK_DIR/processing.log" 2>&1 &
+    PIDS+=($!)
+
+    NATS_URL="$NATS_URL" PORT="$API_PORT" DATABASE_URL="postgres://localhost:1/none?connect_timeout=1" \
+        COINS_FILE="$WORK_DIR/coins.json" PORTFOLIO_FILE="$WORK_DIR/portfolio.json" \
+        BINANCE_REST_URL="http://localhost:$MOCK_PORT" \
+        "$WORK_DIR/api" > "$WORK_DIR/api.log" 2>&1 &
+    PIDS+=($!)
+
+    for i in {1..20}; do
+        curl -s "$BASE_URL/api/price" > /dev/null 2>&1 && break
+        sleep 1
+    done
+    curl -s "$BASE_URL/api/price" > /dev/null 2>&1 || fail "API did not start"
+
+    # Ingestion connects last so no scripted tick is missed
+    NATS_URL="$NATS_URL" SYMBOL=btcusdt BACKFILL=0 \
+        BINANCE_WS_URL="ws://localhost:$MOCK_PORT/ws" BINANCE_REST_URL="http://localhost:$MOCK_PORT" \
+        "$WORK_DIR/ingestion" > "$WORK_DIR/ingestion.log" 2>&1 &
+    PIDS+=($!)
+}
+
+# Wait for the last scripted tick, then compare /api/stats with the script
+check_stats() {
+    echo -e "${CYAN}═══════════════════════════════════════════${NC}"
+    echo -e "${CYAN}  Checking /api/stats after the script${NC}"
+    echo -e "${CYAN}═══════════════════════════════════════════${NC}"
+
+    TOTAL=$(echo "$PRICES" | tr ',' '\n' | wc -l | tr -d ' ')
+    for i in {1..30}; do
+        STATS=$(curl -s "$BASE_URL/api/stats")
+        COUNT=$(curl -s "$BASE_URL/api/history?source=memory&limit=1000" | python3 -c 'import json,sys; print(len(json.load(sys.stdin)))' 2>/dev/null)
+        [ "$COUNT" = "$TOTAL" ] && break
+        sleep 1
+    done
+
+    echo "$STATS" | PRICES="$PRICES" MA_WINDOW="$MA_WINDOW" python3 -c '
+import json, os, sys
+
+prices = [float(p) for p in os.environ["PRICES"].split(",")]
+window = int(os.environ["MA_WINDOW"])
+stats = json.load(sys.stdin)
+
+# The API rounds the moving average to the decimals it reports, the mock
+# tick size once that is looked up and a magnitude-based guess before, so
+# it may be off by up to half of the last place
+decimals = stats["price_decimals"]
+tolerance = {"indicators.moving_average": 0.5 * 10 ** -decimals}
+
+expected = {
+    "price": prices[-1],
+    "session.high": max(prices),
+    "session.low": min(prices),
+    "indicators.moving_average": sum(prices[-window:]) / window,
+}
+actual = {
+    "price": stats["price"],
+    "session.high": stats["session"]["high"],
+    "session.low": stats["session"]["low"],
+    "indicators.moving_average": stats["indicators"]["moving_average"],
+}
+
+ok = True
+for key, want in expected.items():
+    got = actual[key]
+    good = abs(got - want) < tolerance.get(key, 0) + 1e-9
+    ok = ok and good
+    print("  %-28s want %-12g got %-12g %s" % (key, want, got, "ok" if good else "MISMATCH"))
+sys.exit(0 if ok else 1)
+' || fail "stats do not match the script"
+}
+
+main() {
+    echo -e "${GREEN}Trading Pipeline - Integration Test${NC}"
+    build
+    start_nats
+    start_pipeline
+    check_stats
+    echo -e "${GREEN}PASS${NC}"
+}
+
+main
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Alert is a price threshold rule for one symbol. It fires once unless it
+// has a cooldown or re-arm band, in which case it re-arms after firing.
+type Alert struct {
+	ID            int     `json:"id"`
+	Symbol        string  `json:"symbol"`
+	Direction     string  `json:"direction"` // "above" or "below"
+	Threshold     float64 `json:"threshold"`
+	Cooldown      string  `json:"cooldown,omitempty"`      // least time between fires
+	RearmPercent  float64 `json:"rearm_percent,omitempty"` // how far back past the threshold the price must go to re-arm
+	State         string  `json:"state"`                   // armed, cooldown, rearming or fired
+	Triggered     bool    `json:"triggered"`               // fired and not re-armed since
+	Fires         int     `json:"fires"`
+	TriggeredAt   int64   `json:"triggered_at,omitempty"` // unix ms of the latest fire
+	TriggerPrice  float64 `json:"trigger_price,omitempty"`
+	CooldownUntil int64   `json:"cooldown_until,omitempty"` // unix ms, while in cooldown
+
+	cooldown  time.Duration
+	threshold decimal.Decimal // Threshold as written, which evaluate compares against
+	lastPrice decimal.Decimal // previous tick seen by this rule
+}
+
+// alertBook holds registered alerts
+type alertBook struct {
+	mu     sync.Mutex
+	alerts []*Alert
+	nextID int
+
+	// Defaults for alerts that don't set their own (ALERT_COOLDOWN and
+	// ALERT_REARM)
+	cooldown     time.Duration
+	rearmPercent float64
+}
+
+// repeats reports whether a re-arms after firing
+func (a *Alert) repeats() bool {
+	return a.cooldown > 0 || a.RearmPercent > 0
+}
+
+// cleared reports whether price is back past the threshold by the re-arm
+// band
+func (a *Alert) cleared(price decimal.Decimal) bool {
+	band := a.threshold.Mul(decimal.NewFromFloat(a.RearmPercent)).Shift(-2)
+	if a.Direction == "above" {
+		return price.LessThan(a.threshold.Sub(band))
+	}
+	return price.GreaterThan(a.threshold.Add(band))
+}
+
+// refresh sets the state fields as of now
+func (a *Alert) refresh(now time.Time) {
+	a.CooldownUntil = 0
+	switch until := time.UnixMilli(a.TriggeredAt).Add(a.cooldown); {
+	case !a.Triggered:
+		a.State = "armed"
+	case !a.repeats():
+		a.State = "fired"
+	case now.Before(until):
+		a.State = "cooldown"
+		a.CooldownUntil = until.UnixMilli()
+	default:
+		a.State = "rearming"
+	}
+}
+
+// parseAlertRule parses rules like "btcusdt>70000" or "ethusdt<3000"
+func parseAlertRule(rule string) (Alert, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+
+	var a Alert
+	var parts []string
+	switch {
+	case strings.Contains(rule, ">"):
+		a.Direction = "above"
+		parts = strings.SplitN(rule, ">", 2)
+	case strings.Contains(rule, "<"):
+		a.Direction = "below"
+		parts = strings.SplitN(rule, "<", 2)
+	default:
+		return a, fmt.Errorf("alert %q must look like symbol>price or symbol<price", rule)
+	}
+
+	a.Symbol = strings.TrimSpace(parts[0])
+	if a.Symbol == "" {
+		return a, fmt.Errorf("alert %q has no symbol", rule)
+	}
+	threshold, err := decimal.NewFromString(strings.TrimSpace(parts[1]))
+	if err != nil || !threshold.IsPositive() {
+		return a, fmt.Errorf("alert %q has an invalid price", rule)
+	}
+	a.threshold = threshold
+	a.Threshold = threshold.InexactFloat64()
+	return a, nil
+}
+
+// add registers an alert and returns it with its ID assigned
+func (b *alertBook) add(a Alert) Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	a.ID = b.nextID
+	a.Triggered = false
+	if a.threshold.IsZero() {
+		a.threshold = decimal.NewFromFloat(a.Threshold)
+	}
+	if a.cooldown == 0 {
+		a.cooldown = b.cooldown
+	}
+	if a.RearmPercent == 0 {
+		a.RearmPercent = b.rearmPercent
+	}
+	a.Cooldown = ""
+	if a.cooldown > 0 {
+		a.Cooldown = a.cooldown.String()
+	}
+	a.refresh(time.Now())
+	b.alerts = append(b.alerts, &a)
+	return a
+}
+
+// remove deletes an alert by ID
+func (b *alertBook) remove(id int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, a := range b.alerts {
+		if a.ID == id {
+			b.alerts = append(b.alerts[:i], b.alerts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// list returns a copy of every alert
+func (b *alertBook) list() []Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	list := make([]Alert, 0, len(b.alerts))
+	for _, a := range b.alerts {
+		a.refresh(now)
+		list = append(list, *a)
+	}
+	return list
+}
+
+// evaluate checks a tick against the symbol's alerts and returns the ones
+// that fired. A rule fires when the price crosses its threshold between two
+// ticks, so a jump straight past the threshold still counts. A fired rule
+// that repeats re-arms only once its cooldown is over and the price has
+// left the re-arm band, so chop around the threshold doesn't refire it.
+// Prices are compared as decimals, so a tick quoted at exactly the
+// threshold counts as reaching it.
+func (b *alertBook) evaluate(symbol string, price float64) []Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	tick := decimal.NewFromFloat(price)
+	var fired []Alert
+	for _, a := range b.alerts {
+		if a.Symbol != symbol {
+			continue
+		}
+
+		prev := a.lastPrice
+		a.lastPrice = tick
+		if a.Triggered {
+			a.refresh(now)
+			if a.State == "rearming" && a.cleared(tick) {
+				a.Triggered = false
+			}
+			continue
+		}
+		if prev.IsZero() {
+			continue
+		}
+
+		crossed := (a.Direction == "above" && prev.LessThan(a.threshold) && !tick.LessThan(a.threshold)) ||
+			(a.Direction == "below" && prev.GreaterThan(a.threshold) && !tick.GreaterThan(a.threshold))
+		if crossed {
+			a.Triggered = true
+			a.Fires++
+			a.TriggeredAt = now.UnixMilli()
+			a.TriggerPrice = price
+			a.refresh(now)
+			fired = append(fired, *a)
+		}
+	}
+	return fired
+}
+
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			Rule      string  `json:"rule"`
+			Symbol    string  `json:"symbol"`
+			Direction string  `json:"direction"`
+			Threshold float64 `json:"threshold"`
+
+			Cooldown     string  `json:"cooldown"`
+			RearmPercent float64 `json:"rearm_percent"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		var a Alert
+		if req.Rule != "" {
+			var err error
+			if a, err = parseAlertRule(req.Rule); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else {
+			if req.Symbol == "" || req.Threshold <= 0 || (req.Direction != "above" && req.Direction != "below") {
+				http.Error(w, "Alert needs symbol, direction (above/below) and a positive threshold", http.StatusBadRequest)
+				return
+			}
+			a = Alert{Symbol: strings.ToLower(req.Symbol), Direction: req.Direction, Threshold: req.Threshold}
+		}
+		if req.Cooldown != "" {
+			d, err := time.ParseDuration(req.Cooldown)
+			if err != nil || d < 0 {
+				http.Error(w, "Invalid cooldown", http.StatusBadRequest)
+				return
+			}
+			a.cooldown = d
+		}
+		if req.RearmPercent < 0 || req.RearmPercent >= 100 {
+			http.Error(w, "rearm_percent must be 0 to 100", http.StatusBadRequest)
+			return
+		}
+		a.RearmPercent = req.RearmPercent
+
+		a = s.alerts.add(a)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(a)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid id", http.StatusBadRequest)
+			return
+		}
+		if !s.alerts.remove(id) {
+			http.Error(w, "Unknown alert", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("type") == "spike" {
+			json.NewEncoder(w).Encode(s.spikes.active())
+			return
+		}
+		json.NewEncoder(w).Encode(s.alerts.list())
+	}
+}
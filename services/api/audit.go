@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Audit log tuning
+const (
+	auditQueueSize     = 1024
+	auditFlushInterval = time.Second
+)
+
+// auditEntry is one sampled tick
+type auditEntry struct {
+	symbol string
+	time   int64
+	price  float64
+}
+
+// auditLog appends every Nth live tick of each symbol to a file, rotated
+// once it grows past maxBytes or is older than maxAge. Ticks are queued on
+// a buffered channel and written by a single goroutine, so the trade
+// handler never waits on disk; a tick arriving at a full queue is dropped
+// and counted instead.
+type auditLog struct {
+	path     string
+	every    int
+	maxBytes int64
+	maxAge   time.Duration // 0 rotates by size only
+	backups  int
+
+	mu      sync.Mutex
+	counts  map[string]int
+	queue   chan auditEntry
+	dropped atomic.Int64
+	done    chan struct{}
+
+	// Owned by the writer goroutine
+	file   *os.File
+	buf    *bufio.Writer
+	size   int64
+	opened time.Time
+}
+
+func newAuditLog(path string, every int, maxBytes int64, maxAge time.Duration, backups int) (*auditLog, error) {
+	a := &auditLog{
+		path:     path,
+		every:    every,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		backups:  backups,
+		counts:   make(map[string]int),
+		queue:    make(chan auditEntry, auditQueueSize),
+		done:     make(chan struct{}),
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	go a.run()
+	return a, nil
+}
+
+// observe samples a tick; a nil log does nothing
+func (a *auditLog) observe(symbol string, t int64, price float64) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.counts[symbol]++
+	sampled := a.counts[symbol]%a.every == 0
+	a.mu.Unlock()
+	if !sampled {
+		return
+	}
+
+	select {
+	case a.queue <- auditEntry{symbol: symbol, time: t, price: price}:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// close writes out everything queued and closes the file
+func (a *auditLog) close() {
+	if a == nil {
+		return
+	}
+	close(a.queue)
+	<-a.done
+}
+
+// open opens the log for appending, continuing an existing file
+func (a *auditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file, a.buf, a.size, a.opened = f, bufio.NewWriter(f), info.Size(), time.Now()
+	return nil
+}
+
+// run writes queued entries until the queue is closed
+func (a *auditLog) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+
+	reported := int64(0)
+	for {
+		select {
+		case e, ok := <-a.queue:
+			if !ok {
+				a.buf.Flush()
+				a.file.Close()
+				return
+			}
+			a.write(e)
+		case <-ticker.C:
+			if err := a.buf.Flush(); err != nil {
+				slog.Error("Audit log write failed", "path", a.path, "err", err)
+			}
+			if dropped := a.dropped.Load(); dropped > reported {
+				slog.Warn("Audit log queue full, ticks dropped", "dropped", dropped)
+				reported = dropped
+			}
+		}
+	}
+}
+
+// write appends one compact line, rotating first when due
+func (a *auditLog) write(e auditEntry) {
+	if a.size >= a.maxBytes || (a.maxAge > 0 && time.Since(a.opened) >= a.maxAge) {
+		if err := a.rotate(); err != nil {
+			slog.Error("Audit log rotation failed", "path", a.path, "err", err)
+		}
+	}
+	line := fmt.Sprintf("%s %s %s\n",
+		time.UnixMilli(e.time).UTC().Format("2006-01-02T15:04:05.000Z07:00"), e.symbol, plainPrice(e.symbol, e.price))
+	n, _ := a.buf.WriteString(line)
+	a.size += int64(n)
+}
+
+// rotate shifts path to path.1, path.1 to path.2 and so on, dropping the
+// oldest beyond backups, and starts a fresh file
+func (a *auditLog) rotate() error {
+	a.buf.Flush()
+	a.file.Close()
+
+	if a.backups > 0 {
+		os.Remove(a.path + "." + strconv.Itoa(a.backups))
+		for i := a.backups - 1; i >= 1; i-- {
+			os.Rename(a.path+"."+strconv.Itoa(i), a.path+"."+strconv.Itoa(i+1))
+		}
+		os.Rename(a.path, a.path+".1")
+	} else {
+		os.Remove(a.path)
+	}
+	return a.open()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// withAuth puts next behind token, if one is set. The probes stay open,
+// and /metrics does too unless metricsAuth.
+func withAuth(token string, metricsAuth bool, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	exempt := slices.Clone(probePaths)
+	if !metricsAuth {
+		exempt = append(exempt, "/metrics")
+	}
+	return requireToken(token, next, exempt...)
+}
+
+// requireToken wraps next so every request must carry
+// "Authorization: Bearer <token>", except for the exempt paths. Others get
+// a 401.
+func requireToken(token string, next http.Handler, exempt ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, path := range exempt {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="crypto-analysis"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
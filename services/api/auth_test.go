@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// authServer serves s's routes behind withAuth
+func authServer(t *testing.T, token string, metricsAuth bool) *httptest.Server {
+	t.Helper()
+	s := newServer(nil, nil, []time.Duration{time.Minute}, newSpikeDetector(0, time.Minute))
+	s.symbols = []string{"btcusdt"}
+	s.restURL = offlineBinance(t)
+	feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: 100, High: 100, Low: 100, Time: time.Now().UnixMilli()})
+	ts := httptest.NewServer(withAuth(token, metricsAuth, s.routes()))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		metricsAuth bool
+		path        string
+		header      string
+		want        int
+	}{
+		{"disabled", "", false, "/api/stats", "", http.StatusOK},
+		{"no header", "s3cret", false, "/api/stats", "", http.StatusUnauthorized},
+		{"right token", "s3cret", false, "/api/stats", "Bearer s3cret", http.StatusOK},
+		{"wrong token", "s3cret", false, "/api/stats", "Bearer s3cre", http.StatusUnauthorized},
+		{"token prefix", "s3cret", false, "/api/stats", "Bearer s3cretx", http.StatusUnauthorized},
+		{"lowercase scheme", "s3cret", false, "/api/stats", "bearer s3cret", http.StatusUnauthorized},
+		{"basic auth", "s3cret", false, "/api/stats", "Basic czNjcmV0", http.StatusUnauthorized},
+		{"other endpoint", "s3cret", false, "/api/symbols", "", http.StatusUnauthorized},
+		{"unknown path", "s3cret", false, "/nope", "", http.StatusUnauthorized},
+		{"liveness probe", "s3cret", false, "/healthz", "", http.StatusOK},
+		{"metrics open", "s3cret", false, "/metrics", "", http.StatusOK},
+		{"metrics closed", "s3cret", true, "/metrics", "", http.StatusUnauthorized},
+		{"metrics with token", "s3cret", true, "/metrics", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := authServer(t, tt.token, tt.metricsAuth)
+			req, _ := http.NewRequest(http.MethodGet, ts.URL+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("GET %s: %s, want %d", tt.path, resp.Status, tt.want)
+			}
+			challenge := resp.Header.Get("WWW-Authenticate")
+			if (resp.StatusCode == http.StatusUnauthorized) != strings.HasPrefix(challenge, "Bearer ") {
+				t.Errorf("WWW-Authenticate %q on a %d", challenge, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAuthWebSocket(t *testing.T) {
+	ts := authServer(t, "s3cret", false)
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("upgrade without a token: %v, want a 401", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer s3cret"}})
+	if err != nil {
+		t.Fatalf("upgrade with the token: %v", err)
+	}
+	conn.Close()
+}
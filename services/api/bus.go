@@ -0,0 +1,163 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Events queued per bus subscriber before new ones are dropped
+const busBuffer = 1024
+
+// priceEvent is one live processed trade as the bus delivers it
+type priceEvent struct {
+	Trade ProcessedMessage
+	Raw   []byte // the NATS payload, for consumers that forward it as is
+}
+
+// bus fans live trades out to any number of subscribers, each draining its
+// own buffered channel, so a slow subscriber drops its own events instead
+// of holding up the NATS callback or the other subscribers. A subscriber
+// that doesn't need every trade can have them coalesced: it then gets at
+// most one per symbol per interval, the latest.
+type bus struct {
+	mu     sync.Mutex
+	subs   map[*subscription]bool
+	wg     sync.WaitGroup // consume goroutines
+	onDrop func(name string)
+}
+
+// subscription is one subscriber's queue
+type subscription struct {
+	name    string
+	events  chan priceEvent
+	dropped uint64 // guarded by bus.mu
+
+	// With every set, events wait in pending, the latest per symbol in
+	// arrival order, for the next flush; guarded by bus.mu
+	every   time.Duration
+	pending map[string]priceEvent
+	order   []string
+	done    chan struct{} // closed with events, stopping the flushes
+}
+
+// newBus returns a bus calling onDrop, when set, for every event a full
+// subscriber misses
+func newBus(onDrop func(name string)) *bus {
+	return &bus{subs: make(map[*subscription]bool), onDrop: onDrop}
+}
+
+// subscribe registers a subscriber named name with room for buffer events,
+// coalesced to one per symbol every interval when every is positive
+func (b *bus) subscribe(name string, buffer int, every time.Duration) *subscription {
+	s := &subscription{name: name, events: make(chan priceEvent, buffer), every: every, done: make(chan struct{})}
+	if every > 0 {
+		s.pending = make(map[string]priceEvent)
+		go func() {
+			t := time.NewTicker(every)
+			defer t.Stop()
+			for {
+				select {
+				case <-s.done:
+					return
+				case <-t.C:
+					b.mu.Lock()
+					b.flush(s)
+					b.mu.Unlock()
+				}
+			}
+		}()
+	}
+	b.mu.Lock()
+	b.subs[s] = true
+	b.mu.Unlock()
+	return s
+}
+
+// unsubscribe removes s and closes its queue once the events already in it
+// have been taken; it is safe to call twice
+func (b *bus) unsubscribe(s *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[s] {
+		b.remove(s)
+	}
+}
+
+// remove queues s's pending events, then closes its queue. Callers hold
+// b.mu.
+func (b *bus) remove(s *subscription) {
+	b.flush(s)
+	delete(b.subs, s)
+	close(s.events)
+	close(s.done)
+}
+
+// consume subscribes and runs handle on each event in order, in its own
+// goroutine, until the subscription is removed
+func (b *bus) consume(name string, buffer int, every time.Duration, handle func(priceEvent)) *subscription {
+	s := b.subscribe(name, buffer, every)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for e := range s.events {
+			handle(e)
+		}
+	}()
+	return s
+}
+
+// publish offers e to every subscriber without waiting on any, or sets it
+// aside as its symbol's latest for coalescing ones
+func (b *bus) publish(e priceEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if s.every <= 0 {
+			b.offer(s, e)
+			continue
+		}
+		if _, ok := s.pending[e.Trade.Symbol]; !ok {
+			s.order = append(s.order, e.Trade.Symbol)
+		}
+		s.pending[e.Trade.Symbol] = e
+	}
+}
+
+// flush offers a coalescing subscriber its pending events. Callers hold
+// b.mu.
+func (b *bus) flush(s *subscription) {
+	for _, symbol := range s.order {
+		b.offer(s, s.pending[symbol])
+	}
+	clear(s.pending)
+	s.order = s.order[:0]
+}
+
+// offer queues e for s, dropping it when s's queue is full. Callers hold
+// b.mu.
+func (b *bus) offer(s *subscription, e priceEvent) {
+	select {
+	case s.events <- e:
+	default:
+		s.dropped++
+		if b.onDrop != nil {
+			b.onDrop(s.name)
+		}
+		// Log the first drop, then ever more rarely
+		if s.dropped&(s.dropped-1) == 0 {
+			slog.Warn("Bus subscriber falling behind, dropping events", "subscriber", s.name, "dropped", s.dropped)
+		}
+	}
+}
+
+// close removes every subscriber and waits for the consume goroutines to
+// handle what was already queued
+func (b *bus) close() {
+	b.mu.Lock()
+	for s := range b.subs {
+		b.remove(s)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
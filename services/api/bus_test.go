@@ -0,0 +1,112 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func event(symbol string, price float64) priceEvent {
+	return priceEvent{Trade: ProcessedMessage{Symbol: symbol, Price: price}}
+}
+
+// drain returns what is left in s's queue once it is closed
+func drain(s *subscription) []ProcessedMessage {
+	var out []ProcessedMessage
+	for e := range s.events {
+		out = append(out, e.Trade)
+	}
+	return out
+}
+
+func TestBusCoalescesToLatest(t *testing.T) {
+	b := newBus(nil)
+	all := b.subscribe("stats", 16, 0)
+	ui := b.subscribe("ui", 16, time.Hour) // flushed only on unsubscribe here
+
+	for _, e := range []priceEvent{event("btcusdt", 1), event("btcusdt", 2), event("ethusdt", 10), event("btcusdt", 3)} {
+		b.publish(e)
+	}
+	b.unsubscribe(ui)
+	b.unsubscribe(ui) // a second time is harmless
+	b.unsubscribe(all)
+
+	if got := drain(all); len(got) != 4 {
+		t.Errorf("uncoalesced subscriber got %d events, want every one of 4", len(got))
+	}
+	// The latest per symbol, in the order the symbols first arrived
+	got := drain(ui)
+	if len(got) != 2 || got[0].Symbol != "btcusdt" || got[0].Price != 3 || got[1].Symbol != "ethusdt" || got[1].Price != 10 {
+		t.Fatalf("coalesced events %+v, want btcusdt at 3 then ethusdt at 10", got)
+	}
+}
+
+func TestBusFirehose(t *testing.T) {
+	const (
+		every  = 20 * time.Millisecond
+		events = 200_000
+	)
+	b := newBus(nil)
+
+	var mu sync.Mutex
+	var stats, ui int
+	var last float64
+	b.consume("stats", events, 0, func(priceEvent) {
+		mu.Lock()
+		stats++
+		mu.Unlock()
+	})
+	b.consume("ui", 4, every, func(e priceEvent) {
+		mu.Lock()
+		ui++
+		last = e.Trade.Price
+		mu.Unlock()
+	})
+
+	// Publishing never waits on a consumer, however fast trades come
+	start := time.Now()
+	for i := range events {
+		b.publish(event("btcusdt", float64(i)))
+	}
+	elapsed := time.Since(start)
+	if elapsed > 2*time.Second {
+		t.Errorf("publishing %d events took %v", events, elapsed)
+	}
+	time.Sleep(3 * every)
+	b.close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if stats != events {
+		t.Errorf("stats consumer got %d of %d events", stats, events)
+	}
+	// One update per interval, plus the final flush
+	if limit := int((elapsed+3*every)/every) + 2; ui < 1 || ui > limit {
+		t.Errorf("ui consumer got %d updates over %v, want at most %d", ui, elapsed+3*every, limit)
+	}
+	if last != events-1 {
+		t.Errorf("ui consumer's last price %v, want the newest, %d", last, events-1)
+	}
+}
+
+func TestBusSlowSubscriberDrops(t *testing.T) {
+	var dropped []string
+	b := newBus(func(name string) { dropped = append(dropped, name) })
+	slow := b.subscribe("slow", 2, 0)
+	fast := b.subscribe("fast", 10, 0)
+
+	for i := range 5 {
+		b.publish(event("btcusdt", float64(i)))
+	}
+	b.close()
+
+	if got := drain(slow); len(got) != 2 || got[0].Price != 0 || got[1].Price != 1 {
+		t.Errorf("slow subscriber kept %+v, want the first two", got)
+	}
+	if len(dropped) != 3 || slow.dropped != 3 {
+		t.Errorf("drops reported %v, counted %d; want slow three times", dropped, slow.dropped)
+	}
+	if got := drain(fast); len(got) != 5 || fast.dropped != 0 {
+		t.Errorf("fast subscriber got %d, dropped %d", len(got), fast.dropped)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of candles kept per symbol and interval
+const candleCapacity = 500
+
+// Candle is one OHLC bar aggregated from ticks
+type Candle struct {
+	Start  int64   `json:"start"` // unix ms, aligned to the interval
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Ticks  int     `json:"ticks"`
+	Closed bool    `json:"closed"`
+}
+
+// candleSeries aggregates ticks into fixed-interval candles, newest last
+type candleSeries struct {
+	interval time.Duration
+	capacity int // candles kept, candleCapacity when 0
+	candles  []Candle
+}
+
+// limit returns the number of candles kept
+func (cs *candleSeries) limit() int {
+	if cs.capacity > 0 {
+		return cs.capacity
+	}
+	return candleCapacity
+}
+
+// add folds a tick into the series, closing the open candle and filling any
+// empty intervals once a tick lands past its boundary
+func (cs *candleSeries) add(price float64, at time.Time) {
+	ms := cs.interval.Milliseconds()
+	start := at.UnixMilli() / ms * ms
+
+	if n := len(cs.candles); n > 0 {
+		last := &cs.candles[n-1]
+		if start < last.Start {
+			return // out-of-order tick for an already closed candle
+		}
+		if start == last.Start {
+			if price > last.High {
+				last.High = price
+			}
+			if price < last.Low {
+				last.Low = price
+			}
+			last.Close = price
+			last.Ticks++
+			return
+		}
+
+		// Boundary passed: close the candle and fill quiet intervals flat
+		last.Closed = true
+		prevClose := last.Close
+		gap := (start - last.Start) / ms
+		if gap > int64(cs.limit()) {
+			gap = int64(cs.limit())
+		}
+		for i := gap - 1; i >= 1; i-- {
+			cs.candles = append(cs.candles, Candle{
+				Start:  start - i*ms,
+				Open:   prevClose,
+				High:   prevClose,
+				Low:    prevClose,
+				Close:  prevClose,
+				Closed: true,
+			})
+		}
+	}
+
+	cs.candles = append(cs.candles, Candle{
+		Start: start,
+		Open:  price,
+		High:  price,
+		Low:   price,
+		Close: price,
+		Ticks: 1,
+	})
+	if len(cs.candles) > cs.limit() {
+		cs.candles = cs.candles[len(cs.candles)-cs.limit():]
+	}
+}
+
+// last returns up to count candles, oldest first. The newest candle is
+// reported closed once its interval has elapsed even if no tick followed.
+func (cs *candleSeries) last(count int, now time.Time) []Candle {
+	candles := cs.candles
+	if count < len(candles) {
+		candles = candles[len(candles)-count:]
+	}
+
+	out := make([]Candle, len(candles))
+	copy(out, candles)
+	if n := len(out); n > 0 && now.UnixMilli() >= out[n-1].Start+cs.interval.Milliseconds() {
+		out[n-1].Closed = true
+	}
+	return out
+}
+
+// candleBook holds candle series per symbol and interval
+type candleBook struct {
+	mu        sync.RWMutex
+	intervals []time.Duration
+	series    map[string]map[time.Duration]*candleSeries
+}
+
+func newCandleBook(intervals []time.Duration) *candleBook {
+	return &candleBook{
+		intervals: intervals,
+		series:    make(map[string]map[time.Duration]*candleSeries),
+	}
+}
+
+// parseIntervals parses a comma-separated list like "1m,5m,15m"
+func parseIntervals(value string) ([]time.Duration, error) {
+	var intervals []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid candle interval %q", part)
+		}
+		intervals = append(intervals, d)
+	}
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("no candle intervals given")
+	}
+	return intervals, nil
+}
+
+// add folds a tick into every interval for symbol
+func (b *candleBook) add(symbol string, price float64, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bySymbol := b.series[symbol]
+	if bySymbol == nil {
+		bySymbol = make(map[time.Duration]*candleSeries)
+		for _, d := range b.intervals {
+			bySymbol[d] = &candleSeries{interval: d}
+		}
+		b.series[symbol] = bySymbol
+	}
+	for _, cs := range bySymbol {
+		cs.add(price, at)
+	}
+}
+
+// remove drops every series for symbol
+func (b *candleBook) remove(symbol string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.series, symbol)
+}
+
+// get returns up to count candles for symbol at interval, oldest first
+func (b *candleBook) get(symbol string, interval time.Duration, count int) ([]Candle, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, d := range b.intervals {
+		if d == interval {
+			cs := b.series[symbol][interval]
+			if cs == nil {
+				return []Candle{}, true
+			}
+			return cs.last(count, time.Now()), true
+		}
+	}
+	return nil, false
+}
+
+// trueRange is the widest of a candle's range and its gaps from the
+// previous close
+func trueRange(c Candle, prevClose float64) float64 {
+	return math.Max(c.High-c.Low, math.Max(math.Abs(c.High-prevClose), math.Abs(c.Low-prevClose)))
+}
+
+// averageTrueRange averages the true range of the last period candles,
+// oldest first. Each needs the close before it, so period+1 are required.
+func averageTrueRange(candles []Candle, period int) (float64, bool) {
+	if period < 1 || len(candles) < period+1 {
+		return 0, false
+	}
+	candles = candles[len(candles)-period-1:]
+	sum := 0.0
+	for i := 1; i < len(candles); i++ {
+		sum += trueRange(candles[i], candles[i-1].Close)
+	}
+	return sum / float64(period), true
+}
+
+// ATR returns the average true range of symbol's closed candles at the
+// primary interval over period candles, false until enough have closed
+func (b *candleBook) ATR(symbol string, period int) (float64, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	cs := b.series[symbol][b.intervals[0]]
+	if cs == nil {
+		return 0, false
+	}
+	candles := cs.last(period+2, time.Now())
+	if n := len(candles); n > 0 && !candles[n-1].Closed {
+		candles = candles[:n-1]
+	}
+	return averageTrueRange(candles, period)
+}
+
+func (s *Server) handleCandles(w http.ResponseWriter, r *http.Request) {
+	symbol := s.requestSymbol(r)
+
+	interval := s.candles.intervals[0]
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, "Invalid interval", http.StatusBadRequest)
+			return
+		}
+		interval = d
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	candles, ok := s.candles.get(symbol, interval, limit)
+	if !ok {
+		http.Error(w, "Interval not aggregated: "+interval.String(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(candles)
+}
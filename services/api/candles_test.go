@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// hand is a short series with a plain range, a gap down and a gap up, and
+// the true range worked out by hand for each candle after the first
+var hand = []struct {
+	high, low, close float64
+	tr               float64
+}{
+	{10, 8, 9, 0},
+	{11, 9, 10.5, 2},      // high-low
+	{12, 11, 11.5, 1.5},   // |high-prevClose|
+	{10, 9, 9.5, 2.5},     // gap down: |low-prevClose|
+	{14, 13, 13.5, 4.5},   // gap up: |high-prevClose|
+	{13.8, 13, 13.2, 0.8}, // previous close inside the range
+}
+
+func handCandles() []Candle {
+	candles := make([]Candle, len(hand))
+	for i, h := range hand {
+		candles[i] = Candle{Open: h.close, High: h.high, Low: h.low, Close: h.close, Closed: true}
+	}
+	return candles
+}
+
+func TestTrueRange(t *testing.T) {
+	candles := handCandles()
+	for i := 1; i < len(candles); i++ {
+		if got := trueRange(candles[i], candles[i-1].Close); math.Abs(got-hand[i].tr) > 1e-12 {
+			t.Errorf("candle %d: true range %v, want %v", i, got, hand[i].tr)
+		}
+	}
+}
+
+func TestAverageTrueRange(t *testing.T) {
+	candles := handCandles()
+	tests := []struct {
+		period int
+		want   float64
+		ready  bool
+	}{
+		{1, 0.8, true},
+		{3, (2.5 + 4.5 + 0.8) / 3, true},
+		{5, (2 + 1.5 + 2.5 + 4.5 + 0.8) / 5, true},
+		{6, 0, false}, // the first candle has no close before it
+		{0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ready := averageTrueRange(candles, tt.period)
+		if ready != tt.ready || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("ATR(%d) = %v, %v; want %v, %v", tt.period, got, ready, tt.want, tt.ready)
+		}
+	}
+}
+
+func TestCandleBookATR(t *testing.T) {
+	// Hour candles, so the open one can't close while the test runs
+	book := newCandleBook([]time.Duration{time.Hour})
+	start := time.Now().Truncate(time.Hour).Add(-time.Duration(len(hand)) * time.Hour)
+	for i, h := range hand {
+		at := start.Add(time.Duration(i) * time.Hour)
+		for _, price := range []float64{h.close, h.high, h.low, h.close} {
+			book.add("btcusdt", price, at)
+			at = at.Add(time.Minute)
+		}
+	}
+	if _, ready := book.ATR("btcusdt", len(hand)); ready {
+		t.Fatal("ATR ready without period+1 closed candles")
+	}
+
+	// A wild open candle doesn't count until it closes
+	now := time.Now()
+	book.add("btcusdt", 100, now)
+	book.add("btcusdt", 1, now)
+	got, ready := book.ATR("btcusdt", 3)
+	if want := (2.5 + 4.5 + 0.8) / 3; !ready || math.Abs(got-want) > 1e-12 {
+		t.Fatalf("ATR(3) = %v, %v; want %v from the closed candles", got, ready, want)
+	}
+	if _, ready := book.ATR("ethusdt", 3); ready {
+		t.Error("ATR ready for a symbol without candles")
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// coin is a built-in pair: base asset priced in quote
+type coin struct {
+	symbol string
+	base   string
+	quote  string
+	name   string
+}
+
+var coins = []coin{
+	{"btcusdt", "btc", "usdt", "Bitcoin (BTC)"},
+	{"ethusdt", "eth", "usdt", "Ethereum (ETH)"},
+	{"solusdt", "sol", "usdt", "Solana (SOL)"},
+	{"bnbusdt", "bnb", "usdt", "Binance Coin (BNB)"},
+	{"xrpusdt", "xrp", "usdt", "Ripple (XRP)"},
+	{"dogeusdt", "doge", "usdt", "Dogecoin (DOGE)"},
+	{"ethbtc", "eth", "btc", "Ethereum (ETH/BTC)"},
+	{"btceur", "btc", "eur", "Bitcoin (BTC/EUR)"},
+}
+
+// Maximum number of remembered custom coins
+const maxCustomCoins = 20
+
+// Quote assets recognized when splitting a custom symbol
+var quoteAssets = []string{"usdt", "usdc", "fdusd", "busd", "btc", "eth", "bnb", "eur", "try"}
+
+var (
+	customCoins []string // most recently used first
+	customPath  string
+	customMu    sync.RWMutex
+)
+
+// splitSymbol splits a symbol like ethbtc into its base and quote assets;
+// quote is empty when no known quote asset ends the symbol
+func splitSymbol(symbol string) (base, quote string) {
+	for _, c := range coins {
+		if c.symbol == symbol {
+			return c.base, c.quote
+		}
+	}
+	for _, q := range quoteAssets {
+		if b := strings.TrimSuffix(symbol, q); b != symbol && b != "" {
+			return b, q
+		}
+	}
+	return symbol, ""
+}
+
+// getCoinShort returns the base asset of a symbol, e.g. ADA for adausdt
+func getCoinShort(symbol string) string {
+	base, _ := splitSymbol(symbol)
+	return strings.ToUpper(base)
+}
+
+// getCoinName returns the display name for a symbol, deriving one like
+// ETH/BTC from the symbol itself for custom coins
+func getCoinName(symbol string) string {
+	for _, c := range coins {
+		if c.symbol == symbol {
+			return c.name
+		}
+	}
+	if base, quote := splitSymbol(symbol); quote != "" {
+		return strings.ToUpper(base + "/" + quote)
+	}
+	return strings.ToUpper(symbol)
+}
+
+// coinInfo is one /api/coins entry
+func coinInfo(symbol, name string) map[string]string {
+	base, quote := splitSymbol(symbol)
+	return map[string]string{
+		"symbol": symbol,
+		"name":   name,
+		"short":  strings.ToUpper(base),
+		"base":   strings.ToUpper(base),
+		"quote":  strings.ToUpper(quote),
+	}
+}
+
+// isKnownCoin reports whether symbol is built in or has been added as custom
+func isKnownCoin(symbol string) bool {
+	if isBuiltinCoin(symbol) {
+		return true
+	}
+	customMu.RLock()
+	defer customMu.RUnlock()
+	for _, c := range customCoins {
+		if c == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultCoinsPath returns ~/.crypto-analysis/coins.json
+func defaultCoinsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "coins.json"
+	}
+	return filepath.Join(home, ".crypto-analysis", "coins.json")
+}
+
+// loadCustomCoins restores remembered custom coins from path
+func loadCustomCoins(path string) {
+	customMu.Lock()
+	defer customMu.Unlock()
+	customPath = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		slog.Warn("Failed to read custom coins", "path", path, "err", err)
+		return
+	}
+	if err := json.Unmarshal(data, &customCoins); err != nil {
+		slog.Warn("Corrupt custom coins", "path", path, "err", err)
+		customCoins = nil
+		return
+	}
+	slog.Info("Loaded custom coins", "count", len(customCoins))
+}
+
+// addCustomCoin moves symbol to the front of the custom list and persists it
+func addCustomCoin(symbol string) {
+	customMu.Lock()
+	defer customMu.Unlock()
+
+	list := []string{symbol}
+	for _, c := range customCoins {
+		if c != symbol && len(list) < maxCustomCoins {
+			list = append(list, c)
+		}
+	}
+	customCoins = list
+
+	if customPath == "" {
+		return
+	}
+	data, _ := json.Marshal(customCoins)
+	if err := os.MkdirAll(filepath.Dir(customPath), 0o755); err != nil {
+		slog.Error("Failed to save custom coins", "path", customPath, "err", err)
+		return
+	}
+	tmp := customPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		slog.Error("Failed to save custom coins", "path", customPath, "err", err)
+		return
+	}
+	if err := os.Rename(tmp, customPath); err != nil {
+		slog.Error("Failed to save custom coins", "path", customPath, "err", err)
+	}
+}
+
+// validateBinanceSymbol checks that symbol is a trading pair on the Binance
+// REST API at base, caching its tick size on the way
+func validateBinanceSymbol(ctx context.Context, base, symbol string) error {
+	info, err := fetchSymbolInfo(ctx, base, symbol)
+	if err != nil {
+		return err
+	}
+	if info.Symbol == "" {
+		return fmt.Errorf("%s is not a Binance trading pair", strings.ToUpper(symbol))
+	}
+	if info.Status != "TRADING" {
+		return fmt.Errorf("%s is not currently trading (%s)", strings.ToUpper(symbol), info.Status)
+	}
+	cacheTickSize(symbol, info.tickSize())
+	return nil
+}
+
+func (s *Server) handleCoins(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var req struct {
+			Symbol string `json:"symbol"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Symbol == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		symbol := strings.ToLower(strings.TrimSpace(req.Symbol))
+
+		if !isKnownCoin(symbol) {
+			if err := validateBinanceSymbol(r.Context(), s.restURL, symbol); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		addCustomCoin(symbol)
+		slog.Info("Added custom coin", "symbol", symbol)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(coinInfo(symbol, getCoinName(symbol)))
+		return
+	}
+
+	list := []map[string]string{}
+	for _, c := range coins {
+		list = append(list, coinInfo(c.symbol, c.name))
+	}
+	customMu.RLock()
+	for _, symbol := range customCoins {
+		if !isBuiltinCoin(symbol) {
+			list = append(list, coinInfo(symbol, getCoinName(symbol)))
+		}
+	}
+	customMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// isBuiltinCoin reports whether symbol is in the hardcoded coins list
+func isBuiltinCoin(symbol string) bool {
+	for _, c := range coins {
+		if c.symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
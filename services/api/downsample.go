@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Default retention of each history tier: every trade (also capped at
+// HISTORY_SIZE), then 1-minute aggregates, then 1-hour aggregates
+const (
+	defaultFullRetention   = 15 * time.Minute
+	defaultMinuteRetention = 6 * time.Hour
+	defaultHourRetention   = 7 * 24 * time.Hour
+)
+
+// retention is how far back each history tier reaches
+type retention struct {
+	full, minutes, hours time.Duration
+}
+
+// tieredHistory aggregates one symbol's trades into 1-minute and 1-hour
+// buckets, each kept only as long as its tier reaches, so long-range shape
+// survives in bounded memory after the trade ring has moved on
+type tieredHistory struct {
+	minutes, hours candleSeries
+}
+
+func newTieredHistory(r retention) *tieredHistory {
+	return &tieredHistory{
+		minutes: candleSeries{interval: time.Minute, capacity: int(r.minutes / time.Minute)},
+		hours:   candleSeries{interval: time.Hour, capacity: int(r.hours / time.Hour)},
+	}
+}
+
+func (h *tieredHistory) add(price float64, at time.Time) {
+	h.minutes.add(price, at)
+	h.hours.add(price, at)
+}
+
+// HistoryPoint is one /api/history?range= point: a trade at full
+// resolution, or a bucket priced at its close
+type HistoryPoint struct {
+	Time  int64   `json:"time"` // unix ms, a bucket's start
+	Price float64 `json:"price"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Ticks int     `json:"ticks"`
+}
+
+// RangeHistory is the /api/history?range= response
+type RangeHistory struct {
+	Symbol     string         `json:"symbol"`
+	Range      string         `json:"range"`
+	Resolution string         `json:"resolution"` // "tick", "1m" or "1h"
+	Points     []HistoryPoint `json:"points"`     // newest first
+}
+
+// rangeHistory returns symbol's history over the span before now at the
+// finest tier that reaches back that far
+func (s *Server) rangeHistory(symbol string, span time.Duration, now time.Time) RangeHistory {
+	h := RangeHistory{Symbol: symbol, Range: span.String(), Points: []HistoryPoint{}}
+	from := now.Add(-span).UnixMilli()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if span <= s.retention.full {
+		h.Resolution = "tick"
+		ring := s.recent[symbol]
+		for i := ring.len() - 1; i >= 0; i-- {
+			t := ring.at(i)
+			if t.Timestamp.UnixMilli() < from {
+				break
+			}
+			h.Points = append(h.Points, HistoryPoint{Time: t.Timestamp.UnixMilli(), Price: t.Price, High: t.Price, Low: t.Price, Ticks: 1})
+		}
+		return h
+	}
+
+	tiers := s.tiers[symbol]
+	if tiers == nil {
+		h.Resolution = "1m"
+		if span > s.retention.minutes {
+			h.Resolution = "1h"
+		}
+		return h
+	}
+	series := &tiers.minutes
+	h.Resolution = "1m"
+	if span > s.retention.minutes {
+		series, h.Resolution = &tiers.hours, "1h"
+	}
+
+	// A bucket counts when any of it falls inside the span
+	interval := series.interval.Milliseconds()
+	for i := len(series.candles) - 1; i >= 0; i-- {
+		c := series.candles[i]
+		if c.Start+interval <= from {
+			break
+		}
+		h.Points = append(h.Points, HistoryPoint{Time: c.Start, Price: c.Close, High: c.High, Low: c.Low, Ticks: c.Ticks})
+	}
+	return h
+}
+
+// handleRangeHistory serves /api/history?range=, from memory whether or
+// not the database is up
+func (s *Server) handleRangeHistory(w http.ResponseWriter, r *http.Request, symbol string) {
+	span, err := time.ParseDuration(r.URL.Query().Get("range"))
+	if err != nil || span <= 0 {
+		http.Error(w, "Invalid range", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.rangeHistory(symbol, span, time.Now()))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+// changed marks the state the conditional endpoints serve as updated. Call
+// it after the update, so a reply is never tagged newer than its body.
+func (s *Server) changed() {
+	s.version.Add(1)
+}
+
+// stateVersion counts every change to the served state, the tick sizes
+// rounding follows included; both counters only grow, so their sum does too
+func (s *Server) stateVersion() uint64 {
+	return s.version.Load() + tickGeneration.Load()
+}
+
+// conditional tags GET and HEAD responses with an ETag made of the state
+// version and the request URL, and answers an If-None-Match carrying it
+// with 304 Not Modified instead of running next. The version is read before
+// next runs, so an update racing the request only costs the client a full
+// reply next time; the server's start time in the tag keeps a restart from
+// reusing one.
+func (s *Server) conditional(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+		h := fnv.New64a()
+		h.Write([]byte(r.URL.RequestURI()))
+		tag := fmt.Sprintf(`"%x-%x-%x"`, s.epoch, s.stateVersion(), h.Sum64())
+
+		w.Header().Set("ETag", tag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if etagMatches(r.Header.Get("If-None-Match"), tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists tag, weakly
+// compared as RFC 9110 asks, or is "*"
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
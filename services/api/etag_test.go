@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETagMatches(t *testing.T) {
+	const tag = `"1-2-3"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{tag, true},
+		{`W/"1-2-3"`, true},
+		{`"0-0-0", "1-2-3"`, true},
+		{`"0-0-0",W/"1-2-3"`, true},
+		{"*", true},
+		{`"1-2-4"`, false},
+		{`1-2-3`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, tag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// request issues method path against ts with an If-None-Match when etag is
+// set
+func request(t *testing.T, ts *httptest.Server, method, path, etag string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, ts.URL+path, nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestPriceConditional(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt", "ethusdt")
+	cacheTickSize("btcusdt", 0.01)
+	cacheTickSize("ethusdt", 0.01)
+	now := time.Now().UnixMilli()
+	feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: 100, High: 100, Low: 100, Time: now})
+
+	first := request(t, ts, http.MethodGet, "/api/price?symbol=btcusdt", "")
+	etag := first.Header.Get("ETag")
+	if first.StatusCode != http.StatusOK || etag == "" || first.Header.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("first poll: %s, ETag %q, Cache-Control %q", first.Status, etag, first.Header.Get("Cache-Control"))
+	}
+	if resp := request(t, ts, http.MethodGet, "/api/price?symbol=btcusdt", etag); resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("unchanged poll: %s, want 304", resp.Status)
+	}
+	if resp := request(t, ts, http.MethodHead, "/api/price?symbol=btcusdt", etag); resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("unchanged HEAD: %s, want 304", resp.Status)
+	}
+
+	// The tag covers the URL, so another symbol's reply isn't taken for it
+	other := request(t, ts, http.MethodGet, "/api/price?symbol=ethusdt", "")
+	if other.Header.Get("ETag") == etag {
+		t.Fatal("two symbols share an ETag")
+	}
+
+	// Any trade moves the version on
+	feed(t, s, ProcessedMessage{Symbol: "ethusdt", Price: 3000, High: 3000, Low: 3000, Time: now})
+	resp := request(t, ts, http.MethodGet, "/api/price?symbol=btcusdt", etag)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Fatalf("after a trade: %s with ETag %q, want 200 and a new tag", resp.Status, resp.Header.Get("ETag"))
+	}
+}
+
+func TestConditionalSkipsWrites(t *testing.T) {
+	s := newServer(nil, nil, []time.Duration{time.Minute}, newSpikeDetector(0, time.Minute))
+	ran := 0
+	h := s.conditional(func(w http.ResponseWriter, r *http.Request) { ran++ })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/stats", nil)
+	req.Header.Set("If-None-Match", "*")
+	h(w, req)
+	if ran != 1 || w.Code != http.StatusOK || w.Header().Get("ETag") != "" {
+		t.Fatalf("POST: ran %d, %d, ETag %q; want it passed through untagged", ran, w.Code, w.Header().Get("ETag"))
+	}
+}
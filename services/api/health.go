@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Probe paths, served without a token since orchestrators don't send one
+var probePaths = []string{"/healthz", "/readyz"}
+
+// handleHealthz answers as long as the process is serving HTTP
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz answers 200 once the primary symbol has had a live tick and
+// its exchange feed is connected, and 503 with the reason otherwise
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	symbol := s.symbols[0]
+	_, priced := s.updated[symbol]
+	state := s.status[symbol].State
+	s.mu.RUnlock()
+
+	switch {
+	case !priced:
+		http.Error(w, "Waiting for the first "+symbol+" price", http.StatusServiceUnavailable)
+	case state != "connected":
+		if state == "" {
+			state = "unknown"
+		}
+		http.Error(w, "Exchange feed for "+symbol+" is "+state, http.StatusServiceUnavailable)
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+// Default and largest number of recent trades kept in memory per symbol
+const (
+	defaultHistorySize = 1000
+	maxHistorySize     = 100000
+)
+
+// ring keeps the most recent values pushed to it in a fixed-size buffer,
+// so retention costs the same memory however long the service runs and
+// pushing never copies what is held
+type ring[T any] struct {
+	values []T
+	next   int // slot the next value goes in once full
+	full   bool
+}
+
+// newRing returns a ring holding up to size values
+func newRing[T any](size int) *ring[T] {
+	return &ring[T]{values: make([]T, 0, size)}
+}
+
+// add appends v, overwriting the oldest value once the ring is full
+func (r *ring[T]) add(v T) {
+	if !r.full {
+		r.values = append(r.values, v)
+		r.full = len(r.values) == cap(r.values)
+		return
+	}
+	r.values[r.next] = v
+	r.next = (r.next + 1) % len(r.values)
+}
+
+// len returns the number of values held
+func (r *ring[T]) len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.values)
+}
+
+// last returns a copy of the newest n values, oldest first. A nil ring
+// holds none.
+func (r *ring[T]) last(n int) []T {
+	n = min(n, r.len())
+	out := make([]T, n)
+	for i := range out {
+		out[i] = r.at(r.len() - n + i)
+	}
+	return out
+}
+
+// snapshot returns a copy of every value held, oldest first
+func (r *ring[T]) snapshot() []T {
+	return r.last(r.len())
+}
+
+// at returns the i-th oldest value
+func (r *ring[T]) at(i int) T {
+	if !r.full {
+		return r.values[i]
+	}
+	return r.values[(r.next+i)%len(r.values)]
+}
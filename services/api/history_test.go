@@ -0,0 +1,69 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRingWraparound(t *testing.T) {
+	for _, size := range []int{1, 3, 5} {
+		r := newRing[int](size)
+		var pushed []int
+		for i := range 3*size + 2 {
+			// Every fill level, from empty through several laps
+			want := pushed[max(0, len(pushed)-size):]
+			if got := r.snapshot(); !slices.Equal(got, want) || r.len() != len(want) {
+				t.Fatalf("size %d after %d pushes: snapshot %v (len %d), want %v", size, i, got, r.len(), want)
+			}
+			for n := 0; n <= size+1; n++ {
+				if got := r.last(n); !slices.Equal(got, want[max(0, len(want)-n):]) {
+					t.Fatalf("size %d after %d pushes: last(%d) = %v", size, i, n, got)
+				}
+			}
+			r.add(i)
+			pushed = append(pushed, i)
+		}
+		if cap(r.values) != size {
+			t.Errorf("size %d ring grew to %d", size, cap(r.values))
+		}
+	}
+}
+
+func TestRingSnapshotIsACopy(t *testing.T) {
+	r := newRing[int](2)
+	r.add(1)
+	r.add(2)
+	r.add(3)
+	snap := r.snapshot()
+	snap[0] = 99
+	if got := r.snapshot(); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("snapshot after editing a copy = %v", got)
+	}
+
+	var none *ring[int]
+	if none.len() != 0 || len(none.last(5)) != 0 {
+		t.Fatal("nil ring isn't empty")
+	}
+}
+
+func TestHistoryKeepsHistorySize(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt")
+	s.historySize = 50
+	now := time.Now().UnixMilli()
+	for i := range 175 {
+		price := float64(1000 + i)
+		feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: price, High: price, Low: 1000, Time: now + int64(i)})
+	}
+
+	var trades []Trade
+	getJSON(t, ts, "/api/history?symbol=btcusdt&source=memory&limit=1000", &trades)
+	if len(trades) != 50 {
+		t.Fatalf("%d trades held, want HISTORY_SIZE's 50", len(trades))
+	}
+	for i, tr := range trades {
+		if want := float64(1174 - i); tr.Price != want {
+			t.Fatalf("trade %d = %v, want %v, newest first", i, tr.Price, want)
+		}
+	}
+}
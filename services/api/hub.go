@@ -0,0 +1,162 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Frames queued per client before new ones are dropped
+const clientBuffer = 64
+
+// How long a single frame write may take before the client is dropped
+const writeTimeout = 5 * time.Second
+
+// client is one connected WebSocket consumer
+type client struct {
+	conn     *websocket.Conn
+	send     chan []byte
+	dropped  int
+	symbols  map[string]bool // pushed symbols, nil for all; guarded by hub.mu
+	maWindow int             // ad-hoc window for stats commands, 0 for the primary one
+}
+
+// hub fans frames out to every connected client without letting a slow
+// client hold up the others
+type hub struct {
+	mu      sync.Mutex
+	clients map[*client]bool
+}
+
+func newHub() *hub {
+	return &hub{clients: make(map[*client]bool)}
+}
+
+// register adds a client and returns the new client count
+func (h *hub) register(c *client) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = true
+	return len(h.clients)
+}
+
+// unregister removes a client and closes its queue; it is safe to call twice
+func (h *hub) unregister(c *client) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+	return len(h.clients)
+}
+
+// closeAll disconnects every client; each writePump sends a close frame
+// once its queue drains
+func (h *hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// subscribe limits the trades pushed to c to symbols, or lifts the limit
+// when there are none
+func (h *hub) subscribe(c *client, symbols []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c.symbols = nil
+	if len(symbols) > 0 {
+		c.symbols = make(map[string]bool, len(symbols))
+		for _, sym := range symbols {
+			c.symbols[sym] = true
+		}
+	}
+}
+
+// reply queues a command response for c unless it has disconnected,
+// reporting whether it was queued
+func (h *hub) reply(c *client, frame []byte) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.clients[c] {
+		return false
+	}
+	select {
+	case c.send <- frame:
+		return true
+	default:
+		return false
+	}
+}
+
+// broadcast queues a trade frame of symbol for every client subscribed to
+// it, dropping it for clients whose queue is full
+func (h *hub) broadcast(symbol string, frame []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients {
+		if c.symbols != nil && !c.symbols[symbol] {
+			continue
+		}
+		select {
+		case c.send <- frame:
+		default:
+			c.dropped++
+			if c.dropped%100 == 1 {
+				slog.Warn("Slow WebSocket client", "remote", c.conn.RemoteAddr().String(), "dropped", c.dropped)
+			}
+		}
+	}
+}
+
+// writePump sends queued frames until the queue is closed or a write fails
+func (c *client) writePump() {
+	defer c.conn.Close()
+	for frame := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := c.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+			return
+		}
+	}
+	c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Warn("WebSocket upgrade failed", "remote", r.RemoteAddr, "err", err)
+		return
+	}
+
+	conn.SetReadLimit(maxCommandBytes)
+	c := &client{conn: conn, send: make(chan []byte, clientBuffer)}
+	total := s.hub.register(c)
+	s.metrics.wsClients.Set(float64(total))
+	slog.Info("Client connected", "remote", r.RemoteAddr, "total", total)
+	go c.writePump()
+
+	// Reading serves commands and detects disconnects
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			total := s.hub.unregister(c)
+			s.metrics.wsClients.Set(float64(total))
+			slog.Info("Client disconnected", "remote", r.RemoteAddr, "total", total)
+			return
+		}
+		if !s.hub.reply(c, s.command(c, frame)) {
+			slog.Warn("WebSocket reply dropped", "remote", r.RemoteAddr)
+		}
+	}
+}
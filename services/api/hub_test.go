@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialWS connects a WebSocket client to ts's /ws and waits until the hub
+// has taken it in
+func dialWS(t *testing.T, s *Server, ts *httptest.Server) *websocket.Conn {
+	t.Helper()
+	before := clientCount(s.hub)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	waitFor(t, func() bool { return clientCount(s.hub) > before })
+	return conn
+}
+
+func clientCount(h *hub) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// waitFor polls cond for up to five seconds
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("timed out")
+}
+
+func TestWebSocketPushesTrades(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt")
+	conn := dialWS(t, s, ts)
+
+	for i := 0; i < 3; i++ {
+		s.hub.broadcast("btcusdt", []byte(fmt.Sprintf(`{"symbol":"btcusdt","price":%d}`, 100+i)))
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for i := 0; i < 3; i++ {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf(`{"symbol":"btcusdt","price":%d}`, 100+i); string(frame) != want {
+			t.Errorf("frame %d = %s, want %s", i, frame, want)
+		}
+	}
+
+	// A disconnect unregisters the client
+	conn.Close()
+	waitFor(t, func() bool { return clientCount(s.hub) == 0 })
+}
+
+func TestSlowClientDropsFrames(t *testing.T) {
+	// Server-side connections whose queues nothing drains
+	conns := make(chan *websocket.Conn, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conns <- conn
+	}))
+	defer ts.Close()
+	for i := 0; i < 2; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+
+	h := newHub()
+	slow := &client{conn: <-conns, send: make(chan []byte, 2)}
+	other := &client{conn: <-conns, send: make(chan []byte, 10), symbols: map[string]bool{"ethusdt": true}}
+	h.register(slow)
+	h.register(other)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			h.broadcast("btcusdt", []byte("frame"))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast blocked on a slow client")
+	}
+
+	if len(slow.send) != 2 || slow.dropped != 3 {
+		t.Errorf("slow client: %d queued, %d dropped; want 2 and 3", len(slow.send), slow.dropped)
+	}
+	if len(other.send) != 0 {
+		t.Errorf("client subscribed to ethusdt got %d btcusdt frames", len(other.send))
+	}
+
+	h.unregister(slow)
+	h.unregister(slow) // safe twice
+	if n := clientCount(h); n != 1 {
+		t.Errorf("%d clients after unregistering one of two", n)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"syscall"
+)
+
+// Default HTTP port, and how many after it to try when it is taken
+const (
+	defaultPort   = 8080
+	portFallbacks = 10
+)
+
+// listen binds the HTTP port. A port chosen with PORT must be free; the
+// default one moves on to the next free port so a stray process on 8080
+// doesn't keep the API from starting.
+func listen(port int, explicit bool) (net.Listener, error) {
+	tries := 1
+	if !explicit {
+		tries += portFallbacks
+	}
+	for i := 0; i < tries; i++ {
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port+i))
+		if err == nil {
+			if i > 0 {
+				slog.Warn("Port in use, listening on the next free one", "wanted", port, "port", port+i)
+			}
+			return ln, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			return nil, err
+		}
+	}
+	if explicit {
+		return nil, fmt.Errorf("port %d is already in use; stop whatever holds it or set PORT to another", port)
+	}
+	return nil, fmt.Errorf("ports %d to %d are all in use; set PORT to a free one", port, port+portFallbacks)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// setupLogging installs a structured logger writing to stderr. LOG_LEVEL
+// sets the minimum level (debug, info, warn or error) and LOG_FORMAT=json
+// switches from key=value text to JSON lines. Output from the standard log
+// package goes through the same logger at info level.
+func setupLogging() {
+	var level slog.Level
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid LOG_LEVEL %q: must be debug, info, warn or error\n", v)
+			os.Exit(1)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch v := os.Getenv("LOG_FORMAT"); v {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid LOG_FORMAT %q: must be text or json\n", v)
+		os.Exit(1)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// fatal logs msg at error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// offlineBinance stands in for the Binance REST API, knowing no symbol, so
+// tick size lookups fail fast instead of reaching the exchange. Prices keep
+// their magnitude-based decimals unless a test caches a tick size.
+func offlineBinance(t *testing.T) string {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":-1121,"msg":"Invalid symbol."}`, http.StatusBadRequest)
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+// newTestServer is a Server with no database, NATS or Binance, tracking
+// symbols, serving its routes over httptest
+func newTestServer(t *testing.T, symbols ...string) (*Server, *httptest.Server) {
+	t.Helper()
+	s := newServer(nil, nil, []time.Duration{time.Minute}, newSpikeDetector(0, time.Minute))
+	s.symbols = symbols
+	s.restURL = offlineBinance(t)
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// feed hands s processed trades as they arrive over trades.processed
+func feed(t *testing.T, s *Server, trades ...ProcessedMessage) {
+	t.Helper()
+	for _, p := range trades {
+		data, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.handleProcessed(data)
+	}
+}
+
+// getJSON fetches path from ts into v, failing on anything but 200
+func getJSON(t *testing.T, ts *httptest.Server, path string, v any) {
+	t.Helper()
+	resp, err := http.Get(ts.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+}
+
+func TestStatsFromProcessedTrades(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt")
+
+	now := time.Now().UnixMilli()
+	prices := []float64{100, 104, 98, 102}
+	var trades []ProcessedMessage
+	high, low := 0.0, 1e9
+	for i, price := range prices {
+		high, low = max(high, price), min(low, price)
+		trades = append(trades, ProcessedMessage{
+			Symbol:         "btcusdt",
+			Price:          price,
+			Quantity:       0.5,
+			MovingAverage:  101 + float64(i),
+			MovingAverages: map[string]float64{"20": 101 + float64(i)},
+			High:           high,
+			Low:            low,
+			RSI:            -1,
+			EMA:            -1,
+			Time:           now - int64(len(prices)-i)*1000,
+		})
+	}
+	// Another pair's trades mustn't leak in
+	feed(t, s, append(trades, ProcessedMessage{Symbol: "ethusdt", Price: 3000, High: 3000, Low: 3000, Time: now})...)
+
+	var st Stats
+	getJSON(t, ts, "/api/stats?symbol=btcusdt", &st)
+	if st.SchemaVersion != statsSchemaVersion || st.Symbol != "btcusdt" || st.Status != "live" {
+		t.Fatalf("header fields: %+v", st)
+	}
+	if st.Price != 102 {
+		t.Errorf("price = %v, want 102", st.Price)
+	}
+	if st.Session.High != 104 || st.Session.Low != 98 {
+		t.Errorf("session high/low = %v/%v, want 104/98", st.Session.High, st.Session.Low)
+	}
+	if st.Rolling24h.High != 104 || st.Rolling24h.Low != 98 {
+		t.Errorf("24h high/low = %v/%v, want 104/98", st.Rolling24h.High, st.Rolling24h.Low)
+	}
+	if st.Indicators.MovingAverage != 104 || st.Indicators.MovingAverages["20"] != 104 {
+		t.Errorf("moving average = %v %v, want the processor's latest, 104", st.Indicators.MovingAverage, st.Indicators.MovingAverages)
+	}
+	if s.MovingAverage("btcusdt", 0) != 104 || s.High("btcusdt") != 104 || s.Low("btcusdt") != 98 {
+		t.Errorf("accessors = %v %v/%v, want 104 104/98", s.MovingAverage("btcusdt", 0), s.High("btcusdt"), s.Low("btcusdt"))
+	}
+	if got, want := s.MovingAverage("btcusdt", 2), (98.0+102)/2; got != want {
+		t.Errorf("MovingAverage over 2 trades = %v, want %v", got, want)
+	}
+	if st.HistoryLen != len(prices) || st.Volume != 2 {
+		t.Errorf("history_len %d, volume %v; want %d and 2", st.HistoryLen, st.Volume, len(prices))
+	}
+
+	// An ad-hoc window averages the trades held
+	getJSON(t, ts, "/api/stats?symbol=btcusdt&ma_window=2", &st)
+	if st.Indicators.MAWindow != 2 || st.Indicators.MovingAverage != 100 {
+		t.Errorf("ma_window=2: window %d, average %v; want 2 and 100", st.Indicators.MAWindow, st.Indicators.MovingAverage)
+	}
+}
+
+func TestStatsConditional(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt")
+	feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: 100, High: 100, Low: 100, Time: time.Now().UnixMilli()})
+
+	get := func(etag string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/stats?symbol=btcusdt", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	first := get("")
+	etag := first.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if resp := get(etag); resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("unchanged: %s, want 304", resp.Status)
+	}
+	feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: 101, High: 101, Low: 100, Time: time.Now().UnixMilli()})
+	if resp := get(etag); resp.StatusCode != http.StatusOK {
+		t.Fatalf("after a trade: %s, want 200", resp.Status)
+	}
+}
+
+func TestIgnoresNonPositivePrices(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt")
+	now := time.Now().UnixMilli()
+	feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: 100, High: 100, Low: 100, Time: now})
+	feed(t, s,
+		ProcessedMessage{Symbol: "btcusdt", Price: 0, High: 100, Low: 0, Time: now + 1},
+		ProcessedMessage{Symbol: "btcusdt", Price: -5, High: 100, Low: -5, Time: now + 2},
+	)
+	s.handleProcessed([]byte(`{"symbol":"btcusdt","time":1}`))
+
+	var st Stats
+	getJSON(t, ts, "/api/stats?symbol=btcusdt", &st)
+	if st.Price != 100 || st.Session.Low != 100 || st.Rolling24h.Low != 100 {
+		t.Fatalf("price %v, session low %v, 24h low %v after bogus prices; want 100 throughout",
+			st.Price, st.Session.Low, st.Rolling24h.Low)
+	}
+	var history []map[string]any
+	getJSON(t, ts, "/api/history?symbol=btcusdt&source=memory", &history)
+	if len(history) != 1 {
+		t.Fatalf("history has %d trades, want the one real trade", len(history))
+	}
+}
+
+// TestConcurrentTradesAndReads feeds trades while clients read every
+// per-symbol endpoint. Run with -race.
+func TestConcurrentTradesAndReads(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt", "ethusdt")
+	paths := []string{
+		"/api/price?symbol=btcusdt", "/api/prices", "/api/stats?symbol=ethusdt", "/api/stats?symbol=btcusdt&ma_window=5",
+		"/api/history?symbol=btcusdt&source=memory", "/api/trades?symbol=btcusdt", "/api/returns?symbol=ethusdt",
+		"/api/candles?symbol=btcusdt&interval=1m", "/api/symbols", "/api/status?symbol=btcusdt", "/metrics",
+	}
+
+	var wg sync.WaitGroup
+	for _, sym := range []string{"btcusdt", "ethusdt"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				price := 100 + float64(i%7)
+				feed(t, s, ProcessedMessage{Symbol: sym, Price: price, Quantity: 1, MovingAverage: price, High: 107, Low: 100, Time: time.Now().UnixMilli()})
+			}
+		}()
+	}
+	for _, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 30; i++ {
+				resp, err := http.Get(ts.URL + path)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("GET %s: %s", path, resp.Status)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var st Stats
+	getJSON(t, ts, "/api/stats?symbol=btcusdt", &st)
+	if st.HistoryLen != 500 {
+		t.Errorf("history_len = %d after 500 trades", st.HistoryLen)
+	}
+}
+
+func TestSymbolSwitchDropsUntradedState(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt", "ethusdt")
+	feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: 100, High: 100, Low: 100, Time: time.Now().UnixMilli()})
+	// ethusdt only ever reported a feed status
+	s.mu.Lock()
+	s.status["ethusdt"] = ConnectionStatus{Symbol: "ethusdt", State: "connected"}
+	s.mu.Unlock()
+
+	resp, err := http.Post(ts.URL+"/api/symbol", "application/json", strings.NewReader(`{"symbols":["solusdt"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /api/symbol: %s", resp.Status)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if left := s.symbolsWithState(); len(left) != 0 {
+		t.Errorf("state left for %v after switching away", left)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Feed states exported as the feed_state gauge
+var feedStates = []string{"connected", "reconnecting", "unavailable", "failed", "down"}
+
+// metrics are the Prometheus collectors exported at /metrics, registered
+// on their own registry so each Server has independent label sets
+type metrics struct {
+	registry *prometheus.Registry
+
+	price         *prometheus.GaugeVec
+	movingAverage *prometheus.GaugeVec
+	high          *prometheus.GaugeVec
+	low           *prometheus.GaugeVec
+	updates       *prometheus.CounterVec
+	feedState     *prometheus.GaugeVec
+	droppedTrades *prometheus.GaugeVec
+	busDropped    *prometheus.CounterVec
+	wsClients     prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	symbolGauge := func(name, help string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "crypto",
+			Name:      name,
+			Help:      help,
+		}, []string{"symbol"})
+	}
+
+	m := &metrics{
+		registry:      prometheus.NewRegistry(),
+		price:         symbolGauge("price", "Latest traded price."),
+		movingAverage: symbolGauge("moving_average", "Moving average over the primary window."),
+		high:          symbolGauge("session_high", "Highest price this session."),
+		low:           symbolGauge("session_low", "Lowest price this session."),
+		updates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "crypto",
+			Name:      "updates_total",
+			Help:      "Processed trades received.",
+		}, []string{"symbol"}),
+		feedState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "crypto",
+			Name:      "feed_state",
+			Help:      "Exchange connection state; 1 for the current state, 0 otherwise.",
+		}, []string{"symbol", "exchange", "state"}),
+		droppedTrades: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "crypto",
+			Name:      "dropped_trades",
+			Help:      "Live trades ingestion dropped on a full publish queue since it began streaming the symbol.",
+		}, []string{"symbol", "exchange"}),
+		busDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "crypto",
+			Name:      "bus_dropped_total",
+			Help:      "Live trades a bus subscriber missed because its queue was full.",
+		}, []string{"subscriber"}),
+		wsClients: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "crypto",
+			Name:      "websocket_clients",
+			Help:      "Connected /ws clients.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.price, m.movingAverage, m.high, m.low, m.updates, m.feedState, m.droppedTrades, m.busDropped, m.wsClients,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// observe records a processed trade
+func (m *metrics) observe(msg ProcessedMessage) {
+	m.price.WithLabelValues(msg.Symbol).Set(msg.Price)
+	m.movingAverage.WithLabelValues(msg.Symbol).Set(msg.MovingAverage)
+	m.high.WithLabelValues(msg.Symbol).Set(msg.High)
+	m.low.WithLabelValues(msg.Symbol).Set(msg.Low)
+	m.updates.WithLabelValues(msg.Symbol).Inc()
+}
+
+// setFeedState marks status.State as the symbol's current feed state and
+// records its dropped trades
+func (m *metrics) setFeedState(status ConnectionStatus) {
+	m.droppedTrades.WithLabelValues(status.Symbol, status.Exchange).Set(float64(status.DroppedTrades))
+	for _, state := range feedStates {
+		value := 0.0
+		if state == status.State {
+			value = 1
+		}
+		m.feedState.WithLabelValues(status.Symbol, status.Exchange, state).Set(value)
+	}
+}
+
+// busDrop counts an event the named bus subscriber missed
+func (m *metrics) busDrop(name string) {
+	m.busDropped.WithLabelValues(name).Inc()
+}
+
+// remove drops every series for symbol once it is no longer tracked
+func (m *metrics) remove(symbol string) {
+	labels := prometheus.Labels{"symbol": symbol}
+	m.price.DeletePartialMatch(labels)
+	m.movingAverage.DeletePartialMatch(labels)
+	m.high.DeletePartialMatch(labels)
+	m.low.DeletePartialMatch(labels)
+	m.updates.DeletePartialMatch(labels)
+	m.feedState.DeletePartialMatch(labels)
+	m.droppedTrades.DeletePartialMatch(labels)
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Level is one price level of an order book
+type Level struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// DepthUpdate is a top-of-book snapshot from the ingestion service
+type DepthUpdate struct {
+	Symbol   string  `json:"symbol"`
+	Exchange string  `json:"exchange"`
+	Bids     []Level `json:"bids"` // best (highest) first
+	Asks     []Level `json:"asks"` // best (lowest) first
+	UpdateID int64   `json:"update_id"`
+	Time     int64   `json:"time"`
+}
+
+// orderBooks holds the latest depth snapshot per symbol
+type orderBooks struct {
+	mu    sync.RWMutex
+	books map[string]DepthUpdate
+}
+
+func newOrderBooks() *orderBooks {
+	return &orderBooks{books: make(map[string]DepthUpdate)}
+}
+
+// update stores a snapshot unless it is older than the one held; an older
+// ID from a fresh feed (ingestion restarted) is accepted after a gap
+func (ob *orderBooks) update(u DepthUpdate) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if cur, ok := ob.books[u.Symbol]; ok && u.UpdateID <= cur.UpdateID && u.Time-cur.Time < 5000 {
+		return
+	}
+	ob.books[u.Symbol] = u
+}
+
+func (ob *orderBooks) get(symbol string) (DepthUpdate, bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	u, ok := ob.books[symbol]
+	return u, ok
+}
+
+func (ob *orderBooks) remove(symbol string) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	delete(ob.books, symbol)
+}
+
+func (s *Server) handleOrderBook(w http.ResponseWriter, r *http.Request) {
+	symbol := s.requestSymbol(r)
+
+	levels := 10
+	if v := r.URL.Query().Get("levels"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid levels", http.StatusBadRequest)
+			return
+		}
+		levels = n
+	}
+
+	book, ok := s.books.get(symbol)
+	if !ok {
+		http.Error(w, "No order book for "+symbol, http.StatusNotFound)
+		return
+	}
+	if len(book.Bids) > levels {
+		book.Bids = book.Bids[:levels]
+	}
+	if len(book.Asks) > levels {
+		book.Asks = book.Asks[:levels]
+	}
+
+	resp := map[string]interface{}{
+		"symbol":    symbol,
+		"bids":      book.Bids,
+		"asks":      book.Asks,
+		"update_id": book.UpdateID,
+		"time":      book.Time,
+	}
+	if len(book.Bids) > 0 && len(book.Asks) > 0 {
+		bid, ask := book.Bids[0].Price, book.Asks[0].Price
+		resp["best_bid"] = bid
+		resp["best_ask"] = ask
+		resp["spread"] = ask - bid
+		resp["spread_percent"] = (ask - bid) / ((ask + bid) / 2) * 100
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
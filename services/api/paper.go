@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Number of simulated fills remembered
+const paperTradeCapacity = 100
+
+// Decimal places kept when averaging entry prices, well past any
+// exchange's tick
+const paperPlaces = 16
+
+// PaperPosition is a simulated holding in one symbol, as the API shows it
+type PaperPosition struct {
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"` // negative when short
+	AvgEntry      float64 `json:"avg_entry"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	Price         float64 `json:"price"`          // latest, 0 when the symbol isn't tracked
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // at Price
+}
+
+// PaperTrade is one simulated fill
+type PaperTrade struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"` // "buy" or "sell"
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Time     int64   `json:"time"`
+}
+
+// paperHolding is a position as the book keeps and saves it. Its amounts
+// are decimals, so PnL realized over many fills doesn't pick up float
+// rounding on each one; it is saved as decimal strings and still loads the
+// plain numbers older files have.
+type paperHolding struct {
+	Symbol      string          `json:"symbol"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	AvgEntry    decimal.Decimal `json:"avg_entry"`
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+}
+
+// unrealized is h's open PnL at price
+func (h paperHolding) unrealized(price decimal.Decimal) decimal.Decimal {
+	return h.Quantity.Mul(price.Sub(h.AvgEntry))
+}
+
+// position returns h for display, valued at price when priced
+func (h paperHolding) position(price float64, priced bool) PaperPosition {
+	p := PaperPosition{
+		Symbol:      h.Symbol,
+		Quantity:    h.Quantity.InexactFloat64(),
+		AvgEntry:    h.AvgEntry.InexactFloat64(),
+		RealizedPnL: h.RealizedPnL.InexactFloat64(),
+		Price:       price,
+	}
+	if priced {
+		p.UnrealizedPnL = h.unrealized(decimal.NewFromFloat(price)).InexactFloat64()
+	}
+	return p
+}
+
+// paperBook is a simulated portfolio filled at the live price, persisted to
+// path after every trade
+type paperBook struct {
+	mu        sync.Mutex
+	path      string
+	positions map[string]*paperHolding
+	trades    *ring[PaperTrade]
+}
+
+// paperFile is the on-disk portfolio
+type paperFile struct {
+	Positions []paperHolding `json:"positions"`
+	Trades    []PaperTrade   `json:"trades"`
+}
+
+// defaultPortfolioPath returns ~/.crypto-analysis/portfolio.json
+func defaultPortfolioPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "portfolio.json"
+	}
+	return filepath.Join(home, ".crypto-analysis", "portfolio.json")
+}
+
+// newPaperBook returns an empty portfolio saved to path, or kept in memory
+// when path is ""
+func newPaperBook(path string) *paperBook {
+	return &paperBook{
+		path:      path,
+		positions: make(map[string]*paperHolding),
+		trades:    newRing[PaperTrade](paperTradeCapacity),
+	}
+}
+
+// loadPaperBook restores the portfolio at path, starting empty when there
+// is none
+func loadPaperBook(path string) *paperBook {
+	b := newPaperBook(path)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b
+	}
+	if err != nil {
+		slog.Warn("Failed to read portfolio", "path", path, "err", err)
+		return b
+	}
+	var file paperFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		slog.Warn("Corrupt portfolio", "path", path, "err", err)
+		return b
+	}
+	for _, p := range file.Positions {
+		b.positions[p.Symbol] = &p
+	}
+	for _, t := range file.Trades {
+		b.trades.add(t)
+	}
+	slog.Info("Loaded paper portfolio", "positions", len(b.positions))
+	return b
+}
+
+// save writes the portfolio atomically. Callers hold b.mu.
+func (b *paperBook) save() {
+	if b.path == "" {
+		return
+	}
+	file := paperFile{Trades: b.trades.snapshot()}
+	for _, p := range b.positions {
+		file.Positions = append(file.Positions, *p)
+	}
+	data, _ := json.Marshal(file)
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		slog.Error("Failed to save portfolio", "path", b.path, "err", err)
+		return
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		slog.Error("Failed to save portfolio", "path", b.path, "err", err)
+		return
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		slog.Error("Failed to save portfolio", "path", b.path, "err", err)
+	}
+}
+
+// fill applies a trade at price. Trades against the position realize PnL
+// on the closed part; any excess opens a position the other way at price.
+func (b *paperBook) fill(symbol, side string, quantity, price float64) (PaperTrade, paperHolding) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := b.positions[symbol]
+	if p == nil {
+		p = &paperHolding{Symbol: symbol}
+		b.positions[symbol] = p
+	}
+
+	// NewFromFloat picks the shortest decimal that round-trips, the text
+	// the quantity was sent as and the price was quoted in
+	qty, px := decimal.NewFromFloat(quantity), decimal.NewFromFloat(price)
+	signed := qty
+	if side == "sell" {
+		signed = qty.Neg()
+	}
+	held := p.Quantity.Abs()
+	if p.Quantity.IsZero() || p.Quantity.IsPositive() == signed.IsPositive() {
+		// Opening or adding: average the entry
+		total := held.Add(qty)
+		p.AvgEntry = held.Mul(p.AvgEntry).Add(qty.Mul(px)).DivRound(total, paperPlaces)
+		p.Quantity = p.Quantity.Add(signed)
+	} else {
+		closed := decimal.Min(qty, held)
+		if p.Quantity.IsPositive() {
+			p.RealizedPnL = p.RealizedPnL.Add(closed.Mul(px.Sub(p.AvgEntry)))
+		} else {
+			p.RealizedPnL = p.RealizedPnL.Add(closed.Mul(p.AvgEntry.Sub(px)))
+		}
+		p.Quantity = p.Quantity.Add(signed)
+		switch {
+		case p.Quantity.IsZero():
+			p.AvgEntry = decimal.Zero
+		case qty.GreaterThan(closed):
+			p.AvgEntry = px // flipped
+		}
+	}
+
+	t := PaperTrade{Symbol: symbol, Side: side, Quantity: quantity, Price: price, Time: time.Now().UnixMilli()}
+	b.trades.add(t)
+	b.save()
+	return t, *p
+}
+
+// reset clears every position and fill
+func (b *paperBook) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.positions = make(map[string]*paperHolding)
+	b.trades = newRing[PaperTrade](paperTradeCapacity)
+	b.save()
+}
+
+// snapshot returns the positions ordered by symbol and the fills newest
+// first
+func (b *paperBook) snapshot() ([]paperHolding, []PaperTrade) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	positions := make([]paperHolding, 0, len(b.positions))
+	for _, p := range b.positions {
+		positions = append(positions, *p)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+
+	trades := b.trades.snapshot()
+	slices.Reverse(trades)
+	return positions, trades
+}
+
+func (s *Server) handlePortfolio(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			Symbol   string  `json:"symbol"`
+			Side     string  `json:"side"`
+			Quantity float64 `json:"quantity"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if req.Side != "buy" && req.Side != "sell" {
+			http.Error(w, "Side must be buy or sell", http.StatusBadRequest)
+			return
+		}
+		if !(req.Quantity > 0) || math.IsInf(req.Quantity, 0) {
+			http.Error(w, "Quantity must be positive", http.StatusBadRequest)
+			return
+		}
+		symbol := strings.ToLower(req.Symbol)
+		if symbol == "" {
+			symbol = s.requestSymbol(r)
+		}
+		price, ok := s.Price(symbol)
+		if !ok {
+			http.Error(w, fmt.Sprintf("No live price for %s", symbol), http.StatusConflict)
+			return
+		}
+
+		trade, holding := s.paper.fill(symbol, req.Side, req.Quantity, price)
+		slog.Info("Paper trade", "side", trade.Side, "quantity", trade.Quantity, "symbol", symbol, "price", FormatPrice(symbol, price))
+		position := holding.position(price, true)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"trade": trade, "position": position})
+
+	case http.MethodDelete:
+		s.paper.reset()
+		slog.Info("Paper portfolio reset")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		holdings, trades := s.paper.snapshot()
+		positions := make([]PaperPosition, len(holdings))
+		var realized, unrealized decimal.Decimal
+		for i, h := range holdings {
+			price, priced := s.Price(h.Symbol)
+			positions[i] = h.position(price, priced)
+			realized = realized.Add(h.RealizedPnL)
+			if priced {
+				unrealized = unrealized.Add(h.unrealized(decimal.NewFromFloat(price)))
+			}
+		}
+		if len(trades) > 20 {
+			trades = trades[:20]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"positions":      positions,
+			"trades":         trades,
+			"realized_pnl":   realized.InexactFloat64(),
+			"unrealized_pnl": unrealized.InexactFloat64(),
+			"total_pnl":      realized.Add(unrealized).InexactFloat64(),
+		})
+	}
+}
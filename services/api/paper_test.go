@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPaperPnLHasNoDrift(t *testing.T) {
+	b := newPaperBook("")
+
+	// Ten thousand round trips of 0.1 bought at 0.1 and sold at 0.3, none
+	// of which float64 holds exactly, realize exactly 200
+	var h paperHolding
+	for range 10000 {
+		b.fill("dustusdt", "buy", 0.1, 0.1)
+		_, h = b.fill("dustusdt", "sell", 0.1, 0.3)
+	}
+	if !h.RealizedPnL.Equal(decimal.NewFromInt(200)) || !h.Quantity.IsZero() || !h.AvgEntry.IsZero() {
+		t.Fatalf("after the round trips: %+v, want 200 realized and flat", h)
+	}
+
+	// Averaging in at 60000.25 and selling past flat: 0.4 closed at 0.75
+	// each, the rest opened short
+	b.fill("btcusdt", "buy", 0.3, 60000.1)
+	b.fill("btcusdt", "buy", 0.1, 60000.7)
+	_, h = b.fill("btcusdt", "sell", 0.5, 60001)
+	if !h.RealizedPnL.Equal(decimal.RequireFromString("0.3")) {
+		t.Errorf("realized %s, want 0.3", h.RealizedPnL)
+	}
+	if !h.Quantity.Equal(decimal.RequireFromString("-0.1")) || !h.AvgEntry.Equal(decimal.NewFromInt(60001)) {
+		t.Errorf("flipped to %s at %s, want -0.1 at 60001", h.Quantity, h.AvgEntry)
+	}
+	if got := h.position(60000, true).UnrealizedPnL; got != 0.1 {
+		t.Errorf("unrealized %v, want 0.1", got)
+	}
+}
+
+func TestPaperBookReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portfolio.json")
+	b := newPaperBook(path)
+	for range 1000 {
+		b.fill("dustusdt", "buy", 0.001, 0.07)
+	}
+	b.fill("dustusdt", "sell", 0.5, 0.09)
+
+	positions, trades := loadPaperBook(path).snapshot()
+	if len(positions) != 1 || len(trades) != paperTradeCapacity {
+		t.Fatalf("reloaded %d positions and %d fills", len(positions), len(trades))
+	}
+	p := positions[0]
+	if !p.Quantity.Equal(decimal.RequireFromString("0.5")) || !p.AvgEntry.Equal(decimal.RequireFromString("0.07")) ||
+		!p.RealizedPnL.Equal(decimal.RequireFromString("0.01")) {
+		t.Fatalf("reloaded %+v, want 0.5 at 0.07 with 0.01 realized", p)
+	}
+}
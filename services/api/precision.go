@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// How long to wait before asking Binance again after a failed lookup
+const tickRetryDelay = time.Minute
+
+// Binance price tick sizes by symbol, fetched from exchangeInfo on first use
+var (
+	tickSizes    = make(map[string]float64)
+	tickFetching = make(map[string]bool)
+	tickFailed   = make(map[string]time.Time)
+	tickMu       sync.Mutex
+
+	tickGeneration atomic.Uint64 // bumped on each tick size learned, for ETags
+)
+
+// binanceSymbolInfo is one entry of an exchangeInfo response
+type binanceSymbolInfo struct {
+	Symbol  string `json:"symbol"`
+	Status  string `json:"status"`
+	Filters []struct {
+		FilterType string `json:"filterType"`
+		TickSize   string `json:"tickSize"`
+	} `json:"filters"`
+}
+
+// tickSize returns the PRICE_FILTER tick size, 0 if there is none
+func (info binanceSymbolInfo) tickSize() float64 {
+	for _, f := range info.Filters {
+		if f.FilterType == "PRICE_FILTER" {
+			tick, _ := strconv.ParseFloat(f.TickSize, 64)
+			return tick
+		}
+	}
+	return 0
+}
+
+// fetchSymbolInfo looks symbol up in the exchangeInfo of the Binance REST
+// API at base. It returns a zero info and no error when Binance doesn't
+// know the symbol.
+func fetchSymbolInfo(ctx context.Context, base, symbol string) (binanceSymbolInfo, error) {
+	resp, err := restGet(ctx, base+"/api/v3/exchangeInfo?symbol="+strings.ToUpper(symbol))
+	if err != nil {
+		return binanceSymbolInfo{}, fmt.Errorf("could not reach Binance: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return binanceSymbolInfo{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return binanceSymbolInfo{}, fmt.Errorf("Binance returned %s", resp.Status)
+	}
+
+	var info struct {
+		Symbols []binanceSymbolInfo `json:"symbols"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return binanceSymbolInfo{}, fmt.Errorf("invalid exchangeInfo response: %v", err)
+	}
+	if len(info.Symbols) == 0 {
+		return binanceSymbolInfo{}, nil
+	}
+	return info.Symbols[0], nil
+}
+
+// cacheTickSize remembers a tick size learned elsewhere
+func cacheTickSize(symbol string, tick float64) {
+	if tick <= 0 {
+		return
+	}
+	tickMu.Lock()
+	tickSizes[symbol] = tick
+	tickMu.Unlock()
+	tickGeneration.Add(1)
+}
+
+// cachedTickSize returns the tick size for symbol if one has been learned,
+// without asking Binance
+func cachedTickSize(symbol string) (float64, bool) {
+	tickMu.Lock()
+	defer tickMu.Unlock()
+	tick, ok := tickSizes[symbol]
+	return tick, ok
+}
+
+// lookupTickSize returns the cached tick size for symbol. On a miss it
+// starts a background fetch from the Binance REST API at base and reports
+// false, so callers never wait on Binance.
+func lookupTickSize(base, symbol string) (float64, bool) {
+	tickMu.Lock()
+	defer tickMu.Unlock()
+	if tick, ok := tickSizes[symbol]; ok {
+		return tick, true
+	}
+	if tickFetching[symbol] || time.Since(tickFailed[symbol]) < tickRetryDelay {
+		return 0, false
+	}
+
+	tickFetching[symbol] = true
+	go func() {
+		info, err := fetchSymbolInfo(context.Background(), base, symbol)
+		tick := info.tickSize()
+
+		tickMu.Lock()
+		defer tickMu.Unlock()
+		delete(tickFetching, symbol)
+		if err != nil || tick <= 0 {
+			if err == nil {
+				err = fmt.Errorf("no price filter")
+			}
+			slog.Warn("Tick size lookup failed", "symbol", symbol, "err", err)
+			tickFailed[symbol] = time.Now()
+			return
+		}
+		tickSizes[symbol] = tick
+		tickGeneration.Add(1)
+	}()
+	return 0, false
+}
+
+// priceDecimals picks the decimal places to show for a price of symbol:
+// as many as its tick size has, or a magnitude-based guess until that is
+// known. A miss starts the tick size lookup.
+func (s *Server) priceDecimals(symbol string, price float64) int {
+	if tick, ok := lookupTickSize(s.restURL, symbol); ok {
+		return tickDecimals(tick)
+	}
+	return magnitudeDecimals(price)
+}
+
+// knownDecimals is priceDecimals for code without a Server, such as log
+// lines, which only uses tick sizes already learned
+func knownDecimals(symbol string, price float64) int {
+	if tick, ok := cachedTickSize(symbol); ok {
+		return tickDecimals(tick)
+	}
+	return magnitudeDecimals(price)
+}
+
+// roundTo rounds v to decimals places, still a number for JSON
+func roundTo(v float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(v*scale) / scale
+}
+
+// roundedValues copies values, MAs or EMAs keyed by window or period, with
+// each rounded to decimals places
+func roundedValues(values map[string]float64, decimals int) map[string]float64 {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]float64, len(values))
+	for key, v := range values {
+		out[key] = roundTo(v, decimals)
+	}
+	return out
+}
+
+// rounded returns p with the indicators derived from its prices rounded to
+// decimals places: the MAs, EMAs, VWAP and Bollinger Bands. Traded prices
+// are on the exchange's tick already, and MACD, whose sign matters more
+// than its size, and RSI aren't prices. The maps and bands are copied so
+// p's own stay untouched.
+func (p ProcessedMessage) rounded(decimals int) ProcessedMessage {
+	p.MovingAverage = roundTo(p.MovingAverage, decimals)
+	p.MovingAverages = roundedValues(p.MovingAverages, decimals)
+	p.EMA = roundTo(p.EMA, decimals)
+	p.EMAs = roundedValues(p.EMAs, decimals)
+	p.VWAP = roundTo(p.VWAP, decimals)
+	if p.Bollinger != nil {
+		bb := *p.Bollinger
+		bb.Upper, bb.Middle, bb.Lower = roundTo(bb.Upper, decimals), roundTo(bb.Middle, decimals), roundTo(bb.Lower, decimals)
+		p.Bollinger = &bb
+	}
+	return p
+}
+
+// tickDecimals counts the decimal places of a tick size, e.g. 5 for 0.00001
+func tickDecimals(tick float64) int {
+	s := strconv.FormatFloat(tick, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// magnitudeDecimals keeps about six significant digits, between 2 and 8
+// decimal places
+func magnitudeDecimals(price float64) int {
+	if !(price > 0) {
+		return 2
+	}
+	d := 5 - int(math.Floor(math.Log10(price)))
+	return min(max(d, 2), 8)
+}
+
+// numberFormat is how a locale groups thousands and marks decimals
+type numberFormat struct {
+	group, decimal string
+}
+
+// Locales LOCALE accepts. plain is strconv's format: no grouping and a
+// decimal point.
+var numberFormats = map[string]numberFormat{
+	"plain": {"", "."},
+	"en":    {",", "."},
+	"de":    {".", ","},
+	"fr":    {" ", ","},
+	"ch":    {"'", "."},
+}
+
+// logLocale is the format of the prices FormatPrice renders for logs;
+// LOCALE overrides it
+var logLocale = numberFormats["plain"]
+
+// apply regroups s, a number as strconv formats it, in this format.
+// Anything but digits and a decimal point (NaN, Inf) is left alone.
+func (f numberFormat) apply(s string) string {
+	if f.group == "" && (f.decimal == "." || f.decimal == "") {
+		return s
+	}
+	sign, digits := "", s
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(digits, ".")
+	if whole == "" || strings.Trim(whole, "0123456789") != "" {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i := range len(whole) {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteByte(whole[i])
+	}
+	if hasFrac {
+		b.WriteString(f.decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// FormatPrice renders price with the precision Binance quotes symbol in,
+// in the LOCALE format
+func FormatPrice(symbol string, price float64) string {
+	return logLocale.apply(plainPrice(symbol, price))
+}
+
+// plainPrice is FormatPrice in the plain format, for output read back by
+// machines
+func plainPrice(symbol string, price float64) string {
+	return strconv.FormatFloat(price, 'f', knownDecimals(symbol, price), 64)
+}
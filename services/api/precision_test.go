@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFormatPriceLocale(t *testing.T) {
+	defer func(f numberFormat) { logLocale = f }(logLocale)
+	cacheTickSize("localeusdt", 0.01)
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"plain", "1234567.80"},
+		{"en", "1,234,567.80"},
+		{"de", "1.234.567,80"},
+		{"fr", "1 234 567,80"},
+		{"ch", "1'234'567.80"},
+	}
+	for _, tt := range tests {
+		logLocale = numberFormats[tt.locale]
+		if got := FormatPrice("localeusdt", 1234567.8); got != tt.want {
+			t.Errorf("LOCALE=%s: %q, want %q", tt.locale, got, tt.want)
+		}
+		// Output read back by machines stays plain whatever the locale
+		if got := plainPrice("localeusdt", 1234567.8); got != "1234567.80" {
+			t.Errorf("LOCALE=%s: plain price %q", tt.locale, got)
+		}
+	}
+
+	logLocale = numberFormats["de"]
+	for in, want := range map[string]string{"-1000.5": "-1.000,5", "12": "12", "NaN": "NaN", "-Inf": "-Inf"} {
+		if got := logLocale.apply(in); got != want {
+			t.Errorf("de: %q = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLowPricedStatsKeepTheirDigits(t *testing.T) {
+	s, ts := newTestServer(t, "pepeusdt")
+	cacheTickSize("pepeusdt", 0.00000001)
+	feed(t, s, ProcessedMessage{
+		Symbol:         "pepeusdt",
+		Price:          0.00001234,
+		MovingAverage:  0.0000123449,
+		MovingAverages: map[string]float64{"20": 0.0000123449},
+		EMA:            0.0000122,
+		EMAs:           map[string]float64{"12": 0.0000122},
+		RSI:            -1,
+		VWAP:           0.000012301,
+		High:           0.00001250,
+		Low:            0.00001201,
+		Bollinger:      &Bollinger{Upper: 0.0000125551, Middle: 0.0000123449, Lower: 0.0000121347, Period: 20, K: 2},
+		Time:           time.Now().UnixMilli(),
+	})
+
+	var st Stats
+	getJSON(t, ts, "/api/stats?symbol=pepeusdt", &st)
+	if st.PriceDecimals != 8 {
+		t.Fatalf("price_decimals = %d, want the tick's 8", st.PriceDecimals)
+	}
+	ind := st.Indicators
+	// Rounded to the tick, not to cents, so nothing collapses to zero
+	for name, got := range map[string][2]float64{
+		"moving_average": {ind.MovingAverage, 0.00001234},
+		"ma 20":          {ind.MovingAverages["20"], 0.00001234},
+		"ema":            {ind.EMA, 0.0000122},
+		"vwap":           {ind.VWAP, 0.0000123},
+		"bb upper":       {ind.Bollinger.Upper, 0.00001256},
+		"bb lower":       {ind.Bollinger.Lower, 0.00001213},
+	} {
+		if math.Abs(got[0]-got[1]) > 1e-15 {
+			t.Errorf("%s = %v, want %v", name, got[0], got[1])
+		}
+	}
+	if st.Price != 0.00001234 || st.Session.High != 0.0000125 || st.Session.Low != 0.00001201 {
+		t.Errorf("price %v, high %v, low %v", st.Price, st.Session.High, st.Session.Low)
+	}
+}
+
+func TestMagnitudeDecimals(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  int
+	}{
+		{68432.5, 2},
+		{3012.25, 2},
+		{1.2345, 5},
+		{0.0123, 7},
+		{0.00001234, 8},
+		{0, 2},
+	}
+	for _, tt := range tests {
+		if got := magnitudeDecimals(tt.price); got != tt.want {
+			t.Errorf("magnitudeDecimals(%v) = %d, want %d", tt.price, got, tt.want)
+		}
+		if rounded := roundTo(tt.price, magnitudeDecimals(tt.price)); tt.price > 0 && rounded == 0 {
+			t.Errorf("%v rounds to zero", tt.price)
+		}
+	}
+}
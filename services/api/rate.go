@@ -0,0 +1,35 @@
+package main
+
+import "time"
+
+// tickRate counts live ticks over the last minute in one-second buckets,
+// so it costs the same however busy the symbol is
+type tickRate struct {
+	counts [60]int
+	secs   [60]int64 // unix second each bucket is counting
+}
+
+func (r *tickRate) add(now time.Time) {
+	sec := now.Unix()
+	i := sec % int64(len(r.counts))
+	if r.secs[i] != sec {
+		r.secs[i], r.counts[i] = sec, 0
+	}
+	r.counts[i]++
+}
+
+// perMinute returns the ticks seen in the minute up to now. A nil rate
+// has seen none.
+func (r *tickRate) perMinute(now time.Time) int {
+	if r == nil {
+		return 0
+	}
+	sec := now.Unix()
+	var n int
+	for i, s := range r.secs {
+		if s > sec-int64(len(r.secs)) && s <= sec {
+			n += r.counts[i]
+		}
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Binance REST bases; BINANCE_TESTNET picks the testnet one and
+// BINANCE_REST_URL overrides either, as in ingestion
+const (
+	binanceRESTURL        = "https://api.binance.com"
+	binanceTestnetRESTURL = "https://testnet.binance.vision"
+)
+
+// REST calls are tried restAttempts times, waiting restBackoff before the
+// first retry and doubling it after each
+const (
+	restAttempts  = 3
+	restBackoff   = 500 * time.Millisecond
+	maxRetryAfter = 30 * time.Second
+)
+
+// restClient is shared by every Binance lookup so connections are reused;
+// REST_TIMEOUT overrides the per-request timeout
+var restClient = &http.Client{Timeout: 10 * time.Second}
+
+// restBase returns the Binance REST base the environment asks for: raw
+// when it is set, else the testnet or production one
+func restBase(testnet bool, raw string) (string, error) {
+	if raw == "" {
+		if testnet {
+			return binanceTestnetRESTURL, nil
+		}
+		return binanceRESTURL, nil
+	}
+	if err := checkBaseURL(raw, "http", "https"); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(raw, "/"), nil
+}
+
+// checkBaseURL rejects URLs without a host or with a scheme other than those
+// given
+func checkBaseURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("%q must use %s", raw, strings.Join(schemes, " or "))
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q must not have a query or fragment", raw)
+	}
+	return nil
+}
+
+// restGet fetches url, retrying network errors, 5xx and 429 responses.
+// A Retry-After header replaces the backoff for that wait. Once retries
+// run out the last response is returned as is, so callers still report
+// its status.
+func restGet(ctx context.Context, url string) (*http.Response, error) {
+	backoff := restBackoff
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := restClient.Do(req)
+		if attempt == restAttempts || ctx.Err() != nil || !retryable(resp, err) {
+			return resp, err
+		}
+
+		wait := backoff
+		backoff *= 2
+		if err == nil {
+			if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = d
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			slog.Warn("REST call failed, retrying", "url", url, "status", resp.Status, "wait", wait)
+		} else {
+			slog.Warn("REST call failed, retrying", "url", url, "err", err, "wait", wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// retryable reports whether a call failing this way may succeed if repeated
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryAfter parses a Retry-After header, either seconds or an HTTP date,
+// capped at maxRetryAfter
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = time.Until(t)
+	} else {
+		return 0, false
+	}
+	return min(max(d, 0), maxRetryAfter), true
+}
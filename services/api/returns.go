@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Milliseconds in a 365-day year, the horizon volatility is annualized to
+const yearMillis = float64(365 * 24 * time.Hour / time.Millisecond)
+
+// Return is the log return from the previous trade to this one
+type Return struct {
+	Time      int64   `json:"time"` // unix ms of the later trade
+	Price     float64 `json:"price"`
+	LogReturn float64 `json:"log_return"`
+}
+
+// Returns is the /api/returns response
+type Returns struct {
+	Symbol             string   `json:"symbol"`
+	Count              int      `json:"count"`
+	Mean               float64  `json:"mean"`
+	StdDev             float64  `json:"stddev"`              // sample, 0 below two returns
+	RealizedVolatility float64  `json:"realized_volatility"` // annualized, 0 until time has passed
+	Returns            []Return `json:"returns"`             // oldest first
+}
+
+// logReturns returns the log return of each trade from the one before it.
+// Pairs with a non-positive price on either side are skipped rather than
+// dividing by or taking the log of zero.
+func logReturns(trades []Trade) []Return {
+	returns := []Return{}
+	for i := 1; i < len(trades); i++ {
+		prev, cur := trades[i-1], trades[i]
+		if !(prev.Price > 0) || !(cur.Price > 0) {
+			continue
+		}
+		returns = append(returns, Return{
+			Time:      cur.Timestamp.UnixMilli(),
+			Price:     cur.Price,
+			LogReturn: math.Log(cur.Price / prev.Price),
+		})
+	}
+	return returns
+}
+
+// summarizeReturns fills in the mean, standard deviation and realized
+// volatility of rs.Returns. Trades arrive irregularly, so volatility is
+// the summed squared returns over the time they span, scaled to a year.
+func summarizeReturns(rs *Returns, first int64) {
+	n := len(rs.Returns)
+	rs.Count = n
+	if n == 0 {
+		return
+	}
+
+	var sum, squares float64
+	for _, r := range rs.Returns {
+		sum += r.LogReturn
+		squares += r.LogReturn * r.LogReturn
+	}
+	rs.Mean = sum / float64(n)
+
+	if n > 1 {
+		var variance float64
+		for _, r := range rs.Returns {
+			d := r.LogReturn - rs.Mean
+			variance += d * d
+		}
+		rs.StdDev = math.Sqrt(variance / float64(n-1))
+	}
+
+	if span := float64(rs.Returns[n-1].Time - first); span > 0 {
+		rs.RealizedVolatility = math.Sqrt(squares / span * yearMillis)
+	}
+}
+
+func (s *Server) handleReturns(w http.ResponseWriter, r *http.Request) {
+	symbol := s.requestSymbol(r)
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if limit >= s.historySize {
+		limit = s.historySize - 1
+	}
+
+	// One more trade than returns, for the first one's previous price
+	s.mu.RLock()
+	trades := s.recent[symbol].last(limit + 1)
+	s.mu.RUnlock()
+
+	rs := Returns{Symbol: symbol, Returns: logReturns(trades)}
+	if len(trades) > 0 {
+		summarizeReturns(&rs, trades[0].Timestamp.UnixMilli())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rs)
+}
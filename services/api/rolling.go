@@ -0,0 +1,90 @@
+package main
+
+import "time"
+
+// Length of the rolling stats window
+const rollingWindow = 24 * time.Hour
+
+// pricePoint is one timestamped price (time in ms)
+type pricePoint struct {
+	time  int64
+	price float64
+}
+
+// rollingStats tracks high, low and change over a sliding time window.
+// Alongside the time-ordered points it keeps monotonic queues of candidate
+// highs and lows, so every update and eviction is amortized O(1) no matter
+// how many points the window holds.
+type rollingStats struct {
+	window int64
+	points []pricePoint
+	highs  []pricePoint // prices strictly decreasing
+	lows   []pricePoint // prices strictly increasing
+}
+
+func newRollingStats(window time.Duration) *rollingStats {
+	return &rollingStats{window: window.Milliseconds()}
+}
+
+// add records a tick and evicts points that fell out of the window;
+// out-of-order ticks are ignored
+func (rs *rollingStats) add(t int64, price float64) {
+	if n := len(rs.points); n > 0 && t < rs.points[n-1].time {
+		return
+	}
+
+	p := pricePoint{time: t, price: price}
+	rs.points = append(rs.points, p)
+	for len(rs.highs) > 0 && rs.highs[len(rs.highs)-1].price <= price {
+		rs.highs = rs.highs[:len(rs.highs)-1]
+	}
+	rs.highs = append(rs.highs, p)
+	for len(rs.lows) > 0 && rs.lows[len(rs.lows)-1].price >= price {
+		rs.lows = rs.lows[:len(rs.lows)-1]
+	}
+	rs.lows = append(rs.lows, p)
+
+	rs.evict(t - rs.window)
+}
+
+// evict drops everything older than cutoff
+func (rs *rollingStats) evict(cutoff int64) {
+	i := 0
+	for i < len(rs.points) && rs.points[i].time < cutoff {
+		i++
+	}
+	rs.points = rs.points[i:]
+	for len(rs.highs) > 0 && rs.highs[0].time < cutoff {
+		rs.highs = rs.highs[1:]
+	}
+	for len(rs.lows) > 0 && rs.lows[0].time < cutoff {
+		rs.lows = rs.lows[1:]
+	}
+}
+
+// high returns the highest price in the window
+func (rs *rollingStats) high() float64 {
+	if len(rs.highs) == 0 {
+		return 0
+	}
+	return rs.highs[0].price
+}
+
+// low returns the lowest price in the window
+func (rs *rollingStats) low() float64 {
+	if len(rs.lows) == 0 {
+		return 0
+	}
+	return rs.lows[0].price
+}
+
+// changePercent returns the change from the oldest to the newest price in
+// the window
+func (rs *rollingStats) changePercent() float64 {
+	if len(rs.points) == 0 || rs.points[0].price == 0 {
+		return 0
+	}
+	first := rs.points[0].price
+	last := rs.points[len(rs.points)-1].price
+	return (last - first) / first * 100
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRollingMatchesBruteForce(t *testing.T) {
+	const window = 10 * time.Second
+	rs := newRollingStats(window)
+	rng := rand.New(rand.NewSource(1))
+
+	var all []pricePoint
+	now, price := int64(1_700_000_000_000), 100.0
+	for range 20000 {
+		// Bursts and quiet gaps, some longer than the window
+		now += int64(rng.ExpFloat64() * 300)
+		if rng.Intn(500) == 0 {
+			now += 2 * window.Milliseconds()
+		}
+		price = math.Max(1, price+rng.NormFloat64())
+		rs.add(now, price)
+		all = append(all, pricePoint{now, price})
+
+		cutoff := now - window.Milliseconds()
+		for all[0].time < cutoff {
+			all = all[1:]
+		}
+		high, low, first := 0.0, math.Inf(1), all[0].price
+		for _, p := range all {
+			high, low = math.Max(high, p.price), math.Min(low, p.price)
+		}
+		if rs.high() != high || rs.low() != low {
+			t.Fatalf("at %d: high/low %v/%v, want %v/%v", now, rs.high(), rs.low(), high, low)
+		}
+		if want := (price - first) / first * 100; rs.changePercent() != want {
+			t.Fatalf("at %d: change %v%%, want %v%%", now, rs.changePercent(), want)
+		}
+	}
+}
+
+func TestRollingEviction(t *testing.T) {
+	rs := newRollingStats(time.Second)
+	rs.add(0, 200)  // the high
+	rs.add(500, 50) // the low
+	rs.add(900, 100)
+
+	// A point exactly a window old is still in it
+	rs.add(1000, 120)
+	if rs.high() != 200 || rs.low() != 50 {
+		t.Fatalf("high/low %v/%v, want 200/50 until the high ages out", rs.high(), rs.low())
+	}
+	rs.add(1001, 110)
+	if rs.high() != 120 || rs.low() != 50 || rs.changePercent() != 120 {
+		t.Fatalf("high/low %v/%v, change %v after the high aged out; want 120/50, +120%%", rs.high(), rs.low(), rs.changePercent())
+	}
+	rs.add(1600, 105)
+	if rs.low() != 100 {
+		t.Fatalf("low %v after the low aged out, want 100", rs.low())
+	}
+
+	// Out-of-order ticks are ignored, and a quiet spell empties the window
+	// down to the new tick
+	rs.add(1200, 1)
+	if rs.low() != 100 {
+		t.Fatalf("out-of-order tick moved the low to %v", rs.low())
+	}
+	rs.add(10_000, 99)
+	if rs.high() != 99 || rs.low() != 99 || rs.changePercent() != 0 || len(rs.points) != 1 {
+		t.Fatalf("after a quiet spell: %v/%v, change %v, %d points", rs.high(), rs.low(), rs.changePercent(), len(rs.points))
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Largest command frame a client may send
+const maxCommandBytes = 4096
+
+// JSON-RPC error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// rpcRequest is a command sent by a client over /ws
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// rpcResponse answers the request with the same id; pushed trades carry
+// no id
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result any             `json:"result,omitempty"`
+	Error  *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcParams are the parameters every command draws from
+type rpcParams struct {
+	Symbol  string   `json:"symbol"`
+	Symbols []string `json:"symbols"`
+	Window  int      `json:"window"`
+	Limit   int      `json:"limit"`
+}
+
+// command runs one request from c and returns its encoded response
+func (s *Server) command(c *client, frame []byte) []byte {
+	var req rpcRequest
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return encodeResponse(rpcResponse{ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, "Invalid JSON"}})
+	}
+	if len(req.ID) == 0 {
+		req.ID = json.RawMessage("null")
+	}
+	if req.Method == "" {
+		return encodeResponse(rpcResponse{ID: req.ID, Error: &rpcError{rpcInvalidRequest, "Missing method"}})
+	}
+
+	var p rpcParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return encodeResponse(rpcResponse{ID: req.ID, Error: &rpcError{rpcInvalidParams, "Invalid params"}})
+		}
+	}
+
+	result, rerr := s.runCommand(c, req.Method, p)
+	return encodeResponse(rpcResponse{ID: req.ID, Result: result, Error: rerr})
+}
+
+// runCommand dispatches method
+func (s *Server) runCommand(c *client, method string, p rpcParams) (any, *rpcError) {
+	switch method {
+	case "subscribe":
+		// Pushes of only these symbols; none means every symbol
+		symbols := make([]string, 0, len(p.Symbols))
+		for _, sym := range p.Symbols {
+			if sym = strings.ToLower(strings.TrimSpace(sym)); sym != "" {
+				symbols = append(symbols, sym)
+			}
+		}
+		if p.Symbol != "" {
+			symbols = append(symbols, strings.ToLower(p.Symbol))
+		}
+		s.hub.subscribe(c, symbols)
+		return map[string][]string{"symbols": symbols}, nil
+
+	case "set_ma_window":
+		// 0 restores the processor's primary window
+		if p.Window < 0 || p.Window > s.historySize {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("Window must be 0 to %d", s.historySize)}
+		}
+		c.maWindow = p.Window
+		return map[string]int{"window": c.maWindow}, nil
+
+	case "stats":
+		return s.stats(s.paramSymbol(p), c.maWindow), nil
+
+	case "history":
+		limit := p.Limit
+		if limit == 0 {
+			limit = 100
+		}
+		if limit < 0 {
+			return nil, &rpcError{rpcInvalidParams, "Invalid limit"}
+		}
+		limit = min(limit, s.historySize)
+
+		symbol := s.paramSymbol(p)
+		s.mu.RLock()
+		recent := s.recent[symbol].last(limit)
+		s.mu.RUnlock()
+
+		// Newest first, as /api/history
+		trades := make([]Trade, len(recent))
+		for i, t := range recent {
+			t.Indicators = nil
+			trades[len(recent)-1-i] = t
+		}
+		return trades, nil
+	}
+	return nil, &rpcError{rpcMethodNotFound, "Unknown method: " + method}
+}
+
+// paramSymbol returns the symbol param, defaulting to the primary symbol
+func (s *Server) paramSymbol(p rpcParams) string {
+	if symbol := strings.ToLower(p.Symbol); symbol != "" {
+		return symbol
+	}
+	return s.primarySymbol()
+}
+
+func encodeResponse(resp rpcResponse) []byte {
+	data, _ := json.Marshal(resp)
+	return data
+}
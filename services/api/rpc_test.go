@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// rpcReply is an rpcResponse as a client decodes it
+type rpcReply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// call sends a command frame over conn and returns the response to it
+func call(t *testing.T, conn *websocket.Conn, frame string) rpcReply {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp rpcReply
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("response %s: %v", data, err)
+	}
+	return resp
+}
+
+func TestRPCSubscribe(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt", "ethusdt")
+	conn := dialWS(t, s, ts)
+
+	resp := call(t, conn, `{"id":7,"method":"subscribe","params":{"symbols":[" ETHUSDT ",""]}}`)
+	if string(resp.ID) != "7" || resp.Error != nil || string(resp.Result) != `{"symbols":["ethusdt"]}` {
+		t.Fatalf("subscribe response %+v", resp)
+	}
+
+	// Only the subscribed symbol's pushes arrive, without an id
+	s.hub.broadcast("btcusdt", []byte(`{"symbol":"btcusdt"}`))
+	s.hub.broadcast("ethusdt", []byte(`{"symbol":"ethusdt"}`))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, frame, err := conn.ReadMessage(); err != nil || string(frame) != `{"symbol":"ethusdt"}` {
+		t.Fatalf("push after subscribing = %s, %v; want ethusdt's", frame, err)
+	}
+}
+
+func TestRPCHistory(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt")
+	now := time.Now().UnixMilli()
+	for i, price := range []float64{100, 101, 102} {
+		feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: price, High: price, Low: 100, Time: now + int64(i)})
+	}
+	conn := dialWS(t, s, ts)
+
+	resp := call(t, conn, `{"id":"h1","method":"history","params":{"limit":2}}`)
+	if string(resp.ID) != `"h1"` || resp.Error != nil {
+		t.Fatalf("history response %+v", resp)
+	}
+	var trades []Trade
+	if err := json.Unmarshal(resp.Result, &trades); err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 || trades[0].Price != 102 || trades[1].Price != 101 {
+		t.Fatalf("history = %+v, want the newest two, newest first", trades)
+	}
+}
+
+func TestRPCErrors(t *testing.T) {
+	s, ts := newTestServer(t, "btcusdt")
+	conn := dialWS(t, s, ts)
+
+	tests := []struct {
+		frame string
+		id    string
+		code  int
+	}{
+		{`{"id":1,"method":`, "null", rpcParseError},
+		{`{"id":2}`, "2", rpcInvalidRequest},
+		{`{"id":3,"method":"reboot"}`, "3", rpcMethodNotFound},
+		{`{"id":4,"method":"set_ma_window","params":{"window":-1}}`, "4", rpcInvalidParams},
+		{`{"id":5,"method":"set_ma_window","params":{"window":"wide"}}`, "5", rpcInvalidParams},
+		{`{"id":6,"method":"history","params":{"limit":-3}}`, "6", rpcInvalidParams},
+		{`{"method":"reboot"}`, "null", rpcMethodNotFound},
+	}
+	for _, tt := range tests {
+		resp := call(t, conn, tt.frame)
+		if string(resp.ID) != tt.id || resp.Error == nil || resp.Error.Code != tt.code {
+			t.Errorf("%s: id %s, error %+v; want id %s, code %d", tt.frame, resp.ID, resp.Error, tt.id, tt.code)
+		}
+	}
+
+	// The connection survives bad commands
+	if resp := call(t, conn, `{"id":8,"method":"set_ma_window","params":{"window":5}}`); resp.Error != nil {
+		t.Fatalf("set_ma_window after the errors: %+v", resp.Error)
+	}
+}
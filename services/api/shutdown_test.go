@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestShutdownLeavesNoGoroutines runs the API with a WebSocket client and
+// bus consumers, shuts it down in main's order and checks every goroutine
+// it started has exited
+func TestShutdownLeavesNoGoroutines(t *testing.T) {
+	// A known tick size keeps /api/stats from starting a Binance lookup,
+	// which would outlive the shutdown
+	cacheTickSize("btcusdt", 0.01)
+	baseline := runtime.NumGoroutine()
+
+	s := newServer(nil, nil, []time.Duration{time.Minute}, newSpikeDetector(0, time.Minute))
+	s.bus.consume("all", busBuffer, 0, func(priceEvent) {})
+	s.bus.consume("coalesced", busBuffer, 10*time.Millisecond, func(e priceEvent) { s.hub.broadcast(e.Trade.Symbol, e.Raw) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := &http.Server{Handler: s.routes()}
+	served := make(chan error, 1)
+	go func() { served <- httpServer.Serve(listener) }()
+	url := "http://" + listener.Addr().String()
+
+	transport := &http.Transport{}
+	resp, err := (&http.Client{Transport: transport}).Get(url + "/api/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	waitFor(t, func() bool { return clientCount(s.hub) == 1 })
+	feed(t, s, ProcessedMessage{Symbol: "btcusdt", Price: 100, High: 100, Low: 100, Time: time.Now().UnixMilli()})
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("no trade pushed: %v", err)
+	}
+
+	// Teardown as main does it on SIGINT/SIGTERM
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		t.Fatalf("Serve returned %v", err)
+	}
+	s.hub.closeAll()
+	s.bus.close()
+
+	// The client sees the close frame
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNoStatusReceived, websocket.CloseNormalClosure) {
+		t.Errorf("after shutdown the client read %v, want a close", err)
+	}
+	conn.Close()
+	transport.CloseIdleConnections()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		buf := make([]byte, 1<<16)
+		t.Fatalf("%d goroutines after shutdown, %d before:\n%s", n, baseline, buf[:runtime.Stack(buf, true)])
+	}
+}
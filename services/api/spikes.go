@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Spike describes the largest move of a symbol within the detector's lookback
+type Spike struct {
+	Symbol        string  `json:"symbol"`
+	Active        bool    `json:"active"`
+	ChangePercent float64 `json:"change_percent"` // signed, from the window's extreme to the latest price
+	From          float64 `json:"from"`           // the window low for a jump, high for a drop
+	Price         float64 `json:"price"`
+	Since         int64   `json:"since,omitempty"` // unix ms the spike became active
+	Threshold     float64 `json:"threshold"`       // percent
+	Window        string  `json:"window"`
+}
+
+// spikeState is the detector's per-symbol state
+type spikeState struct {
+	window  *rollingStats
+	pending *pricePoint // a tick past the threshold awaiting confirmation
+	spike   Spike
+}
+
+// spikeDetector flags moves of more than threshold percent within window.
+// A tick past the threshold only counts once the next tick confirms the
+// move, so one bad print can't raise a spike or skew the window.
+type spikeDetector struct {
+	mu        sync.Mutex
+	threshold float64
+	window    time.Duration
+	symbols   map[string]*spikeState
+}
+
+func newSpikeDetector(threshold float64, window time.Duration) *spikeDetector {
+	return &spikeDetector{
+		threshold: threshold,
+		window:    window,
+		symbols:   make(map[string]*spikeState),
+	}
+}
+
+// move returns the signed percent move of price from the window's low or
+// high, whichever is larger, with the price it is measured from
+func (st *spikeState) move(price float64) (float64, float64) {
+	if len(st.window.points) == 0 {
+		return 0, 0
+	}
+	low, high := st.window.low(), st.window.high()
+	up := (price - low) / low * 100
+	down := (price - high) / high * 100
+	if up >= -down {
+		return up, low
+	}
+	return down, high
+}
+
+// observe folds a tick in and reports whether it started a spike
+func (d *spikeDetector) observe(symbol string, t int64, price float64) (Spike, bool) {
+	if d.threshold <= 0 || !(price > 0) || math.IsInf(price, 0) {
+		return Spike{}, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st := d.symbols[symbol]
+	if st == nil {
+		st = &spikeState{
+			window: newRollingStats(d.window),
+			spike:  Spike{Symbol: symbol, Threshold: d.threshold, Window: d.window.String()},
+		}
+		d.symbols[symbol] = st
+	}
+
+	change, from := st.move(price)
+	past := math.Abs(change) >= d.threshold
+
+	if st.pending != nil {
+		prev, _ := st.move(st.pending.price)
+		confirmed := past && (change > 0) == (prev > 0)
+		if confirmed {
+			st.window.add(st.pending.time, st.pending.price)
+		}
+		// Unconfirmed, the pending tick is dropped as an outlier
+		st.pending = nil
+	} else if past && !st.spike.Active {
+		st.pending = &pricePoint{time: t, price: price}
+		return st.spike, false
+	}
+
+	st.window.add(t, price)
+
+	started := past && !st.spike.Active
+	since := st.spike.Since
+	if started {
+		since = t
+	} else if !past {
+		since = 0
+	}
+	st.spike = Spike{
+		Symbol:        symbol,
+		Active:        past,
+		ChangePercent: change,
+		From:          from,
+		Price:         price,
+		Since:         since,
+		Threshold:     d.threshold,
+		Window:        d.window.String(),
+	}
+	return st.spike, started
+}
+
+// get returns the current spike state for symbol
+func (d *spikeDetector) get(symbol string) Spike {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if st := d.symbols[symbol]; st != nil {
+		return st.spike
+	}
+	return Spike{Symbol: symbol, Threshold: d.threshold, Window: d.window.String()}
+}
+
+// active returns every symbol's spike that is currently active
+func (d *spikeDetector) active() []Spike {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := []Spike{}
+	for _, st := range d.symbols {
+		if st.spike.Active {
+			list = append(list, st.spike)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+	return list
+}
+
+func (d *spikeDetector) remove(symbol string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.symbols, symbol)
+}
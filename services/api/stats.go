@@ -0,0 +1,174 @@
+package main
+
+import "time"
+
+// Version of the /api/stats schema, bumped on any change that could break
+// a client: a renamed, removed or retyped field
+const statsSchemaVersion = 1
+
+// Stats is the /api/stats response, every indicator for one symbol at a
+// single point in time
+type Stats struct {
+	SchemaVersion  int        `json:"schema_version"`
+	Symbol         string     `json:"symbol"`
+	Status         string     `json:"status"` // "initializing" until the first valid price, then "live"
+	Price          float64    `json:"price"`
+	Timestamp      int64      `json:"timestamp"`           // unix ms of the latest trade, 0 before the first
+	AgeMs          int64      `json:"age_ms"`              // since the latest live tick, by exchange time; -1 before the first
+	Volume         float64    `json:"volume"`              // live quantity traded since the API began tracking the symbol
+	TicksPerMinute int        `json:"ticks_per_minute"`    // live ticks in the last minute
+	HistoryLen     int        `json:"history_len"`         // trades held in memory, the widest ma_window averages over
+	DroppedTrades  uint64     `json:"dropped_trades"`      // live trades ingestion dropped rather than stall its exchange reader
+	TickSize       float64    `json:"tick_size,omitempty"` // Binance price tick, once known
+	PriceDecimals  int        `json:"price_decimals"`      // places the indicators are rounded to, from tick_size or the price's magnitude
+	Indicators     Indicators `json:"indicators"`
+	Session        Range      `json:"session"`     // since the processor started
+	Rolling24h     Rolling24h `json:"rolling_24h"` // sliding 24-hour window
+	Spike          Spike      `json:"spike"`
+}
+
+// Indicators are the processor's indicators for a symbol
+type Indicators struct {
+	MovingAverage  float64            `json:"moving_average"`      // primary window, or ma_window when given
+	MovingAverages map[string]float64 `json:"moving_averages"`     // keyed by window
+	MAWindow       int                `json:"ma_window,omitempty"` // the ad-hoc window, when given
+	EMA            float64            `json:"ema"`                 // primary period, -1 until enough samples
+	EMAs           map[string]float64 `json:"emas"`                // keyed by period
+	RSI            float64            `json:"rsi"`                 // -1 until enough samples
+	VWAP           float64            `json:"vwap"`                // session, 0 until a trade with quantity
+	MACD           *MACD              `json:"macd"`                // nil until enough samples
+	Bollinger      *Bollinger         `json:"bollinger"`           // nil until the primary MA window is full
+	ATR            *ATR               `json:"atr"`                 // nil until enough candles have closed
+}
+
+// processedIndicators are the indicators a processed trade carries, as
+// /api/stats reports them
+func processedIndicators(p ProcessedMessage) *Indicators {
+	return &Indicators{
+		MovingAverage:  p.MovingAverage,
+		MovingAverages: p.MovingAverages,
+		EMA:            p.EMA,
+		EMAs:           p.EMAs,
+		RSI:            p.RSI,
+		VWAP:           p.VWAP,
+		MACD:           p.MACD,
+		Bollinger:      p.Bollinger,
+	}
+}
+
+// Candles averaged by the ATR
+const atrPeriod = 14
+
+// ATR is the average true range over Period closed candles of Interval
+type ATR struct {
+	Value    float64 `json:"value"`
+	Period   int     `json:"period"`
+	Interval string  `json:"interval"`
+}
+
+// Range is a high and low price with how far the latest price is from each
+type Range struct {
+	High            float64 `json:"high"`
+	HighTime        int64   `json:"high_time,omitempty"` // unix ms it was set, omitted when unknown
+	Low             float64 `json:"low"`
+	LowTime         int64   `json:"low_time,omitempty"`
+	FromHighPercent float64 `json:"from_high_percent"` // below the high
+	FromLowPercent  float64 `json:"from_low_percent"`  // above the low
+}
+
+// sessionExtremes remembers when the processor's session high and low
+// were set
+type sessionExtremes struct {
+	high, low     float64
+	highAt, lowAt int64 // unix ms, 0 when unknown
+}
+
+// observe notes the session high and low reported with a tick at price
+// and t. An extreme that changed to the tick's price was set by it, even
+// after a session reset; one that changed any other way, as when the API
+// joins mid-session, was set at an unknown time.
+func (e *sessionExtremes) observe(high, low, price float64, t int64) {
+	if high != e.high {
+		e.high, e.highAt = high, 0
+		if high == price {
+			e.highAt = t
+		}
+	}
+	if low != e.low {
+		e.low, e.lowAt = low, 0
+		if low == price {
+			e.lowAt = t
+		}
+	}
+}
+
+// Rolling24h is the high, low and percent change over the last 24 hours
+type Rolling24h struct {
+	High            float64 `json:"high"`
+	Low             float64 `json:"low"`
+	FromHighPercent float64 `json:"from_high_percent"`
+	FromLowPercent  float64 `json:"from_low_percent"`
+	ChangePercent   float64 `json:"change_percent"`
+}
+
+// fromRange returns how far price is below high and above low, as percents
+// of each. Both are 0 until there is a range, including when high equals
+// low.
+func fromRange(price, high, low float64) (float64, float64) {
+	if !(price > 0) || !(low > 0) || !(high > low) {
+		return 0, 0
+	}
+	return (high - price) / high * 100, (price - low) / low * 100
+}
+
+// stats gathers symbol's indicators under one read lock so they all
+// describe the same trade. A window > 0 swaps in an ad-hoc moving average
+// over that many recent trades.
+func (s *Server) stats(symbol string, window int) Stats {
+	s.mu.RLock()
+	current := s.current[symbol]
+	decimals := s.priceDecimals(symbol, current.Price)
+	current = current.rounded(decimals)
+	_, priced := s.price(symbol)
+	st := Stats{
+		SchemaVersion:  statsSchemaVersion,
+		Symbol:         symbol,
+		Status:         dataStatus(priced),
+		Price:          current.Price,
+		Timestamp:      current.Time,
+		PriceDecimals:  decimals,
+		Indicators:     *processedIndicators(current),
+		Session:        Range{High: current.High, Low: current.Low},
+		AgeMs:          ageMillis(s.age(symbol)),
+		Volume:         s.volume[symbol],
+		TicksPerMinute: s.rates[symbol].perMinute(time.Now()),
+		HistoryLen:     s.recent[symbol].len(),
+		DroppedTrades:  s.status[symbol].DroppedTrades,
+	}
+	if window > 0 {
+		st.Indicators.MovingAverage = roundTo(movingAverage(s.recent[symbol].last(window), window), decimals)
+		st.Indicators.MAWindow = window
+	}
+	if rolling := s.rolling[symbol]; rolling != nil {
+		st.Rolling24h = Rolling24h{High: rolling.high(), Low: rolling.low(), ChangePercent: rolling.changePercent()}
+	}
+	if e := s.extrema[symbol]; e != nil {
+		st.Session.HighTime, st.Session.LowTime = e.highAt, e.lowAt
+	}
+	s.mu.RUnlock()
+
+	st.Session.FromHighPercent, st.Session.FromLowPercent = fromRange(st.Price, st.Session.High, st.Session.Low)
+	st.Rolling24h.FromHighPercent, st.Rolling24h.FromLowPercent = fromRange(st.Price, st.Rolling24h.High, st.Rolling24h.Low)
+	if current.Price == 0 {
+		st.Indicators.RSI = -1
+		st.Indicators.EMA = -1
+	}
+	if tick, ok := lookupTickSize(s.restURL, symbol); ok {
+		st.TickSize = tick
+	}
+	if value, ok := s.candles.ATR(symbol, atrPeriod); ok {
+		st.Indicators.ATR = &ATR{Value: roundTo(value, decimals), Period: atrPeriod, Interval: s.candles.intervals[0].String()}
+	}
+	st.Spike = s.spikes.get(symbol)
+	return st
+}
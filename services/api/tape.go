@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Default and largest number of live trades kept on each symbol's tape
+const (
+	defaultTapeSize = 50
+	maxTapeSize     = 1000
+)
+
+// handleTrades serves the tape: the latest live trades, newest first, each
+// with its aggressor side when the exchange reports one (?limit=, at most
+// TAPE_SIZE)
+func (s *Server) handleTrades(w http.ResponseWriter, r *http.Request) {
+	symbol := s.requestSymbol(r)
+
+	limit := s.tapeSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, s.tapeSize)
+	}
+
+	s.mu.RLock()
+	tape := s.tape[symbol].last(limit)
+	s.mu.RUnlock()
+
+	trades := make([]Trade, len(tape))
+	for i, t := range tape {
+		trades[len(tape)-1-i] = t
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trades)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long /api/ticker24h waits on Binance, retries included
+const tickerTimeout = 10 * time.Second
+
+var errUnknownTicker = errors.New("unknown symbol")
+
+// Ticker24h is the /api/ticker24h response: Binance's rolling 24-hour
+// summary of a symbol, for context before live trades arrive
+type Ticker24h struct {
+	Symbol        string  `json:"symbol"`
+	Open          float64 `json:"open"`
+	Last          float64 `json:"last"`
+	High          float64 `json:"high"`
+	Low           float64 `json:"low"`
+	ChangePercent float64 `json:"change_percent"`
+	Volume        float64 `json:"volume"` // in the base asset
+}
+
+// fetchTicker24h asks the Binance REST API at base for symbol's 24-hour
+// ticker. It returns errUnknownTicker when Binance doesn't know the symbol.
+func fetchTicker24h(ctx context.Context, base, symbol string) (Ticker24h, error) {
+	resp, err := restGet(ctx, base+"/api/v3/ticker/24hr?symbol="+url.QueryEscape(strings.ToUpper(symbol)))
+	if err != nil {
+		return Ticker24h{}, fmt.Errorf("could not reach Binance: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return Ticker24h{}, errUnknownTicker
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Ticker24h{}, fmt.Errorf("Binance returned %s", resp.Status)
+	}
+
+	// Binance sends numbers as strings
+	var raw struct {
+		Open          string `json:"openPrice"`
+		Last          string `json:"lastPrice"`
+		High          string `json:"highPrice"`
+		Low           string `json:"lowPrice"`
+		ChangePercent string `json:"priceChangePercent"`
+		Volume        string `json:"volume"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return Ticker24h{}, fmt.Errorf("invalid ticker response: %v", err)
+	}
+	t := Ticker24h{Symbol: symbol}
+	for _, f := range []struct {
+		dst *float64
+		src string
+	}{
+		{&t.Open, raw.Open}, {&t.Last, raw.Last}, {&t.High, raw.High},
+		{&t.Low, raw.Low}, {&t.ChangePercent, raw.ChangePercent}, {&t.Volume, raw.Volume},
+	} {
+		if *f.dst, err = strconv.ParseFloat(f.src, 64); err != nil {
+			return Ticker24h{}, fmt.Errorf("invalid ticker response: %v", err)
+		}
+	}
+	return t, nil
+}
+
+// handleTicker24h serves /api/ticker24h, fetched from Binance on each call
+func (s *Server) handleTicker24h(w http.ResponseWriter, r *http.Request) {
+	symbol := s.requestSymbol(r)
+	ctx, cancel := context.WithTimeout(r.Context(), tickerTimeout)
+	defer cancel()
+
+	t, err := fetchTicker24h(ctx, s.restURL, symbol)
+	if err == errUnknownTicker {
+		http.Error(w, "Binance has no ticker for "+symbol, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ticker lookup failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
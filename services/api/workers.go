@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// A worker is dropped and its symbols handed to the others after this long
+// without a heartbeat (three missed beats at the ingestion default)
+const workerTimeout = 6 * time.Second
+
+// WorkerHeartbeat is published by ingestion workers on workers.heartbeat
+// every few seconds, and once on workers.leave when they shut down. The
+// first heartbeat registers the worker.
+type WorkerHeartbeat struct {
+	ID       string   `json:"id"`
+	Exchange string   `json:"exchange"`
+	Symbols  []string `json:"symbols"` // symbols the worker is streaming
+	Time     int64    `json:"time"`
+}
+
+// WorkerAssignment is published on workers.assign.<id> to tell a worker
+// which symbols to stream
+type WorkerAssignment struct {
+	Symbols []string `json:"symbols"`
+	Time    int64    `json:"time"`
+}
+
+// workerInfo is the coordinator's view of one worker
+type workerInfo struct {
+	ID        string    `json:"id"`
+	Exchange  string    `json:"exchange"`
+	Assigned  []string  `json:"assigned"`
+	Streaming []string  `json:"streaming"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// coordinator spreads the tracked symbols across ingestion workers, moving
+// a worker's symbols to the others when it leaves or stops sending
+// heartbeats
+type coordinator struct {
+	mu      sync.Mutex
+	nc      *nats.Conn
+	workers map[string]*workerInfo
+	symbols []string
+}
+
+func newCoordinator(nc *nats.Conn, symbols []string) *coordinator {
+	return &coordinator{
+		nc:      nc,
+		workers: make(map[string]*workerInfo),
+		symbols: symbols,
+	}
+}
+
+// heartbeat registers or refreshes a worker
+func (c *coordinator) heartbeat(hb WorkerHeartbeat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w, ok := c.workers[hb.ID]
+	if !ok {
+		// Keep what it already streams so a restarted coordinator does not
+		// shuffle symbols between running workers
+		w = &workerInfo{ID: hb.ID, Assigned: hb.Symbols}
+		c.workers[hb.ID] = w
+		slog.Info("Worker registered", "worker", hb.ID, "exchange", hb.Exchange)
+	}
+	w.Exchange = hb.Exchange
+	w.Streaming = hb.Symbols
+	w.LastSeen = time.Now()
+
+	if !ok {
+		c.rebalance()
+	} else if !sameSymbols(w.Assigned, w.Streaming) {
+		// Lost or not yet applied; assignments are idempotent
+		c.assign(w)
+	}
+}
+
+// leave drops a worker that announced its shutdown
+func (c *coordinator) leave(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.workers[id]; !ok {
+		return
+	}
+	delete(c.workers, id)
+	slog.Info("Worker left", "worker", id)
+	c.rebalance()
+}
+
+// setSymbols changes the symbols spread across the workers
+func (c *coordinator) setSymbols(symbols []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.symbols = append([]string(nil), symbols...)
+	c.rebalance()
+}
+
+// expire drops workers whose heartbeats stopped
+func (c *coordinator) expire(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expired := false
+	for id, w := range c.workers {
+		if now.Sub(w.LastSeen) > workerTimeout {
+			delete(c.workers, id)
+			slog.Warn("Worker timed out, reassigning", "worker", id, "symbols", w.Assigned)
+			expired = true
+		}
+	}
+	if expired {
+		c.rebalance()
+	}
+}
+
+// run expires dead workers until ctx is cancelled
+func (c *coordinator) run(ctx context.Context) {
+	ticker := time.NewTicker(workerTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.expire(now)
+		}
+	}
+}
+
+// rebalance gives every tracked symbol to exactly one worker, keeping
+// existing assignments where it can and evening out the load so no worker
+// has more than one symbol above another. Changed assignments are sent.
+// Callers hold c.mu.
+func (c *coordinator) rebalance() {
+	if len(c.workers) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(c.workers))
+	for id := range c.workers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	owned := make(map[string][]string)
+	taken := make(map[string]bool)
+	for _, id := range ids {
+		for _, sym := range c.workers[id].Assigned {
+			if slices.Contains(c.symbols, sym) && !taken[sym] {
+				owned[id] = append(owned[id], sym)
+				taken[sym] = true
+			}
+		}
+	}
+
+	lightest := func() string {
+		best := ids[0]
+		for _, id := range ids[1:] {
+			if len(owned[id]) < len(owned[best]) {
+				best = id
+			}
+		}
+		return best
+	}
+	heaviest := func() string {
+		best := ids[0]
+		for _, id := range ids[1:] {
+			if len(owned[id]) > len(owned[best]) {
+				best = id
+			}
+		}
+		return best
+	}
+
+	for _, sym := range c.symbols {
+		if !taken[sym] {
+			id := lightest()
+			owned[id] = append(owned[id], sym)
+		}
+	}
+	for {
+		from, to := heaviest(), lightest()
+		if len(owned[from])-len(owned[to]) <= 1 {
+			break
+		}
+		n := len(owned[from]) - 1
+		owned[to] = append(owned[to], owned[from][n])
+		owned[from] = owned[from][:n]
+	}
+
+	for _, id := range ids {
+		w := c.workers[id]
+		assigned := owned[id]
+		if assigned == nil {
+			assigned = []string{}
+		}
+		changed := !sameSymbols(w.Assigned, assigned)
+		w.Assigned = assigned
+		if changed {
+			c.assign(w)
+		}
+	}
+}
+
+// assign sends a worker its symbols
+func (c *coordinator) assign(w *workerInfo) {
+	data, _ := json.Marshal(WorkerAssignment{Symbols: w.Assigned, Time: time.Now().UnixMilli()})
+	c.nc.Publish("workers.assign."+w.ID, data)
+}
+
+// list returns the workers ordered by ID
+func (c *coordinator) list() []workerInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]workerInfo, 0, len(c.workers))
+	for _, w := range c.workers {
+		list = append(list, *w)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// sameSymbols reports whether a and b hold the same symbols in any order
+func sameSymbols(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, sym := range a {
+		if !slices.Contains(b, sym) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.workers.list())
+}
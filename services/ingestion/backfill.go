@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Longest a backfill may hold up a symbol's live stream
+const backfillTimeout = 10 * time.Second
+
+// Number of recent candles replayed before a symbol goes live; 0 disables
+var backfillLimit = 500
+
+// Backfiller is implemented by exchanges that can provide recent history
+type Backfiller interface {
+	// Backfill returns up to limit recent one-minute closes for symbol,
+	// oldest first, as trades stamped with the candle close time
+	Backfill(ctx context.Context, symbol string, limit int) ([]TradeMessage, error)
+}
+
+// backfill sends recent history for symbol into trades, marked so
+// downstream services can tell it from live ticks. Failures are logged and
+// the stream goes live without it.
+func backfill(ctx context.Context, bf Backfiller, symbol string, trades chan<- TradeMessage) {
+	if backfillLimit <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, backfillTimeout)
+	defer cancel()
+
+	history, err := bf.Backfill(ctx, symbol, backfillLimit)
+	if err != nil {
+		slog.Warn("Backfill failed", "symbol", symbol, "err", err)
+		return
+	}
+	for _, trade := range history {
+		trade.Symbol = symbol
+		trade.Backfill = true
+		trades <- trade
+	}
+	slog.Info("Backfilled candles", "symbol", symbol, "count", len(history))
+}
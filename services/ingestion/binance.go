@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BinanceTrade represents a trade event from Binance. The trade ID and
+// the ignored "M" flag are declared so their keys can't land in Time or
+// BuyerMaker via case-insensitive matching.
+type BinanceTrade struct {
+	Price      string `json:"p"`
+	Quantity   string `json:"q"`
+	Time       int64  `json:"T"`
+	TradeID    int64  `json:"t"`
+	BuyerMaker bool   `json:"m"`
+	Ignore     bool   `json:"M"`
+}
+
+// BinanceTicker represents a 24hr rolling ticker event; keys that differ
+// from the used ones only by case are declared for the same reason
+type BinanceTicker struct {
+	Close       string `json:"c"`
+	CloseTime   int64  `json:"C"`
+	LastQty     string `json:"Q"`
+	QuoteVolume string `json:"q"`
+}
+
+// BinanceMiniTicker represents a 24hr rolling mini ticker event
+type BinanceMiniTicker struct {
+	Close string `json:"c"`
+}
+
+// BinanceError is an error frame, either {"code":..,"msg":..} or
+// {"error":{"code":..,"msg":..},"id":..}
+type BinanceError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// binanceEnvelope holds the fields that identify a message's shape
+type binanceEnvelope struct {
+	Event     string           `json:"e"`
+	EventTime int64            `json:"E"`
+	Code      int              `json:"code"`
+	Msg       string           `json:"msg"`
+	Error     *BinanceError    `json:"error"`
+	Result    *json.RawMessage `json:"result"`
+	ID        *int64           `json:"id"`
+}
+
+// errNotPrice marks well-formed messages that carry no price, such as
+// subscription results and unrelated events
+var errNotPrice = errors.New("not a price update")
+
+// parseBinanceMessage converts a trade, 24hrTicker or 24hrMiniTicker
+// message into a TradeMessage without a symbol. Error frames and bad
+// prices are reported as errors; other messages yield errNotPrice.
+func parseBinanceMessage(message []byte) (TradeMessage, error) {
+	var env binanceEnvelope
+	if err := json.Unmarshal(message, &env); err != nil {
+		return TradeMessage{}, fmt.Errorf("malformed message: %w", err)
+	}
+
+	switch {
+	case env.Error != nil:
+		return TradeMessage{}, fmt.Errorf("code %d: %s", env.Error.Code, env.Error.Msg)
+	case env.Msg != "" || env.Code != 0:
+		return TradeMessage{}, fmt.Errorf("code %d: %s", env.Code, env.Msg)
+	case env.ID != nil || env.Result != nil:
+		return TradeMessage{}, errNotPrice
+	}
+
+	var price, quantity, side string
+	t := env.EventTime
+	switch env.Event {
+	case "trade":
+		var trade BinanceTrade
+		if err := json.Unmarshal(message, &trade); err != nil {
+			return TradeMessage{}, fmt.Errorf("malformed trade: %w", err)
+		}
+		price, quantity = trade.Price, trade.Quantity
+		if trade.Time > 0 {
+			t = trade.Time // when it matched, a little before the event
+		}
+		// A resting buy order filled means a seller took it
+		side = "buy"
+		if trade.BuyerMaker {
+			side = "sell"
+		}
+	case "24hrTicker":
+		// The last quantity repeats on every update, so isn't passed on
+		// as traded
+		var ticker BinanceTicker
+		if err := json.Unmarshal(message, &ticker); err != nil {
+			return TradeMessage{}, fmt.Errorf("malformed ticker: %w", err)
+		}
+		price = ticker.Close
+	case "24hrMiniTicker":
+		var ticker BinanceMiniTicker
+		if err := json.Unmarshal(message, &ticker); err != nil {
+			return TradeMessage{}, fmt.Errorf("malformed mini ticker: %w", err)
+		}
+		price = ticker.Close
+	default:
+		return TradeMessage{}, errNotPrice
+	}
+
+	p, err := strconv.ParseFloat(price, 64)
+	if err != nil || !(p > 0) {
+		return TradeMessage{}, fmt.Errorf("invalid %s price %q", env.Event, price)
+	}
+	q, _ := strconv.ParseFloat(quantity, 64)
+	return TradeMessage{Price: p, Quantity: q, Time: t, Side: side, Ticker: env.Event != "trade"}, nil
+}
+
+// Binance endpoints, production and the spot testnet
+const (
+	binanceWSURL          = "wss://stream.binance.com:9443/ws"
+	binanceRESTURL        = "https://api.binance.com"
+	binanceTestnetWSURL   = "wss://stream.testnet.binance.vision/ws"
+	binanceTestnetRESTURL = "https://testnet.binance.vision"
+)
+
+// Binance streams a feed can subscribe to, set by BINANCE_STREAM. The trade
+// stream carries every trade, with the quantity and side VWAP, volume and
+// the tape need, but on a busy pair that is many messages a second; the
+// mini ticker sends just the last price once a second.
+const (
+	streamTrade  = "trade"
+	streamTicker = "ticker"
+	streamBoth   = "both"
+)
+
+// binance streams from Binance's market streams, every symbol over one
+// combined connection. Empty URLs mean the production endpoints and an
+// empty stream the trade stream.
+type binance struct {
+	wsURL   string // WebSocket base for raw /<stream>s; its sibling /stream is the combined one
+	restURL string // REST base for depth snapshots and klines
+	stream  string // streamTrade, streamTicker or streamBoth
+}
+
+// streamMode reports what exchange's feeds subscribe to: Binance's
+// BINANCE_STREAM setting, otherwise trades
+func streamMode(exchange Exchange) string {
+	if b, ok := exchange.(binance); ok && b.stream != "" {
+		return b.stream
+	}
+	return streamTrade
+}
+
+// streams names the streams carrying native's prices
+func (b binance) streams(native string) []string {
+	switch b.stream {
+	case streamTicker:
+		return []string{native + "@miniTicker"}
+	case streamBoth:
+		return []string{native + "@trade", native + "@miniTicker"}
+	}
+	return []string{native + "@trade"}
+}
+
+// newBinance picks the testnet or production endpoints, overridden by
+// wsURL and restURL when given, e.g. for a proxy or a local mock
+func newBinance(testnet bool, wsURL, restURL string) (binance, error) {
+	b := binance{wsURL: binanceWSURL, restURL: binanceRESTURL}
+	if testnet {
+		b = binance{wsURL: binanceTestnetWSURL, restURL: binanceTestnetRESTURL}
+	}
+	if wsURL != "" {
+		if err := checkBaseURL(wsURL, "ws", "wss"); err != nil {
+			return binance{}, fmt.Errorf("WebSocket URL: %w", err)
+		}
+		b.wsURL = strings.TrimSuffix(wsURL, "/")
+	}
+	if restURL != "" {
+		if err := checkBaseURL(restURL, "http", "https"); err != nil {
+			return binance{}, fmt.Errorf("REST URL: %w", err)
+		}
+		b.restURL = strings.TrimSuffix(restURL, "/")
+	}
+	return b, nil
+}
+
+// checkBaseURL rejects URLs without a host or with a scheme other than those
+// given
+func checkBaseURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("%q must use %s", raw, strings.Join(schemes, " or "))
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q must not have a query or fragment", raw)
+	}
+	return nil
+}
+
+// combinedURL returns the URL of one connection carrying all streams, whose
+// messages arrive wrapped as {"stream":..,"data":..}. The combined
+// endpoint is /stream next to the raw /ws one.
+func (b binance) combinedURL(streams []string) string {
+	base := b.wsURL
+	if base == "" {
+		base = binanceWSURL
+	}
+	return strings.TrimSuffix(base, "/ws") + "/stream?streams=" + strings.Join(streams, "/")
+}
+
+// streamURL returns the WebSocket URL of stream
+func (b binance) streamURL(stream string) string {
+	if b.wsURL == "" {
+		return binanceWSURL + "/" + stream
+	}
+	return b.wsURL + "/" + stream
+}
+
+// apiURL returns the REST URL of path
+func (b binance) apiURL(path string) string {
+	if b.restURL == "" {
+		return binanceRESTURL + path
+	}
+	return b.restURL + path
+}
+
+func (binance) Name() string { return "binance" }
+
+func (binance) NativeSymbol(symbol string) string { return symbol }
+
+func (b binance) Connect(ctx context.Context, symbol string, trades chan<- TradeMessage, feed *feedMonitor) bool {
+	streams := b.streams(b.NativeSymbol(symbol))
+	if len(streams) > 1 {
+		// A raw stream URL carries only one stream
+		return b.ConnectCombined(ctx, []string{symbol}, trades, map[string]*feedMonitor{symbol: feed})
+	}
+	url := b.streamURL(streams[0])
+
+	return streamWebSocket(ctx, "Binance", url, nil, feed, func(message []byte) {
+		trade, err := parseBinanceMessage(message)
+		if err == errNotPrice {
+			return
+		}
+		if err != nil {
+			slog.Warn("Binance error", "symbol", symbol, "err", err)
+			return
+		}
+		trade.Symbol = symbol
+		offer(trades, trade, feed)
+	})
+}
+
+// BinanceCombined wraps every message of a combined stream
+type BinanceCombined struct {
+	Stream string          `json:"stream"` // e.g. btcusdt@trade or btcusdt@miniTicker
+	Data   json.RawMessage `json:"data"`
+}
+
+// ConnectCombined streams every symbol's trades over one connection,
+// routing each message to its symbol by the stream name
+func (b binance) ConnectCombined(ctx context.Context, symbols []string, trades chan<- TradeMessage, feeds map[string]*feedMonitor) bool {
+	streams := make([]string, 0, len(symbols))
+	bySymbol := make(map[string]string, len(symbols))
+	for _, symbol := range symbols {
+		native := b.NativeSymbol(symbol)
+		streams = append(streams, b.streams(native)...)
+		bySymbol[native] = symbol
+	}
+
+	return streamWebSocket(ctx, "Binance", b.combinedURL(streams), nil, newSharedFeed(feeds), func(message []byte) {
+		var wrapped BinanceCombined
+		if err := json.Unmarshal(message, &wrapped); err != nil || wrapped.Stream == "" {
+			// Error frames and results aren't wrapped
+			if _, err := parseBinanceMessage(message); err != nil && err != errNotPrice {
+				slog.Warn("Binance error", "err", err)
+			}
+			return
+		}
+		native, _, _ := strings.Cut(wrapped.Stream, "@")
+		symbol, ok := bySymbol[native]
+		if !ok {
+			return
+		}
+		feeds[symbol].message(time.Now())
+
+		trade, err := parseBinanceMessage(wrapped.Data)
+		if err == errNotPrice {
+			return
+		}
+		if err != nil {
+			slog.Warn("Binance error", "symbol", symbol, "err", err)
+			return
+		}
+		trade.Symbol = symbol
+		offer(trades, trade, feeds[symbol])
+	})
+}
+
+// BinanceDepth is a partial book depth snapshot, from the REST depth
+// endpoint or the @depth<levels> stream
+type BinanceDepth struct {
+	LastUpdateID int64       `json:"lastUpdateId"`
+	Bids         [][2]string `json:"bids"`
+	Asks         [][2]string `json:"asks"`
+}
+
+// Consecutive stale stream frames before the book is resynced over REST
+const maxStaleDepthFrames = 10
+
+// depthSnapshot fetches the current top of book over REST
+func (b binance) depthSnapshot(ctx context.Context, symbol string) (BinanceDepth, error) {
+	url := b.apiURL(fmt.Sprintf("/api/v3/depth?symbol=%s&limit=%d", strings.ToUpper(symbol), depthLevels))
+	resp, err := restGet(ctx, url)
+	if err != nil {
+		return BinanceDepth{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return BinanceDepth{}, fmt.Errorf("depth snapshot: %s", resp.Status)
+	}
+
+	var depth BinanceDepth
+	err = json.NewDecoder(resp.Body).Decode(&depth)
+	return depth, err
+}
+
+// Backfill fetches the last limit one-minute klines over REST. The newest
+// kline is still open, so its close time is capped at now.
+func (b binance) Backfill(ctx context.Context, symbol string, limit int) ([]TradeMessage, error) {
+	url := b.apiURL(fmt.Sprintf("/api/v3/klines?symbol=%s&interval=1m&limit=%d", strings.ToUpper(symbol), limit))
+	resp, err := restGet(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("klines: %s", resp.Status)
+	}
+
+	// [openTime, open, high, low, close, volume, closeTime, ...]
+	var klines [][]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&klines); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	trades := make([]TradeMessage, 0, len(klines))
+	for _, k := range klines {
+		if len(k) < 7 {
+			continue
+		}
+		var closeStr, volumeStr string
+		var closeTime int64
+		if json.Unmarshal(k[4], &closeStr) != nil || json.Unmarshal(k[5], &volumeStr) != nil || json.Unmarshal(k[6], &closeTime) != nil {
+			continue
+		}
+		price, err := strconv.ParseFloat(closeStr, 64)
+		if err != nil || !(price > 0) {
+			continue
+		}
+		volume, _ := strconv.ParseFloat(volumeStr, 64)
+		if closeTime > now {
+			closeTime = now
+		}
+		trades = append(trades, TradeMessage{Price: price, Quantity: volume, Time: closeTime})
+	}
+	return trades, nil
+}
+
+// StreamDepth seeds the book from a REST snapshot, then follows the
+// partial depth stream. Frames older than the last applied one are dropped;
+// if the stream keeps lagging the book is resynced from REST.
+func (b binance) StreamDepth(ctx context.Context, symbol string, updates chan<- DepthUpdate) bool {
+	var lastID int64
+	stale := 0
+	sent := false
+
+	apply := func(depth BinanceDepth) {
+		bids, err := parseLevels(depth.Bids)
+		if err != nil {
+			slog.Warn("Invalid Binance depth", "symbol", symbol, "err", err)
+			return
+		}
+		asks, err := parseLevels(depth.Asks)
+		if err != nil {
+			slog.Warn("Invalid Binance depth", "symbol", symbol, "err", err)
+			return
+		}
+		lastID = depth.LastUpdateID
+		sent = true
+		updates <- DepthUpdate{
+			Symbol:   symbol,
+			Bids:     bids,
+			Asks:     asks,
+			UpdateID: depth.LastUpdateID,
+			Time:     time.Now().UnixMilli(),
+		}
+	}
+
+	resync := func() {
+		depth, err := b.depthSnapshot(ctx, b.NativeSymbol(symbol))
+		if err != nil {
+			slog.Warn("Binance depth snapshot failed", "symbol", symbol, "err", err)
+			return
+		}
+		apply(depth)
+	}
+	resync()
+
+	url := b.streamURL(fmt.Sprintf("%s@depth%d@100ms", b.NativeSymbol(symbol), depthLevels))
+	streamWebSocket(ctx, "Binance depth", url, nil, nil, func(message []byte) {
+		var depth BinanceDepth
+		if err := json.Unmarshal(message, &depth); err != nil || depth.LastUpdateID == 0 {
+			if _, perr := parseBinanceMessage(message); perr != nil && perr != errNotPrice {
+				slog.Warn("Binance depth error", "symbol", symbol, "err", perr)
+			}
+			return
+		}
+
+		if depth.LastUpdateID <= lastID {
+			if stale++; stale >= maxStaleDepthFrames {
+				slog.Info("Binance depth is behind, resyncing", "symbol", symbol)
+				stale = 0
+				lastID = 0
+				resync()
+			}
+			return
+		}
+		stale = 0
+		apply(depth)
+	})
+	return sent
+}
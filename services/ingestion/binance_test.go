@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// Frames as Binance sends them on /ws/<symbol>@<stream>
+const (
+	tradeFrame = `{"e":"trade","E":1718035200123,"s":"BTCUSDT","t":3617551568,"p":"67123.45000000","q":"0.00150000","T":1718035200120,"m":true,"M":true}`
+
+	tickerFrame = `{"e":"24hrTicker","E":1718035200456,"s":"ETHUSDT","p":"-41.20000000","P":"-1.118","w":"3672.51349720",` +
+		`"x":"3684.01000000","c":"3642.81000000","Q":"0.05470000","b":"3642.80000000","B":"31.56720000","a":"3642.81000000",` +
+		`"A":"6.10310000","o":"3684.01000000","h":"3712.00000000","l":"3620.00000000","v":"254877.36250000",` +
+		`"q":"936044171.57476300","O":1717948800456,"C":1718035200456,"F":1468746713,"L":1469589991,"n":843279}`
+
+	miniTickerFrame = `{"e":"24hrMiniTicker","E":1718035201000,"s":"SOLUSDT","c":"158.37000000","o":"160.02000000",` +
+		`"h":"162.50000000","l":"155.80000000","v":"3019238.12000000","q":"480346024.31700000"}`
+)
+
+func TestParseBinanceMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   string
+		want    TradeMessage
+		wantErr bool
+		notTick bool // errNotPrice rather than a failure
+	}{
+		{
+			name:  "trade, buyer maker is a sell",
+			frame: tradeFrame,
+			want:  TradeMessage{Price: 67123.45, Quantity: 0.0015, Time: 1718035200120, Side: "sell"},
+		},
+		{
+			name:  "trade, taker buy",
+			frame: `{"e":"trade","E":1718035200200,"s":"BTCUSDT","t":3617551569,"p":"67123.46","q":"0.42","T":1718035200199,"m":false,"M":true}`,
+			want:  TradeMessage{Price: 67123.46, Quantity: 0.42, Time: 1718035200199, Side: "buy"},
+		},
+		{
+			name:  "trade without a trade time takes the event time",
+			frame: `{"e":"trade","E":1718035200300,"s":"BTCUSDT","p":"1.5","q":"2","m":false}`,
+			want:  TradeMessage{Price: 1.5, Quantity: 2, Time: 1718035200300, Side: "buy"},
+		},
+		{
+			name:  "24h ticker last price, its last quantity dropped",
+			frame: tickerFrame,
+			want:  TradeMessage{Price: 3642.81, Time: 1718035200456, Ticker: true},
+		},
+		{
+			name:  "mini ticker last price",
+			frame: miniTickerFrame,
+			want:  TradeMessage{Price: 158.37, Time: 1718035201000, Ticker: true},
+		},
+		{name: "subscribe ack", frame: `{"result":null,"id":1}`, notTick: true},
+		{name: "unknown event", frame: `{"e":"aggTrade","E":1718035200123,"s":"BTCUSDT","a":1,"p":"67123.45","q":"0.1"}`, notTick: true},
+		{name: "no event", frame: `{"s":"BTCUSDT"}`, notTick: true},
+		{name: "error frame", frame: `{"error":{"code":2,"msg":"Invalid request: unknown variant"},"id":1}`, wantErr: true},
+		{name: "error code", frame: `{"code":-1121,"msg":"Invalid symbol."}`, wantErr: true},
+		{name: "not JSON", frame: `{"e":"trade","p":`, wantErr: true},
+		{name: "JSON array", frame: `[1,2,3]`, wantErr: true},
+		{name: "price not a string", frame: `{"e":"trade","E":1,"p":67123.45,"q":"1"}`, wantErr: true},
+		{name: "zero price", frame: `{"e":"trade","E":1,"p":"0.00000000","q":"1"}`, wantErr: true},
+		{name: "negative price", frame: `{"e":"24hrMiniTicker","E":1,"c":"-3.2"}`, wantErr: true},
+		{name: "unparsable price", frame: `{"e":"24hrTicker","E":1,"c":"NaN"}`, wantErr: true},
+		{name: "missing price", frame: `{"e":"24hrMiniTicker","E":1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBinanceMessage([]byte(tt.frame))
+			switch {
+			case tt.notTick:
+				if !errors.Is(err, errNotPrice) {
+					t.Fatalf("err = %v, want errNotPrice", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, errNotPrice) {
+					t.Fatalf("err = %v, want a parse error; got %+v", err, got)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case got != tt.want:
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
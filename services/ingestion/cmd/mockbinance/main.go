@@ -0,0 +1,244 @@
+// Command mockbinance is a stand-in for Binance's WebSocket and REST
+// endpoints that plays a fixed price script, so the pipeline can be run
+// end to end without the exchange. Point the ingestion service at it with
+// BINANCE_WS_URL=ws://localhost:9443/ws and
+// BINANCE_REST_URL=http://localhost:9443, and the API with the latter.
+//
+// Every trade stream connection plays the script once and then stays open
+// and silent, so a client sees each price exactly once; -loop replays it
+// instead. Combined connections (/stream?streams=a@trade/b@trade) play it
+// on each trade stream in turn, wrapped as Binance does. Depth streams stay silent, depth snapshots are empty and kline
+// backfills return nothing.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultScript is played when neither -prices nor -file is given
+const defaultScript = "100,101,102,101.5,103,104,103.5,102,101,100.5," +
+	"99,98.5,99.5,100,101,102.5,103,104.5,105,104," +
+	"103,102,101.5,101,100,99.5,100.5,101.5,102,102.5"
+
+var (
+	addr     = flag.String("addr", ":9443", "listen address")
+	prices   = flag.String("prices", "", "comma-separated prices to play (default a built-in 30-tick script)")
+	file     = flag.String("file", "", "file with one price, or price,quantity, per line")
+	interval = flag.Duration("interval", 50*time.Millisecond, "delay between frames")
+	loop     = flag.Bool("loop", false, "replay the script forever instead of once per connection")
+	symbols  = flag.String("symbols", "btcusdt,ethusdt,solusdt,bnbusdt,xrpusdt,dogeusdt,ethbtc,btceur", "comma-separated pairs exchangeInfo lists as trading")
+	tickSize = flag.String("tick", "0.01", "PRICE_FILTER tick size exchangeInfo gives every pair")
+)
+
+// tick is one scripted trade
+type tick struct {
+	price, quantity float64
+}
+
+// parseTick reads "price" or "price,quantity"
+func parseTick(s string) (tick, error) {
+	fields := strings.Split(strings.TrimSpace(s), ",")
+	price, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	if err != nil || !(price > 0) {
+		return tick{}, fmt.Errorf("invalid price %q", fields[0])
+	}
+	t := tick{price: price, quantity: 1}
+	if len(fields) > 1 {
+		if t.quantity, err = strconv.ParseFloat(strings.TrimSpace(fields[1]), 64); err != nil {
+			return tick{}, fmt.Errorf("invalid quantity %q", fields[1])
+		}
+	}
+	return t, nil
+}
+
+// loadScript reads the ticks to play from -file, -prices or the default
+func loadScript() ([]tick, error) {
+	var lines []string
+	switch {
+	case *file != "":
+		f, err := os.Open(*file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				lines = append(lines, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case *prices != "":
+		lines = strings.Split(*prices, ",")
+	default:
+		lines = strings.Split(defaultScript, ",")
+	}
+
+	script := make([]tick, 0, len(lines))
+	for _, line := range lines {
+		t, err := parseTick(line)
+		if err != nil {
+			return nil, err
+		}
+		script = append(script, t)
+	}
+	if len(script) == 0 {
+		return nil, fmt.Errorf("empty script")
+	}
+	return script, nil
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// serveStream plays the script on trade streams and idles on any other.
+// A raw connection carries the stream in its path, a combined one a list in
+// its streams parameter.
+func serveStream(script []tick) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		combined := r.URL.Path == "/stream"
+		stream := strings.TrimPrefix(r.URL.Path, "/ws/")
+		if combined {
+			stream = r.URL.Query().Get("streams")
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		log.Printf("Client connected to %s", stream)
+
+		// Reading notices the client going away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		var trades []string
+		for _, s := range strings.Split(stream, "/") {
+			if strings.HasSuffix(s, "@trade") {
+				trades = append(trades, s)
+			}
+		}
+		if len(trades) == 0 {
+			<-closed
+			return
+		}
+
+		id := int64(0)
+		for {
+			for _, t := range script {
+				select {
+				case <-closed:
+					return
+				case <-time.After(*interval):
+				}
+				id++
+				for _, s := range trades {
+					if err := conn.WriteMessage(websocket.TextMessage, tradeFrame(s, id, t, combined)); err != nil {
+						return
+					}
+				}
+			}
+			log.Printf("Played %d ticks on %s", len(script), stream)
+			if !*loop {
+				break
+			}
+		}
+		<-closed
+	}
+}
+
+// tradeFrame renders t as a trade event of stream, wrapped in a
+// {stream, data} envelope for combined connections
+func tradeFrame(stream string, id int64, t tick, combined bool) []byte {
+	symbol, _, _ := strings.Cut(stream, "@")
+	now := time.Now().UnixMilli()
+	var event interface{} = map[string]interface{}{
+		"e": "trade",
+		"E": now,
+		"s": strings.ToUpper(symbol),
+		"t": id,
+		"p": strconv.FormatFloat(t.price, 'f', -1, 64),
+		"q": strconv.FormatFloat(t.quantity, 'f', -1, 64),
+		"T": now,
+		"m": id%2 == 0, // alternate aggressor sides
+	}
+	if combined {
+		event = map[string]interface{}{"stream": stream, "data": event}
+	}
+	frame, _ := json.Marshal(event)
+	return frame
+}
+
+func main() {
+	flag.Parse()
+	script, err := loadScript()
+	if err != nil {
+		log.Fatalf("Invalid script: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/", serveStream(script))
+	mux.HandleFunc("/stream", serveStream(script))
+	mux.HandleFunc("/api/v3/depth", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"lastUpdateId":1,"bids":[],"asks":[]}`)
+	})
+	mux.HandleFunc("/api/v3/exchangeInfo", func(w http.ResponseWriter, r *http.Request) {
+		type filter struct {
+			FilterType string `json:"filterType"`
+			TickSize   string `json:"tickSize"`
+		}
+		type symbolInfo struct {
+			Symbol  string   `json:"symbol"`
+			Status  string   `json:"status"`
+			Filters []filter `json:"filters"`
+		}
+		var info struct {
+			Symbols []symbolInfo `json:"symbols"`
+		}
+		// ?symbol= narrows the list to one pair, a 400 if it isn't listed,
+		// as on Binance
+		want := strings.ToUpper(r.URL.Query().Get("symbol"))
+		for _, s := range strings.Split(*symbols, ",") {
+			s = strings.ToUpper(s)
+			if want != "" && s != want {
+				continue
+			}
+			info.Symbols = append(info.Symbols, symbolInfo{s, "TRADING", []filter{{"PRICE_FILTER", *tickSize}}})
+		}
+		if want != "" && len(info.Symbols) == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-1121,"msg":"Invalid symbol."}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	})
+	mux.HandleFunc("/api/v3/klines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	})
+
+	log.Printf("Mock Binance on %s playing %d ticks", *addr, len(script))
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
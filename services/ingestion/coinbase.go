@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CoinbaseMatch represents a match (trade) event from Coinbase
+type CoinbaseMatch struct {
+	Type      string    `json:"type"`
+	ProductID string    `json:"product_id"`
+	Price     string    `json:"price"`
+	Size      string    `json:"size"`
+	Side      string    `json:"side"` // of the resting maker order
+	Time      time.Time `json:"time"`
+}
+
+// coinbase streams from Coinbase Exchange's matches channel
+type coinbase struct{}
+
+func (coinbase) Name() string { return "coinbase" }
+
+// NativeSymbol maps btcusdt to BTC-USD; Coinbase quotes in USD rather than USDT
+func (coinbase) NativeSymbol(symbol string) string {
+	base, quote := splitSymbol(symbol)
+	if quote == "usdt" || quote == "" {
+		quote = "usd"
+	}
+	return strings.ToUpper(base + "-" + quote)
+}
+
+func (c coinbase) Connect(ctx context.Context, symbol string, trades chan<- TradeMessage, feed *feedMonitor) bool {
+	subscribe, _ := json.Marshal(map[string]interface{}{
+		"type":        "subscribe",
+		"product_ids": []string{c.NativeSymbol(symbol)},
+		"channels":    []string{"matches"},
+	})
+
+	return streamWebSocket(ctx, "Coinbase", "wss://ws-feed.exchange.coinbase.com", subscribe, feed, func(message []byte) {
+		var match CoinbaseMatch
+		if err := json.Unmarshal(message, &match); err != nil || match.Type != "match" {
+			return
+		}
+
+		price, err := strconv.ParseFloat(match.Price, 64)
+		if err != nil || price <= 0 {
+			return
+		}
+		quantity, _ := strconv.ParseFloat(match.Size, 64)
+		// The aggressor took the other side of the maker's order
+		side := ""
+		switch match.Side {
+		case "buy":
+			side = "sell"
+		case "sell":
+			side = "buy"
+		}
+		offer(trades, TradeMessage{Symbol: symbol, Price: price, Quantity: quantity, Time: match.Time.UnixMilli(), Side: side}, feed)
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CombinedStreamer is implemented by exchanges that can carry every
+// symbol's trades over a single connection
+type CombinedStreamer interface {
+	// ConnectCombined streams trades for all symbols into trades until the
+	// connection drops or ctx is cancelled, reporting to each symbol's feed
+	// once the stream is up and on every message for it. It reports
+	// whether any message was received.
+	ConnectCombined(ctx context.Context, symbols []string, trades chan<- TradeMessage, feeds map[string]*feedMonitor) bool
+}
+
+// restartCombined replaces the combined connection with one carrying every
+// tracked symbol; the old one is closed before the new one dials. Callers
+// hold ss.mu.
+func (ss *streamSet) restartCombined(cs CombinedStreamer) {
+	if ss.combinedCancel != nil {
+		ss.combinedCancel()
+		ss.combinedCancel = nil
+	}
+	if len(ss.statuses) == 0 {
+		return
+	}
+
+	symbols := make([]string, 0, len(ss.statuses))
+	statuses := make(map[string]*symbolStatus, len(ss.statuses))
+	for sym, st := range ss.statuses {
+		symbols = append(symbols, sym)
+		statuses[sym] = st
+	}
+	sort.Strings(symbols)
+
+	ctx, cancel := context.WithCancel(ss.ctx)
+	ss.combinedCancel = cancel
+	prev := ss.combinedDone
+	done := make(chan struct{})
+	ss.combinedDone = done
+
+	ss.wg.Add(1)
+	go func() {
+		defer ss.wg.Done()
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		ss.streamCombined(ctx, cs, symbols, statuses)
+	}()
+}
+
+// streamCombined keeps one connection alive for symbols, backing off
+// exponentially between failures, until ctx is cancelled or the reconnects
+// are exhausted. Symbols added since the last connection are backfilled
+// first.
+func (ss *streamSet) streamCombined(ctx context.Context, cs CombinedStreamer, symbols []string, statuses map[string]*symbolStatus) {
+	trades, stop := publishTrades(ss.nc, ss.exchange)
+	defer stop()
+
+	if bf, ok := ss.exchange.(Backfiller); ok {
+		ss.backfillPending(ctx, bf, symbols, trades)
+	}
+
+	feeds := make(map[string]*feedMonitor, len(statuses))
+	for sym, st := range statuses {
+		feeds[sym] = st.feed
+	}
+
+	backoff := minBackoff
+	reconnects := 0 // since the last connection that received anything
+	for {
+		slog.Debug("Streaming combined", "exchange", ss.exchange.Name(), "symbols", symbols)
+		received := cs.ConnectCombined(ctx, symbols, trades, feeds)
+		if ctx.Err() != nil {
+			// Dropped symbols report down themselves; kept ones reconnect
+			return
+		}
+
+		if received {
+			backoff, reconnects = minBackoff, 0
+		} else if maxReconnects > 0 && reconnects >= maxReconnects {
+			// A symbol change dials afresh, retrying these
+			list := make([]*symbolStatus, 0, len(statuses))
+			for _, st := range statuses {
+				list = append(list, st)
+			}
+			giveUp(ss.nc, ss.exchange, symbols, list...)
+			ss.gaveUp(symbols...)
+			return
+		}
+		reconnects++
+		for _, st := range statuses {
+			st.set(stateReconnecting)
+		}
+		slog.Info("Reconnecting", "exchange", ss.exchange.Name(), "symbols", symbols, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// backfillPending backfills the symbols not yet seeded, in parallel so a
+// slow one holds up the others' live stream as little as possible. A
+// backfill cut short by a restart is retried on the next connection.
+func (ss *streamSet) backfillPending(ctx context.Context, bf Backfiller, symbols []string, trades chan<- TradeMessage) {
+	ss.mu.Lock()
+	var pending []string
+	for _, sym := range symbols {
+		if ss.pendingBackfill[sym] {
+			pending = append(pending, sym)
+		}
+	}
+	ss.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sym := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			backfill(ctx, bf, sym, trades)
+			if ctx.Err() == nil {
+				ss.mu.Lock()
+				delete(ss.pendingBackfill, sym)
+				ss.mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
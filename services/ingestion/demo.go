@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Demo feed defaults: a tick every defaultDemoInterval with 80% annualized
+// volatility, about what BTC shows
+const (
+	defaultDemoInterval   = 250 * time.Millisecond
+	defaultDemoVolatility = 0.8
+)
+
+// Prices the demo walks start from, by base asset in dollars; other
+// quotes are converted through the base's dollar price
+var demoStartPrices = map[string]float64{
+	"btc": 65000, "eth": 3200, "bnb": 580, "sol": 150, "xrp": 0.55, "ada": 0.45,
+	"doge": 0.15, "avax": 35, "dot": 7, "link": 15, "ltc": 85, "matic": 0.7,
+}
+
+// demo feeds a synthetic price for every symbol, a geometric Brownian
+// motion with no drift, instead of a live exchange. Each symbol's walk is
+// drawn from its own generator seeded from seed and the symbol, so a seed
+// replays the same prices whatever else is tracked; a reconnect carries on
+// from where the walk stood. Trades are stamped with the current time.
+type demo struct {
+	seed       uint64
+	interval   time.Duration
+	volatility float64 // annualized, as a fraction
+
+	mu    sync.Mutex
+	walks map[string]*demoWalk
+}
+
+// demoWalk is one symbol's position in its walk
+type demoWalk struct {
+	rng   *rand.Rand
+	price float64 // unrounded
+	size  float64 // mean trade quantity, about $10,000 worth
+}
+
+// newDemo validates the demo feed's settings. An empty seed picks one from
+// the clock, logged so the run can be repeated.
+func newDemo(seed, interval, volatility string) (*demo, error) {
+	d := &demo{interval: defaultDemoInterval, volatility: defaultDemoVolatility, walks: make(map[string]*demoWalk)}
+	if seed != "" {
+		s, err := strconv.ParseUint(seed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid demo seed %q", seed)
+		}
+		d.seed = s
+	} else {
+		d.seed = uint64(time.Now().UnixNano())
+	}
+	if interval != "" {
+		i, err := time.ParseDuration(interval)
+		if err != nil || i <= 0 {
+			return nil, fmt.Errorf("invalid demo interval %q", interval)
+		}
+		d.interval = i
+	}
+	if volatility != "" {
+		v, err := strconv.ParseFloat(volatility, 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid demo volatility %q", volatility)
+		}
+		d.volatility = v
+	}
+	return d, nil
+}
+
+func (d *demo) Name() string { return "demo" }
+
+func (d *demo) NativeSymbol(symbol string) string { return symbol }
+
+// walk returns symbol's walk, starting it on first use
+func (d *demo) walk(symbol string) *demoWalk {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w, ok := d.walks[symbol]; ok {
+		return w
+	}
+	h := fnv.New64a()
+	h.Write([]byte(symbol))
+	base, _ := splitSymbol(symbol)
+	dollars, ok := demoStartPrices[base]
+	if !ok {
+		dollars = 100
+	}
+	w := &demoWalk{rng: rand.New(rand.NewPCG(d.seed, h.Sum64())), price: demoStartPrice(symbol), size: 1e4 / dollars}
+	d.walks[symbol] = w
+	return w
+}
+
+// step advances symbol's walk one tick, returning the traded price rounded
+// to six significant digits like an exchange tick, its quantity and the
+// aggressor side
+func (d *demo) step(w *demoWalk, drift, sigma float64) (price, quantity float64, side string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w.price *= math.Exp(drift + sigma*w.rng.NormFloat64())
+	scale := math.Pow(10, 5-math.Floor(math.Log10(w.price)))
+	price = math.Round(w.price*scale) / scale
+	quantity = math.Round(w.rng.ExpFloat64()*w.size*1e6) / 1e6
+	side = "buy"
+	if w.rng.IntN(2) == 0 {
+		side = "sell"
+	}
+	return price, quantity, side
+}
+
+// demoStartPrice is where symbol's walk begins: its base's dollar price,
+// converted for quotes with a known dollar price, or 100
+func demoStartPrice(symbol string) float64 {
+	base, quote := splitSymbol(symbol)
+	price, ok := demoStartPrices[base]
+	if !ok {
+		price = 100
+	}
+	switch quote {
+	case "usdt", "usdc", "fdusd", "busd", "usd", "":
+		return price
+	case "eur":
+		return price * 0.92
+	case "try":
+		return price * 34
+	}
+	if q, ok := demoStartPrices[quote]; ok {
+		return price / q
+	}
+	return price
+}
+
+func (d *demo) Connect(ctx context.Context, symbol string, trades chan<- TradeMessage, feed *feedMonitor) bool {
+	w := d.walk(symbol)
+	feed.connected()
+	slog.Info("Demo feed started", "symbol", symbol, "seed", d.seed, "interval", d.interval)
+
+	// Per-tick log-return: sigma sqrt(dt), less the Ito term so the
+	// price has no drift on average
+	dt := d.interval.Seconds() / (365 * 24 * 3600)
+	sigma := d.volatility * math.Sqrt(dt)
+	drift := -d.volatility * d.volatility * dt / 2
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-ticker.C:
+		}
+
+		price, quantity, side := d.step(w, drift, sigma)
+		feed.message(time.Now())
+		trades <- TradeMessage{Symbol: symbol, Price: price, Quantity: quantity, Time: time.Now().UnixMilli(), Side: side}
+	}
+}
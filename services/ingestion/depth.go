@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Order book levels kept per side
+const depthLevels = 10
+
+// Level is one price level of an order book
+type Level struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// DepthUpdate is a top-of-book snapshot published to NATS on depth.raw
+type DepthUpdate struct {
+	Symbol   string  `json:"symbol"`
+	Exchange string  `json:"exchange"`
+	Bids     []Level `json:"bids"` // best (highest) first
+	Asks     []Level `json:"asks"` // best (lowest) first
+	UpdateID int64   `json:"update_id"`
+	Time     int64   `json:"time"`
+}
+
+// DepthStreamer is implemented by exchanges that can stream order book depth
+type DepthStreamer interface {
+	// StreamDepth sends top-of-book snapshots for symbol into updates until
+	// the connection drops or ctx is cancelled. It reports whether any
+	// update was sent.
+	StreamDepth(ctx context.Context, symbol string, updates chan<- DepthUpdate) bool
+}
+
+// streamDepth keeps a depth stream alive for one symbol, backing off
+// exponentially between failures, until ctx is cancelled
+func streamDepth(ctx context.Context, nc *nats.Conn, exchange Exchange, ds DepthStreamer, symbol string) {
+	updates := make(chan DepthUpdate, 16)
+	published := make(chan struct{})
+	defer func() {
+		close(updates)
+		<-published
+	}()
+	go func() {
+		defer close(published)
+		for update := range updates {
+			update.Exchange = exchange.Name()
+			data, _ := json.Marshal(update)
+			nc.Publish("depth.raw", data)
+		}
+	}()
+
+	backoff := minBackoff
+	for {
+		if ds.StreamDepth(ctx, symbol, updates) {
+			backoff = minBackoff
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Info("Reconnecting depth", "symbol", symbol, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// parseLevels converts [["price","qty"], ...] pairs, skipping bad entries
+func parseLevels(pairs [][2]string) ([]Level, error) {
+	levels := make([]Level, 0, len(pairs))
+	for _, pair := range pairs {
+		price, err := strconv.ParseFloat(pair[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad price %q", pair[0])
+		}
+		qty, err := strconv.ParseFloat(pair[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad quantity %q", pair[1])
+		}
+		if price > 0 && qty > 0 {
+			levels = append(levels, Level{Price: price, Quantity: qty})
+		}
+	}
+	return levels, nil
+}
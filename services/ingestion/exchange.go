@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Exchange is a venue that streams trades over a WebSocket
+type Exchange interface {
+	// Name identifies the exchange in logs and status messages
+	Name() string
+
+	// NativeSymbol translates a pipeline symbol (btcusdt) to the
+	// exchange's own format
+	NativeSymbol(symbol string) string
+
+	// Connect streams trades for symbol into trades until the connection
+	// drops or ctx is cancelled, reporting to feed once the stream is up
+	// and on every message. Live trades go through offer so a slow
+	// publisher never stalls the reader. It reports whether any message was
+	// received.
+	Connect(ctx context.Context, symbol string, trades chan<- TradeMessage, feed *feedMonitor) bool
+}
+
+// Quote assets recognized when splitting a pipeline symbol
+var quoteAssets = []string{"usdt", "usdc", "fdusd", "busd", "usd", "btc", "eth", "bnb", "eur", "try"}
+
+// newExchange returns the exchange with the given name
+func newExchange(name string) (Exchange, error) {
+	switch strings.ToLower(name) {
+	case "", "binance":
+		return binance{}, nil
+	case "coinbase":
+		return coinbase{}, nil
+	case "kraken":
+		return kraken{}, nil
+	default:
+		return nil, fmt.Errorf("unknown exchange %q (binance, coinbase, kraken)", name)
+	}
+}
+
+// splitSymbol splits a pipeline symbol like btcusdt into base and quote
+func splitSymbol(symbol string) (base, quote string) {
+	for _, q := range quoteAssets {
+		if b := strings.TrimSuffix(symbol, q); b != symbol && b != "" {
+			return b, q
+		}
+	}
+	return symbol, ""
+}
+
+// streamWebSocket dials url, sends subscribe (if any) and hands every
+// message to handle until the connection drops or ctx is cancelled, pinging
+// the server every pingInterval for feed's round trip. It reports whether
+// any message was received.
+func streamWebSocket(ctx context.Context, name, url string, subscribe []byte, feed *feedMonitor, handle func([]byte)) bool {
+	slog.Debug("Dialing", "exchange", name, "url", url)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		// 429 means rate limited and 418 an IP ban; both say when to retry
+		if resp != nil {
+			slog.Warn("Connection failed", "exchange", name, "err", err, "status", resp.Status, "retry_after", resp.Header.Get("Retry-After"))
+		} else {
+			slog.Warn("Connection failed", "exchange", name, "err", err)
+		}
+		return false
+	}
+	defer conn.Close()
+
+	if subscribe != nil {
+		if err := conn.WriteMessage(websocket.TextMessage, subscribe); err != nil {
+			slog.Warn("Subscribe failed", "exchange", name, "err", err)
+			return false
+		}
+	}
+	feed.connected()
+
+	// Answer pings so the server doesn't drop us as idle
+	conn.SetPingHandler(func(data string) error {
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(5*time.Second))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+
+	// Our pings carry their send time, so each pong gives a round trip
+	conn.SetPongHandler(func(data string) error {
+		if sent, err := time.Parse(time.RFC3339Nano, data); err == nil {
+			feed.pong(time.Since(sent))
+		}
+		return nil
+	})
+
+	// Unblock ReadMessage on shutdown, pinging meanwhile
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		ping := func() {
+			payload := []byte(time.Now().Format(time.RFC3339Nano))
+			conn.WriteControl(websocket.PingMessage, payload, time.Now().Add(5*time.Second))
+		}
+		if feed != nil {
+			ping()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if feed != nil {
+					ping()
+				}
+			}
+		}
+	}()
+
+	received := false
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("Connection lost", "exchange", name, "err", err)
+			} else {
+				slog.Debug("Connection closed", "exchange", name)
+			}
+			return received
+		}
+		received = true
+		feed.message(time.Now())
+		handle(message)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Feed health sampling
+const (
+	rateWindow     = 10               // seconds of messages averaged into the rate
+	pingInterval   = 15 * time.Second // between round-trip pings to the exchange
+	statusInterval = 2 * time.Second  // between periodic status publishes
+)
+
+// A symbol is reported unavailable, likely delisted or halted, once it has
+// been silent for unavailableAfter (UNAVAILABLE_AFTER, 0 never) across at
+// least unavailableReconnects reconnects: a network outage fails every
+// symbol at once, a dead pair stays quiet while its connection comes back
+const unavailableReconnects = 3
+
+var unavailableAfter = 10 * time.Minute
+
+// feedMonitor tracks one symbol's exchange connection: the latest message,
+// the message rate over the last rateWindow seconds, the WebSocket ping
+// round trip and the trades dropped on a full publish queue. A nil monitor
+// ignores every call.
+type feedMonitor struct {
+	mu        sync.Mutex
+	onConnect func()
+	shared    []*feedMonitor // symbol feeds of a combined connection
+	firstMsg  time.Time
+	lastMsg   time.Time
+	buckets   [rateWindow]struct {
+		second int64
+		count  int
+	}
+	rtt     time.Duration // 0 until the first pong
+	dropped uint64
+}
+
+func newFeedMonitor(onConnect func()) *feedMonitor {
+	return &feedMonitor{onConnect: onConnect}
+}
+
+// newSharedFeed returns a monitor for a connection carrying several
+// symbols, which passes the connect and every round trip on to each
+// symbol's feed. Messages are counted per symbol by the caller.
+func newSharedFeed(feeds map[string]*feedMonitor) *feedMonitor {
+	f := &feedMonitor{}
+	for _, feed := range feeds {
+		f.shared = append(f.shared, feed)
+	}
+	return f
+}
+
+// connected reports the stream is up
+func (f *feedMonitor) connected() {
+	if f == nil {
+		return
+	}
+	if f.onConnect != nil {
+		f.onConnect()
+	}
+	for _, feed := range f.shared {
+		feed.connected()
+	}
+}
+
+// message counts one message received at t
+func (f *feedMonitor) message(t time.Time) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.firstMsg.IsZero() {
+		f.firstMsg = t
+	}
+	f.lastMsg = t
+	sec := t.Unix()
+	b := &f.buckets[sec%rateWindow]
+	if b.second != sec {
+		b.second, b.count = sec, 0
+	}
+	b.count++
+}
+
+// pong records a ping round trip
+func (f *feedMonitor) pong(rtt time.Duration) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.rtt = rtt
+	f.mu.Unlock()
+	for _, feed := range f.shared {
+		feed.pong(rtt)
+	}
+}
+
+// drop counts one trade dropped because the publish queue was full,
+// returning the total so far
+func (f *feedMonitor) drop() uint64 {
+	if f == nil {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dropped++
+	return f.dropped
+}
+
+// droppedTrades returns the trades dropped so far
+func (f *feedMonitor) droppedTrades() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
+
+// sample returns the latest message time, messages per second over the
+// last rateWindow seconds (or since the first message, when sooner) and
+// the latest ping round trip
+func (f *feedMonitor) sample(now time.Time) (time.Time, float64, time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, b := range f.buckets {
+		if age := now.Unix() - b.second; age >= 0 && age < rateWindow {
+			count += b.count
+		}
+	}
+	span := min(rateWindow, max(1, now.Sub(f.firstMsg).Seconds()))
+	return f.lastMsg, float64(count) / span, f.rtt
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOfferDropsForSlowConsumer(t *testing.T) {
+	queue := make(chan TradeMessage, 4)
+	feed := newFeedMonitor(nil)
+
+	// A consumer that takes a trade every few milliseconds, far slower than
+	// the exchange reader offers them
+	var wg sync.WaitGroup
+	received := 0
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range queue {
+			received++
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	const offered = 200
+	start := time.Now()
+	for i := range offered {
+		offer(queue, TradeMessage{Symbol: "BTCUSDT", Price: 100 + float64(i)}, feed)
+	}
+	// The reader never waits on the consumer
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("offering %d trades took %v", offered, elapsed)
+	}
+	close(queue)
+	wg.Wait()
+
+	dropped := feed.droppedTrades()
+	if dropped == 0 || received+int(dropped) != offered {
+		t.Fatalf("%d received and %d dropped of %d offered", received, dropped, offered)
+	}
+}
+
+func TestOfferStampsMissingTime(t *testing.T) {
+	queue := make(chan TradeMessage, 2)
+	before := time.Now().UnixMilli()
+	offer(queue, TradeMessage{Symbol: "BTCUSDT", Price: 1}, nil)
+	offer(queue, TradeMessage{Symbol: "BTCUSDT", Price: 1, Time: 42}, nil)
+
+	if got := (<-queue).Time; got < before || got > time.Now().UnixMilli() {
+		t.Errorf("trade without a time stamped %d, want about now", got)
+	}
+	if got := (<-queue).Time; got != 42 {
+		t.Errorf("exchange time %d overwritten", got)
+	}
+
+	// A full queue with no monitor still drops rather than blocking
+	offer(queue, TradeMessage{}, nil)
+	offer(queue, TradeMessage{}, nil)
+	offer(queue, TradeMessage{}, nil)
+	if len(queue) != 2 {
+		t.Errorf("%d queued, want 2", len(queue))
+	}
+}
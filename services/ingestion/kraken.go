@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// kraken streams from Kraken's v1 public trade channel
+type kraken struct{}
+
+func (kraken) Name() string { return "kraken" }
+
+// NativeSymbol maps btcusdt to XBT/USD; Kraken calls bitcoin XBT
+func (kraken) NativeSymbol(symbol string) string {
+	base, quote := splitSymbol(symbol)
+	if quote == "usdt" || quote == "" {
+		quote = "usd"
+	}
+	if base == "btc" {
+		base = "xbt"
+	}
+	if quote == "btc" {
+		quote = "xbt"
+	}
+	return strings.ToUpper(base + "/" + quote)
+}
+
+func (k kraken) Connect(ctx context.Context, symbol string, trades chan<- TradeMessage, feed *feedMonitor) bool {
+	subscribe, _ := json.Marshal(map[string]interface{}{
+		"event":        "subscribe",
+		"pair":         []string{k.NativeSymbol(symbol)},
+		"subscription": map[string]string{"name": "trade"},
+	})
+
+	return streamWebSocket(ctx, "Kraken", "wss://ws.kraken.com", subscribe, feed, func(message []byte) {
+		// Trade messages are [channelID, [[price, volume, time, side, type, misc], ...], "trade", pair];
+		// events like heartbeats are JSON objects and fail to decode here
+		var frame []json.RawMessage
+		if err := json.Unmarshal(message, &frame); err != nil || len(frame) < 4 {
+			return
+		}
+		var entries [][]interface{}
+		if err := json.Unmarshal(frame[1], &entries); err != nil {
+			return
+		}
+
+		for _, entry := range entries {
+			if len(entry) < 3 {
+				continue
+			}
+			priceStr, _ := entry[0].(string)
+			volumeStr, _ := entry[1].(string)
+			timeStr, _ := entry[2].(string)
+			price, err := strconv.ParseFloat(priceStr, 64)
+			if err != nil || price <= 0 {
+				continue
+			}
+			quantity, _ := strconv.ParseFloat(volumeStr, 64)
+			seconds, _ := strconv.ParseFloat(timeStr, 64)
+			// Kraken's side is the taker's: "b" or "s"
+			side := ""
+			if len(entry) > 3 {
+				switch entry[3] {
+				case "b":
+					side = "buy"
+				case "s":
+					side = "sell"
+				}
+			}
+			offer(trades, TradeMessage{Symbol: symbol, Price: price, Quantity: quantity, Time: int64(seconds * 1000), Side: side}, feed)
+		}
+	})
+}
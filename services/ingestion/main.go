@@ -1,33 +1,92 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/nats-io/nats.go"
 )
 
+// Reconnect backoff bounds
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// Connection states reported on status.connection
+const (
+	stateConnected    = "connected"
+	stateReconnecting = "reconnecting"
+	stateDown         = "down"
+	stateUnavailable  = "unavailable" // silent across reconnects, see unavailableAfter
+	stateFailed       = "failed"      // gave up after maxReconnects failed reconnects
+)
+
+// A feed gives up once maxReconnects (MAX_RECONNECTS, 0 never) reconnects
+// in a row have failed to receive anything. It is then reported failed
+// and, unless ON_RECONNECT_EXHAUSTED is alert, the service exits with
+// exitReconnectsExhausted so a supervisor sees the outage instead of
+// endless retries.
+var (
+	maxReconnects   = 0
+	exitOnExhausted = true
+)
+
+const exitReconnectsExhausted = 3
+
 // TradeMessage is published to NATS
 type TradeMessage struct {
-	Symbol string  `json:"symbol"`
-	Price  float64 `json:"price"`
-	Time   int64   `json:"time"`
+	Symbol   string  `json:"symbol"`
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Time     int64   `json:"time"`
+	Exchange string  `json:"exchange"`
+	Side     string  `json:"side,omitempty"`     // aggressor, "buy" or "sell", when the exchange reports it
+	Backfill bool    `json:"backfill,omitempty"` // historical close, not a live tick
+	Ticker   bool    `json:"ticker,omitempty"`   // last price from a ticker stream, not a trade
 }
 
-// BinanceTrade represents a trade event from Binance
-type BinanceTrade struct {
-	Price string `json:"p"`
-	Time  int64  `json:"T"`
+// FeedAlert is published on alerts.fired, beside the API's price alerts,
+// when a feed gives up reconnecting
+type FeedAlert struct {
+	Kind       string `json:"kind"` // always "feed"
+	Symbol     string `json:"symbol"`
+	Exchange   string `json:"exchange"`
+	Reconnects int    `json:"reconnects"` // in a row that received nothing
+	Message    string `json:"message"`
+	Time       int64  `json:"time"`
+}
+
+// ConnectionStatus is published to NATS whenever the exchange connection
+// state changes and every statusInterval while streaming
+type ConnectionStatus struct {
+	Symbol         string  `json:"symbol"`
+	Exchange       string  `json:"exchange"`
+	State          string  `json:"state"`
+	Time           int64   `json:"time"`
+	LastMessage    int64   `json:"last_message,omitempty"` // unix ms of the latest exchange message
+	MessagesPerSec float64 `json:"messages_per_sec"`       // over the last rateWindow seconds
+	RTTMs          float64 `json:"rtt_ms,omitempty"`       // latest WebSocket ping round trip
+	DroppedTrades  uint64  `json:"dropped_trades"`         // since streaming began, on a full publish queue
+	Stream         string  `json:"stream"`                 // "trade", "ticker" or "both": what the feed subscribes to
 }
 
 func main() {
-	symbol := os.Getenv("SYMBOL")
-	if symbol == "" {
-		symbol = "btcusdt"
+	setupLogging()
+
+	symbols := parseSymbols(os.Getenv("SYMBOL"))
+	if len(symbols) == 0 {
+		symbols = []string{"btcusdt"}
 	}
 
 	natsURL := os.Getenv("NATS_URL")
@@ -35,99 +94,604 @@ func main() {
 		natsURL = "nats://localhost:4222"
 	}
 
-	log.Printf("Ingestion service starting for %s", symbol)
+	var exchange Exchange
+	var err error
+	useDemo := false
+	if v := os.Getenv("DEMO"); v != "" {
+		if useDemo, err = strconv.ParseBool(v); err != nil {
+			fatal("Invalid DEMO", "err", err)
+		}
+	}
+	if path := os.Getenv("REPLAY_FILE"); path != "" {
+		// Replay a recorded CSV instead of connecting to an exchange
+		exchange, err = newReplay(path, os.Getenv("REPLAY_SPEED"), os.Getenv("REPLAY_INTERVAL"))
+		if err != nil {
+			fatal("Invalid replay settings", "err", err)
+		}
+	} else if useDemo {
+		// Synthetic prices, no network needed
+		exchange, err = newDemo(os.Getenv("DEMO_SEED"), os.Getenv("DEMO_INTERVAL"), os.Getenv("DEMO_VOLATILITY"))
+		if err != nil {
+			fatal("Invalid demo settings", "err", err)
+		}
+	} else {
+		exchange, err = newExchange(os.Getenv("EXCHANGE"))
+		if err != nil {
+			fatal("Invalid EXCHANGE", "err", err)
+		}
+
+		// Testnet or custom Binance endpoints, e.g. a proxy or a mock in CI
+		if _, ok := exchange.(binance); ok {
+			testnet := false
+			if v := os.Getenv("BINANCE_TESTNET"); v != "" {
+				if testnet, err = strconv.ParseBool(v); err != nil {
+					fatal("Invalid BINANCE_TESTNET", "err", err)
+				}
+			}
+			b, err := newBinance(testnet, os.Getenv("BINANCE_WS_URL"), os.Getenv("BINANCE_REST_URL"))
+			if err != nil {
+				fatal("Invalid Binance endpoint", "err", err)
+			}
+			if v := os.Getenv("BINANCE_STREAM"); v != "" {
+				switch v = strings.ToLower(v); v {
+				case streamTrade, streamTicker, streamBoth:
+					b.stream = v
+				default:
+					fatal("Invalid BINANCE_STREAM", "value", v, "want", "trade, ticker or both")
+				}
+			}
+			exchange = b
+			slog.Info("Binance endpoints", "ws", b.wsURL, "rest", b.restURL, "testnet", testnet, "stream", streamMode(b))
+		}
+	}
+
+	if v := os.Getenv("BACKFILL"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 1000 {
+			fatal("Invalid BACKFILL: must be 0 to 1000", "value", v)
+		}
+		backfillLimit = n
+	}
+
+	if v := os.Getenv("REST_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			fatal("Invalid REST_TIMEOUT", "value", v)
+		}
+		restClient.Timeout = d
+	}
+
+	if v := os.Getenv("MAX_RECONNECTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			fatal("Invalid MAX_RECONNECTS", "value", v)
+		}
+		maxReconnects = n
+	}
+	if v := os.Getenv("ON_RECONNECT_EXHAUSTED"); v != "" {
+		switch strings.ToLower(v) {
+		case "exit":
+			exitOnExhausted = true
+		case "alert":
+			exitOnExhausted = false
+		default:
+			fatal("Invalid ON_RECONNECT_EXHAUSTED", "value", v, "want", "exit or alert")
+		}
+	}
+	if v := os.Getenv("UNAVAILABLE_AFTER"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			fatal("Invalid UNAVAILABLE_AFTER", "value", v)
+		}
+		unavailableAfter = d
+	}
+
+	// A worker streams whatever the coordinator assigns it; standalone
+	// follows control.symbol
+	role := os.Getenv("ROLE")
+	if role == "" {
+		role = "standalone"
+	}
+	if role != "standalone" && role != "worker" {
+		fatal("Invalid ROLE: must be standalone or worker", "value", role)
+	}
+	workerID := os.Getenv("WORKER_ID")
+	if workerID == "" {
+		workerID = defaultWorkerID()
+	}
+
+	if role == "worker" {
+		slog.Info("Ingestion worker starting", "worker", workerID, "exchange", exchange.Name())
+	} else {
+		slog.Info("Ingestion service starting", "symbols", symbols, "exchange", exchange.Name())
+	}
+
+	// Cancel everything on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Binance accepts a stream for any name, so a typo would sit silent
+	// forever; workers get their symbols from the coordinator
+	if b, ok := exchange.(binance); ok && role == "standalone" {
+		cachePath := os.Getenv("SYMBOL_CACHE")
+		if cachePath == "" {
+			cachePath = defaultSymbolCachePath()
+		}
+		checkSymbols(ctx, b, symbols, cachePath)
+	}
 
 	// Connect to NATS with retry
 	var nc *nats.Conn
-	var err error
 	for i := 0; i < 10; i++ {
 		nc, err = nats.Connect(natsURL)
 		if err == nil {
 			break
 		}
-		log.Printf("NATS connection failed, retrying in 2s... (%v)", err)
+		slog.Warn("NATS connection failed, retrying in 2s", "err", err)
 		time.Sleep(2 * time.Second)
 	}
 	if err != nil {
-		log.Fatalf("Failed to connect to NATS: %v", err)
+		fatal("Failed to connect to NATS", "err", err)
 	}
 	defer nc.Close()
-	log.Println("Connected to NATS")
+	slog.Info("Connected to NATS", "url", natsURL)
+
+	streams := newStreamSet(ctx, nc, exchange)
+	if role == "worker" {
+		// Blocks until shutdown
+		runWorker(ctx, nc, streams, workerID)
+	} else {
+		streams.set(symbols)
+
+		// Subscribe to symbol change requests
+		nc.Subscribe("control.symbol", func(msg *nats.Msg) {
+			var req struct {
+				Symbol  string   `json:"symbol"`
+				Symbols []string `json:"symbols"`
+			}
+			if err := json.Unmarshal(msg.Data, &req); err != nil {
+				return
+			}
+			if len(req.Symbols) == 0 && req.Symbol != "" {
+				req.Symbols = []string{req.Symbol}
+			}
+			if len(req.Symbols) == 0 {
+				return
+			}
+			streams.set(req.Symbols)
+			slog.Info("Symbols changed", "symbols", req.Symbols)
+		})
+
+		<-ctx.Done()
+	}
+
+	slog.Info("Shutting down")
+	streams.wait()
 
-	// Track current symbol for dynamic switching
-	var mu sync.RWMutex
-	currentSymbol := symbol
+	// Flush trades still queued for NATS
+	if err := nc.Drain(); err != nil {
+		slog.Error("NATS drain failed", "err", err)
+	}
+}
 
-	// Subscribe to symbol change requests
-	nc.Subscribe("control.symbol", func(msg *nats.Msg) {
-		var req struct {
-			Symbol string `json:"symbol"`
+// parseSymbols splits a comma-separated symbol list
+func parseSymbols(value string) []string {
+	var symbols []string
+	for _, s := range strings.Split(value, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s != "" {
+			symbols = append(symbols, s)
 		}
-		if err := json.Unmarshal(msg.Data, &req); err != nil {
-			return
+	}
+	return symbols
+}
+
+// streamSet runs one exchange stream per tracked symbol, or a single
+// combined stream for all of them where the exchange offers one
+type streamSet struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	nc       *nats.Conn
+	exchange Exchange
+	cancels  map[string]context.CancelFunc
+	wg       sync.WaitGroup
+
+	// Symbols whose feed gave up reconnecting, restarted by the next set
+	// that still wants them, and each per-symbol stream's exit
+	failed map[string]bool
+	dones  map[string]chan struct{}
+
+	// Combined streaming state, see combined.go
+	statuses        map[string]*symbolStatus
+	pendingBackfill map[string]bool
+	combinedCancel  context.CancelFunc
+	combinedDone    chan struct{}
+}
+
+func newStreamSet(ctx context.Context, nc *nats.Conn, exchange Exchange) *streamSet {
+	return &streamSet{
+		ctx:             ctx,
+		nc:              nc,
+		exchange:        exchange,
+		cancels:         make(map[string]context.CancelFunc),
+		failed:          make(map[string]bool),
+		dones:           make(map[string]chan struct{}),
+		statuses:        make(map[string]*symbolStatus),
+		pendingBackfill: make(map[string]bool),
+	}
+}
+
+// set starts streams for new symbols and stops streams for dropped ones
+func (ss *streamSet) set(symbols []string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	wanted := make(map[string]bool)
+	for _, sym := range symbols {
+		wanted[sym] = true
+	}
+	cs, combined := ss.exchange.(CombinedStreamer)
+	changed := false
+
+	for sym, cancel := range ss.cancels {
+		if !wanted[sym] {
+			cancel()
+			delete(ss.cancels, sym)
+			delete(ss.statuses, sym)
+			delete(ss.pendingBackfill, sym)
+			delete(ss.failed, sym)
+			changed = true
 		}
-		mu.Lock()
-		currentSymbol = req.Symbol
-		mu.Unlock()
-		log.Printf("Symbol changed to %s", req.Symbol)
+	}
+
+	for sym := range wanted {
+		if _, ok := ss.cancels[sym]; ok && !ss.failed[sym] {
+			continue
+		}
+		if ss.failed[sym] {
+			slog.Info("Retrying failed feed", "exchange", ss.exchange.Name(), "symbol", sym)
+			delete(ss.failed, sym)
+			changed = true
+			if st := ss.statuses[sym]; combined && st != nil {
+				// Redialled with the rest by restartCombined below
+				st.retry()
+				continue
+			}
+			// Stop what is left of the old stream; the new one waits for it
+			ss.cancels[sym]()
+		}
+		ctx, cancel := context.WithCancel(ss.ctx)
+		ss.cancels[sym] = cancel
+		changed = true
+		if combined {
+			st := newSymbolStatus(ss.nc, ss.exchange, sym)
+			ss.statuses[sym] = st
+			ss.pendingBackfill[sym] = true
+			ss.wg.Add(1)
+			go func() {
+				defer ss.wg.Done()
+				st.run(ctx)
+			}()
+		} else {
+			prev := ss.dones[sym]
+			done := make(chan struct{})
+			ss.dones[sym] = done
+			ss.wg.Add(1)
+			go func(sym string) {
+				defer ss.wg.Done()
+				defer close(done)
+				if prev != nil {
+					<-prev
+				}
+				streamSymbol(ctx, ss.nc, ss.exchange, sym, func() { ss.gaveUp(sym) })
+			}(sym)
+		}
+
+		// Order book depth, where the exchange offers it
+		if ds, ok := ss.exchange.(DepthStreamer); ok {
+			ss.wg.Add(1)
+			go func(sym string) {
+				defer ss.wg.Done()
+				streamDepth(ctx, ss.nc, ss.exchange, ds, sym)
+			}(sym)
+		}
+	}
+
+	if combined && changed {
+		ss.restartCombined(cs)
+	}
+}
+
+// gaveUp marks symbols failed, so a later set retries them
+func (ss *streamSet) gaveUp(symbols ...string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, sym := range symbols {
+		if _, ok := ss.cancels[sym]; ok {
+			ss.failed[sym] = true
+		}
+	}
+}
+
+// symbols returns the streamed symbols in order
+func (ss *streamSet) symbols() []string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	symbols := make([]string, 0, len(ss.cancels))
+	for sym := range ss.cancels {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// wait blocks until all streams have exited
+func (ss *streamSet) wait() {
+	ss.wg.Wait()
+}
+
+// symbolStatus publishes one symbol's connection state whenever it changes
+// and, while connected, the feed's health every statusInterval. Once the
+// symbol looks unavailable that state replaces connected and reconnecting
+// until a message arrives again.
+type symbolStatus struct {
+	nc       *nats.Conn
+	exchange Exchange
+	symbol   string
+	feed     *feedMonitor
+	started  time.Time
+
+	mu          sync.Mutex
+	state       string
+	closed      bool      // down for good, later changes are dropped
+	failed      bool      // gave up reconnecting, see giveUp; only connected or down replace it
+	reconnects  int       // since the feed's last message
+	lastSeen    time.Time // the feed's last message as of the latest reconnect
+	unavailable bool
+}
+
+func newSymbolStatus(nc *nats.Conn, exchange Exchange, symbol string) *symbolStatus {
+	st := &symbolStatus{nc: nc, exchange: exchange, symbol: symbol, started: time.Now()}
+	st.feed = newFeedMonitor(func() {
+		slog.Info("Connected", "exchange", exchange.Name(), "symbol", symbol, "stream", exchange.NativeSymbol(symbol))
+		st.set(stateConnected)
 	})
+	return st
+}
+
+// set changes the state and publishes it
+func (st *symbolStatus) set(state string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.closed {
+		return
+	}
+	if st.failed {
+		if state != stateConnected && state != stateDown {
+			return
+		}
+		st.failed = false
+	}
+	if state == stateFailed {
+		st.failed = true
+	}
+	if state == stateReconnecting {
+		if last, _, _ := st.feed.sample(time.Now()); !last.Equal(st.lastSeen) {
+			st.lastSeen, st.reconnects = last, 0
+		}
+		st.reconnects++
+	}
+	if st.unavailable && state != stateDown && state != stateFailed {
+		state = stateUnavailable
+	}
+	if state != st.state {
+		slog.Debug("Connection state", "exchange", st.exchange.Name(), "symbol", st.symbol, "state", state)
+	}
+	st.state = state
+	publishStatus(st.nc, st.exchange, st.symbol, state, st.feed)
+}
+
+// retry clears a failed state for another round of reconnects
+func (st *symbolStatus) retry() {
+	st.mu.Lock()
+	st.failed, st.reconnects = false, 0
+	st.mu.Unlock()
+	st.set(stateReconnecting)
+}
 
-	// Start Binance connection loop
+// run republishes the connected state every statusInterval until ctx is
+// cancelled, then reports the symbol down
+func (st *symbolStatus) run(ctx context.Context) {
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
 	for {
-		mu.RLock()
-		sym := currentSymbol
-		mu.RUnlock()
+		select {
+		case <-ctx.Done():
+			st.set(stateDown)
+			st.mu.Lock()
+			st.closed = true
+			st.mu.Unlock()
+			return
+		case <-ticker.C:
+			st.checkAvailable(time.Now())
+			st.mu.Lock()
+			if st.state == stateConnected && !st.closed {
+				publishStatus(st.nc, st.exchange, st.symbol, st.state, st.feed)
+			}
+			st.mu.Unlock()
+		}
+	}
+}
 
-		connectToBinance(nc, sym, &mu, &currentSymbol)
-		time.Sleep(2 * time.Second)
+// checkAvailable reports the symbol unavailable once it has been silent
+// for unavailableAfter across unavailableReconnects reconnects, and
+// connected again on its next message
+func (st *symbolStatus) checkAvailable(now time.Time) {
+	last, _, _ := st.feed.sample(now)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.closed || st.failed {
+		return
+	}
+	switch {
+	case st.unavailable && last.After(st.lastSeen):
+		st.unavailable, st.reconnects = false, 0
+		slog.Info("Symbol trading again", "exchange", st.exchange.Name(), "symbol", st.symbol)
+		st.state = stateConnected
+		publishStatus(st.nc, st.exchange, st.symbol, st.state, st.feed)
+	case !st.unavailable && unavailableAfter > 0 && st.reconnects >= unavailableReconnects:
+		since := last
+		if since.IsZero() {
+			since = st.started
+		}
+		if now.Sub(since) < unavailableAfter {
+			return
+		}
+		st.unavailable = true
+		slog.Warn("Symbol may be delisted or unavailable", "exchange", st.exchange.Name(), "symbol", st.symbol,
+			"silent", now.Sub(since).Round(time.Second), "reconnects", st.reconnects)
+		st.state = stateUnavailable
+		publishStatus(st.nc, st.exchange, st.symbol, st.state, st.feed)
 	}
 }
 
-func connectToBinance(nc *nats.Conn, symbol string, mu *sync.RWMutex, currentSymbol *string) {
-	url := "wss://stream.binance.com:9443/ws/" + symbol + "@trade"
+// publishTrades forwards normalized trades to NATS until stop closes the
+// queue and waits for it to drain
+func publishTrades(nc *nats.Conn, exchange Exchange) (trades chan<- TradeMessage, stop func()) {
+	queue := make(chan TradeMessage, 100)
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for trade := range queue {
+			trade.Exchange = exchange.Name()
+			data, _ := json.Marshal(trade)
+			nc.Publish("trades.raw", data)
+		}
+	}()
+	return queue, func() {
+		close(queue)
+		<-published
+	}
+}
 
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
-	if err != nil {
-		log.Printf("Binance connection error: %v", err)
-		return
+// offer queues a live trade for publishing without blocking the exchange
+// reader: a reader stalled on a full queue stops answering pings and gets
+// disconnected, so the trade is dropped and counted on feed instead. A
+// trade the exchange sent no time for is stamped with the local time.
+func offer(trades chan<- TradeMessage, trade TradeMessage, feed *feedMonitor) {
+	if trade.Time <= 0 {
+		trade.Time = time.Now().UnixMilli()
+	}
+	select {
+	case trades <- trade:
+	default:
+		// Log the first drop, then ever more rarely
+		if n := feed.drop(); n > 0 && n&(n-1) == 0 {
+			slog.Warn("Publish queue full, dropping trades", "symbol", trade.Symbol, "dropped", n)
+		}
+	}
+}
+
+// streamSymbol keeps an exchange connection alive for one symbol, backing
+// off exponentially between failures, until ctx is cancelled or the
+// reconnects are exhausted, when it calls failed and holds the failed
+// status until ctx is cancelled
+func streamSymbol(ctx context.Context, nc *nats.Conn, exchange Exchange, symbol string, failed func()) {
+	status := newSymbolStatus(nc, exchange, symbol)
+	statusDone := make(chan struct{})
+	defer func() { <-statusDone }()
+	go func() {
+		defer close(statusDone)
+		status.run(ctx)
+	}()
+
+	trades, stop := publishTrades(nc, exchange)
+	defer stop()
+
+	// Seed downstream indicators before the first live tick
+	if bf, ok := exchange.(Backfiller); ok {
+		backfill(ctx, bf, symbol, trades)
 	}
-	defer conn.Close()
-	log.Printf("Connected to Binance for %s", symbol)
 
+	backoff := minBackoff
+	reconnects := 0 // since the last connection that received anything
 	for {
-		// Check if symbol changed
-		mu.RLock()
-		newSymbol := *currentSymbol
-		mu.RUnlock()
-		if newSymbol != symbol {
-			log.Printf("Symbol changed, reconnecting...")
+		received := exchange.Connect(ctx, symbol, trades, status.feed)
+		if ctx.Err() != nil {
 			return
 		}
 
-		_, message, err := conn.ReadMessage()
-		if err != nil {
-			log.Printf("Read error: %v", err)
+		if received {
+			backoff, reconnects = minBackoff, 0
+		} else if maxReconnects > 0 && reconnects >= maxReconnects {
+			giveUp(nc, exchange, []string{symbol}, status)
+			failed()
 			return
 		}
+		reconnects++
+		status.set(stateReconnecting)
+		slog.Info("Reconnecting", "exchange", exchange.Name(), "symbol", symbol, "backoff", backoff)
 
-		var trade BinanceTrade
-		if err := json.Unmarshal(message, &trade); err != nil {
-			continue
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
 		}
 
-		var price float64
-		if _, err := json.Number(trade.Price).Float64(); err == nil {
-			json.Unmarshal([]byte(trade.Price), &price)
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
 		}
+	}
+}
 
-		if price > 0 {
-			msg := TradeMessage{
-				Symbol: symbol,
-				Price:  price,
-				Time:   trade.Time,
-			}
-			data, _ := json.Marshal(msg)
-			nc.Publish("trades.raw", data)
+// giveUp reports statuses failed once symbols' reconnects are exhausted,
+// fires a FeedAlert for each and, with exitOnExhausted, exits after
+// flushing them to NATS
+func giveUp(nc *nats.Conn, exchange Exchange, symbols []string, statuses ...*symbolStatus) {
+	for _, st := range statuses {
+		st.set(stateFailed)
+	}
+	for _, sym := range symbols {
+		data, _ := json.Marshal(FeedAlert{
+			Kind:       "feed",
+			Symbol:     sym,
+			Exchange:   exchange.Name(),
+			Reconnects: maxReconnects,
+			Message:    fmt.Sprintf("%s feed for %s gave up after %d reconnects", exchange.Name(), strings.ToUpper(sym), maxReconnects),
+			Time:       time.Now().UnixMilli(),
+		})
+		nc.Publish("alerts.fired", data)
+	}
+	if !exitOnExhausted {
+		slog.Error("Reconnects exhausted, giving up", "exchange", exchange.Name(), "symbols", symbols, "max_reconnects", maxReconnects)
+		return
+	}
+	slog.Error("Reconnects exhausted, exiting", "exchange", exchange.Name(), "symbols", symbols, "max_reconnects", maxReconnects)
+	nc.FlushTimeout(time.Second)
+	os.Exit(exitReconnectsExhausted)
+}
+
+// publishStatus announces the exchange connection state with the feed's
+// health
+func publishStatus(nc *nats.Conn, exchange Exchange, symbol, state string, feed *feedMonitor) {
+	now := time.Now()
+	status := ConnectionStatus{
+		Symbol:   symbol,
+		Exchange: exchange.Name(),
+		State:    state,
+		Time:     now.UnixMilli(),
+		Stream:   streamMode(exchange),
+	}
+	if feed != nil {
+		last, rate, rtt := feed.sample(now)
+		if !last.IsZero() {
+			status.LastMessage = last.UnixMilli()
 		}
+		status.MessagesPerSec = rate
+		status.RTTMs = float64(rtt.Microseconds()) / 1000
+		status.DroppedTrades = feed.droppedTrades()
 	}
+	data, _ := json.Marshal(status)
+	nc.Publish("status.connection", data)
 }
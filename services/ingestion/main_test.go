@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deadExchange never receives anything, counting its connection attempts
+type deadExchange struct {
+	mu       sync.Mutex
+	connects map[string]int
+}
+
+func (d *deadExchange) Name() string                      { return "dead" }
+func (d *deadExchange) NativeSymbol(symbol string) string { return symbol }
+
+func (d *deadExchange) Connect(ctx context.Context, symbol string, trades chan<- TradeMessage, feed *feedMonitor) bool {
+	d.mu.Lock()
+	d.connects[symbol]++
+	d.mu.Unlock()
+	return false
+}
+
+func (d *deadExchange) count(symbol string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.connects[symbol]
+}
+
+// waitFailed waits for symbol to be marked failed
+func waitFailed(t *testing.T, ss *streamSet, symbol string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		ss.mu.Lock()
+		failed := ss.failed[symbol]
+		ss.mu.Unlock()
+		if failed {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s never gave up", symbol)
+}
+
+func TestFailedFeedRestartsOnReAdd(t *testing.T) {
+	defer func(n int, exit bool) { maxReconnects, exitOnExhausted = n, exit }(maxReconnects, exitOnExhausted)
+	maxReconnects, exitOnExhausted = 1, false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exchange := &deadExchange{connects: make(map[string]int)}
+	ss := newStreamSet(ctx, nil, exchange)
+	defer func() { cancel(); ss.wait() }()
+
+	ss.set([]string{"btcusdt"})
+	waitFailed(t, ss, "btcusdt")
+	gaveUpAfter := exchange.count("btcusdt")
+
+	// The failed stream still holds its entry; a re-add must redial anyway
+	ss.set([]string{"btcusdt"})
+	waitFailed(t, ss, "btcusdt")
+	if got := exchange.count("btcusdt"); got <= gaveUpAfter {
+		t.Fatalf("re-adding a failed symbol didn't reconnect: %d connects, %d when it gave up", got, gaveUpAfter)
+	}
+	if got := ss.symbols(); len(got) != 1 || got[0] != "btcusdt" {
+		t.Fatalf("streamed symbols = %v", got)
+	}
+}
+
+// TestCancelStopsStreams checks that cancelling the context, as SIGINT and
+// SIGTERM do, ends every stream goroutine
+func TestCancelStopsStreams(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	ss := newStreamSet(ctx, nil, &deadExchange{connects: make(map[string]int)})
+	ss.set([]string{"btcusdt", "ethusdt", "solusdt"})
+
+	cancel()
+	waited := make(chan struct{})
+	go func() {
+		ss.wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("streams still running after cancel")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		t.Fatalf("%d goroutines after shutdown, %d before", n, baseline)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// replay feeds trades from a CSV file (timestamp, price, volume columns, as
+// written by the TUI export) instead of a live exchange. Rows are paced by
+// their recorded timestamps divided by speed, or by a fixed interval when
+// one is set. Trades are stamped with the current time so downstream
+// candles and rolling stats behave as they do live; the file restarts from
+// the top when it runs out.
+type replay struct {
+	path     string
+	speed    float64
+	interval time.Duration
+}
+
+// newReplay validates a replay file and its pacing settings
+func newReplay(path, speed, interval string) (*replay, error) {
+	r := &replay{path: path, speed: 1}
+	if speed != "" {
+		s, err := strconv.ParseFloat(speed, 64)
+		if err != nil || s <= 0 {
+			return nil, fmt.Errorf("invalid replay speed %q", speed)
+		}
+		r.speed = s
+	}
+	if interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid replay interval %q", interval)
+		}
+		r.interval = d
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return r, nil
+}
+
+func (r *replay) Name() string { return "replay" }
+
+func (r *replay) NativeSymbol(symbol string) string { return symbol }
+
+func (r *replay) Connect(ctx context.Context, symbol string, trades chan<- TradeMessage, feed *feedMonitor) bool {
+	f, err := os.Open(r.path)
+	if err != nil {
+		slog.Error("Replay open failed", "err", err)
+		return false
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	feed.connected()
+
+	received := false
+	var last time.Time
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			slog.Info("Replay finished, restarting", "file", r.path, "symbol", symbol)
+			return received
+		}
+		if err != nil {
+			slog.Error("Replay read failed", "err", err)
+			return received
+		}
+		if len(row) < 2 {
+			continue
+		}
+
+		// Skips the header row too
+		price, err := strconv.ParseFloat(row[1], 64)
+		if err != nil || price <= 0 {
+			continue
+		}
+		var quantity float64
+		if len(row) > 2 && row[2] != "" {
+			quantity, _ = strconv.ParseFloat(row[2], 64)
+		}
+
+		delay := r.interval
+		if delay == 0 {
+			if ts, err := time.Parse(time.RFC3339Nano, row[0]); err == nil {
+				if !last.IsZero() && ts.After(last) {
+					delay = time.Duration(float64(ts.Sub(last)) / r.speed)
+				}
+				last = ts
+			}
+		}
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return received
+			case <-time.After(delay):
+			}
+		} else if ctx.Err() != nil {
+			return received
+		}
+
+		received = true
+		feed.message(time.Now())
+		trades <- TradeMessage{Symbol: symbol, Price: price, Quantity: quantity, Time: time.Now().UnixMilli()}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers with statuses in turn, then 200s, counting requests.
+// Its failures say Retry-After: 0 so the tests don't sit out the backoff.
+func flakyServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+func TestRestGetRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     int
+		calls    int32
+	}{
+		{"first try", nil, http.StatusOK, 1},
+		{"5xx then ok", []int{http.StatusBadGateway}, http.StatusOK, 2},
+		{"rate limited then ok", []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}, http.StatusOK, 3},
+		{"retries run out", []int{500, 500, 500, 500}, http.StatusInternalServerError, restAttempts},
+		{"client error isn't retried", []int{http.StatusNotFound}, http.StatusNotFound, 1},
+		{"ban isn't retried", []int{http.StatusTeapot}, http.StatusTeapot, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, calls := flakyServer(t, tt.statuses...)
+			resp, err := restGet(context.Background(), ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want || calls.Load() != tt.calls {
+				t.Fatalf("%s after %d calls, want %d after %d", resp.Status, calls.Load(), tt.want, tt.calls)
+			}
+		})
+	}
+}
+
+func TestRestGetHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	start := time.Now()
+	resp, err := restGet(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < time.Second || resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s after %v, want 200 after waiting Retry-After's second rather than %v", resp.Status, elapsed, restBackoff)
+	}
+}
+
+func TestRestGetTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // hangs until the client gives up
+	}))
+	defer ts.Close()
+	defer func(d time.Duration) { restClient.Timeout = d }(restClient.Timeout)
+	restClient.Timeout = 50 * time.Millisecond
+
+	// A hung call times out rather than stalling startup, and cancelling
+	// the context stops the retries
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if resp, err := restGet(ctx, ts.URL); err == nil {
+		resp.Body.Close()
+		t.Fatal("hung call succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("gave up after %v", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"soon", 0, false},
+		{"0", 0, true},
+		{"2", 2 * time.Second, true},
+		{"-5", 0, true},
+		{"86400", maxRetryAfter, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), maxRetryAfter, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// A date a few seconds out waits about that long
+	if got, ok := retryAfter(time.Now().Add(5 * time.Second).UTC().Format(http.TimeFormat)); !ok || got < 3*time.Second || got > 5*time.Second {
+		t.Errorf("retryAfter of a date 5s out = %v, %v", got, ok)
+	}
+}
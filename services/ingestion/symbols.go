@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// How long a cached exchangeInfo symbol list is trusted before refetching
+const symbolCacheTTL = 24 * time.Hour
+
+// Closest matches suggested for an unsupported symbol
+const maxSuggestions = 3
+
+// symbolCache is the on-disk list of pairs trading on one Binance endpoint
+type symbolCache struct {
+	URL       string   `json:"url"`
+	FetchedAt int64    `json:"fetched_at"` // unix ms
+	Symbols   []string `json:"symbols"`    // lowercase
+}
+
+// defaultSymbolCachePath returns ~/.crypto-analysis/binance-symbols.json
+func defaultSymbolCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "binance-symbols.json"
+	}
+	return filepath.Join(home, ".crypto-analysis", "binance-symbols.json")
+}
+
+// tradingSymbols returns the pairs currently trading, from the cache at
+// path while it is fresh and from exchangeInfo otherwise. A stale cache is
+// still used when exchangeInfo can't be reached.
+func (b binance) tradingSymbols(ctx context.Context, path string) ([]string, error) {
+	url := b.apiURL("/api/v3/exchangeInfo")
+
+	var cached symbolCache
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cached) == nil && cached.URL == url {
+		if time.Since(time.UnixMilli(cached.FetchedAt)) < symbolCacheTTL {
+			return cached.Symbols, nil
+		}
+	} else {
+		cached = symbolCache{}
+	}
+
+	symbols, err := b.fetchTradingSymbols(ctx, url)
+	if err != nil {
+		if len(cached.Symbols) > 0 {
+			slog.Warn("Using stale symbol list", "path", path, "err", err)
+			return cached.Symbols, nil
+		}
+		return nil, err
+	}
+
+	data, _ := json.Marshal(symbolCache{URL: url, FetchedAt: time.Now().UnixMilli(), Symbols: symbols})
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		slog.Warn("Failed to cache symbol list", "path", path, "err", err)
+	} else if err := os.WriteFile(path, data, 0o644); err != nil {
+		slog.Warn("Failed to cache symbol list", "path", path, "err", err)
+	}
+	return symbols, nil
+}
+
+// fetchTradingSymbols lists the pairs exchangeInfo reports as trading
+func (b binance) fetchTradingSymbols(ctx context.Context, url string) ([]string, error) {
+	resp, err := restGet(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchangeInfo: %s", resp.Status)
+	}
+
+	var info struct {
+		Symbols []struct {
+			Symbol string `json:"symbol"`
+			Status string `json:"status"`
+		} `json:"symbols"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	symbols := make([]string, 0, len(info.Symbols))
+	for _, s := range info.Symbols {
+		if s.Status == "TRADING" {
+			symbols = append(symbols, strings.ToLower(s.Symbol))
+		}
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("exchangeInfo lists no trading symbols")
+	}
+	return symbols, nil
+}
+
+// checkSymbols exits with the closest trading pairs when any of symbols
+// isn't trading on b. Validation is skipped, with a warning, when the
+// symbol list can't be had at all so an outage doesn't block startup.
+func checkSymbols(ctx context.Context, b binance, symbols []string, cachePath string) {
+	trading, err := b.tradingSymbols(ctx, cachePath)
+	if err != nil {
+		slog.Warn("Could not validate symbols", "err", err)
+		return
+	}
+
+	known := make(map[string]bool, len(trading))
+	for _, s := range trading {
+		known[s] = true
+	}
+	for _, symbol := range symbols {
+		if known[symbol] {
+			continue
+		}
+		if matches := closestSymbols(symbol, trading); len(matches) > 0 {
+			fatal("Unsupported SYMBOL: not trading on Binance", "symbol", symbol, "did_you_mean", strings.Join(matches, ","))
+		}
+		fatal("Unsupported SYMBOL: not trading on Binance", "symbol", symbol)
+	}
+}
+
+// closestSymbols returns up to maxSuggestions of candidates nearest to
+// symbol by edit distance, nearest first, ignoring any too far off to be
+// a typo
+func closestSymbols(symbol string, candidates []string) []string {
+	limit := max(2, len(symbol)/3)
+	type match struct {
+		symbol   string
+		distance int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if d := editDistance(symbol, c); d <= limit {
+			matches = append(matches, match{c, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].symbol < matches[j].symbol
+	})
+
+	var out []string
+	for _, m := range matches[:min(len(matches), maxSuggestions)] {
+		out = append(out, m.symbol)
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
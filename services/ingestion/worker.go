@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// How often a worker tells the coordinator it is alive
+const heartbeatInterval = 2 * time.Second
+
+// WorkerHeartbeat is published on workers.heartbeat every heartbeatInterval,
+// and once on workers.leave at shutdown. The first one registers the worker
+// with the coordinator (the API service).
+type WorkerHeartbeat struct {
+	ID       string   `json:"id"`
+	Exchange string   `json:"exchange"`
+	Symbols  []string `json:"symbols"` // symbols being streamed
+	Time     int64    `json:"time"`
+}
+
+// WorkerAssignment arrives on workers.assign.<id> with the symbols this
+// worker should stream
+type WorkerAssignment struct {
+	Symbols []string `json:"symbols"`
+	Time    int64    `json:"time"`
+}
+
+// defaultWorkerID names a worker after its host, which is unique per container
+func defaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return fmt.Sprintf("worker-%d", os.Getpid())
+	}
+	return host
+}
+
+// runWorker streams the symbols the coordinator assigns and sends
+// heartbeats until ctx is cancelled, then announces that it is leaving
+func runWorker(ctx context.Context, nc *nats.Conn, streams *streamSet, id string) {
+	nc.Subscribe("workers.assign."+id, func(msg *nats.Msg) {
+		var a WorkerAssignment
+		if err := json.Unmarshal(msg.Data, &a); err != nil {
+			return
+		}
+		if sameSymbols(a.Symbols, streams.symbols()) {
+			return
+		}
+		streams.set(a.Symbols)
+		slog.Info("Assigned symbols", "symbols", a.Symbols)
+	})
+
+	beat := func(subject string) {
+		data, _ := json.Marshal(WorkerHeartbeat{
+			ID:       id,
+			Exchange: streams.exchange.Name(),
+			Symbols:  streams.symbols(),
+			Time:     time.Now().UnixMilli(),
+		})
+		nc.Publish(subject, data)
+	}
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		beat("workers.heartbeat")
+		select {
+		case <-ctx.Done():
+			beat("workers.leave")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sameSymbols reports whether a and b hold the same symbols in any order
+func sameSymbols(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, sym := range a {
+		if !slices.Contains(b, sym) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// bruteBands recomputes the bands over the last window prices from scratch
+func bruteBands(prices []float64, window int, k float64) Bollinger {
+	last := prices[len(prices)-window:]
+	mean := 0.0
+	for _, p := range last {
+		mean += p
+	}
+	mean /= float64(window)
+	variance := 0.0
+	for _, p := range last {
+		variance += (p - mean) * (p - mean)
+	}
+	band := k * math.Sqrt(variance/float64(window))
+	return Bollinger{Upper: mean + band, Middle: mean, Lower: mean - band, Period: window, K: k}
+}
+
+func TestBollingerMatchesBruteForce(t *testing.T) {
+	configure(t, "BBUSDT")
+	window := maWindows[0]
+
+	// BTC-sized prices with cent-sized moves are where a running sum of
+	// squares loses the most to cancellation; run past the periodic rebuild
+	prices := make([]float64, 12000)
+	for i := range prices {
+		prices[i] = 64000 + 25*math.Sin(float64(i)/7) + float64(i%9)*0.01
+	}
+	got := feedPrices("BBUSDT", prices...)
+
+	for i, p := range got {
+		if i+1 < window {
+			if p.Bollinger != nil {
+				t.Fatalf("bands %+v after %d prices, want none before %d", *p.Bollinger, i+1, window)
+			}
+			continue
+		}
+		if p.Bollinger == nil {
+			t.Fatalf("no bands after %d prices", i+1)
+		}
+		want := bruteBands(prices[:i+1], window, bollingerK)
+		const tol = 1e-3 // a tenth of a cent on bands a few dollars wide
+		if math.Abs(p.Bollinger.Upper-want.Upper) > tol || math.Abs(p.Bollinger.Middle-want.Middle) > tol ||
+			math.Abs(p.Bollinger.Lower-want.Lower) > tol || p.Bollinger.Period != window || p.Bollinger.K != bollingerK {
+			t.Fatalf("bands after %d prices = %+v, want %+v", i+1, *p.Bollinger, want)
+		}
+	}
+}
+
+func TestBollingerFlatWindow(t *testing.T) {
+	configure(t, "FLATBBUSDT")
+	prices := make([]float64, 40)
+	for i := range prices {
+		prices[i] = 0.1 + 0.2 // not exactly representable, so squares round
+	}
+	last := feedPrices("FLATBBUSDT", prices...)[len(prices)-1].Bollinger
+	if last == nil {
+		t.Fatal("no bands on a full window")
+	}
+	// The running sums leave rounding in the variance, which mustn't go
+	// negative into a NaN square root
+	if math.IsNaN(last.Upper) || math.IsNaN(last.Lower) || last.Upper-last.Lower > 1e-6*last.Middle {
+		t.Fatalf("bands on a flat window = %+v, want about zero width", *last)
+	}
+}
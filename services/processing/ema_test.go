@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// referenceEMA is the textbook EMA of prices: -1 until period prices have
+// been seen, then seeded with their simple average and smoothed by
+// 2/(period+1)
+func referenceEMA(prices []float64, period int) []float64 {
+	out := make([]float64, len(prices))
+	value := 0.0
+	for i, p := range prices {
+		switch {
+		case i < period-1:
+			out[i] = -1
+			continue
+		case i == period-1:
+			sum := 0.0
+			for _, q := range prices[:period] {
+				sum += q
+			}
+			value = sum / float64(period)
+		default:
+			value += 2 / float64(period+1) * (p - value)
+		}
+		out[i] = value
+	}
+	return out
+}
+
+// zigzag is n prices wandering up and down
+func zigzag(n int) []float64 {
+	prices := make([]float64, n)
+	for i := range prices {
+		prices[i] = 250 + 10*math.Sin(float64(i)/5) + float64(i%4)
+	}
+	return prices
+}
+
+func TestEMAMatchesReference(t *testing.T) {
+	configure(t, "EMAUSDT")
+	prices := zigzag(300)
+	got := feedPrices("EMAUSDT", prices...)
+
+	for _, period := range emaPeriods {
+		want := referenceEMA(prices, period)
+		for i, p := range got {
+			ema := p.EMAs[strconv.Itoa(period)]
+			if math.Abs(ema-want[i]) > 1e-9 {
+				t.Fatalf("EMA(%d) at price %d = %v, want %v", period, i, ema, want[i])
+			}
+		}
+	}
+	if last := got[len(got)-1]; last.EMA != last.EMAs[strconv.Itoa(emaPeriods[0])] {
+		t.Errorf("ema %v isn't the primary period's", last.EMA)
+	}
+}
+
+func TestEMASurvivesStateReload(t *testing.T) {
+	configure(t, "SAVEDUSDT")
+	configure(t, "LIVEUSDT")
+	path := filepath.Join(t.TempDir(), "state.json")
+	prices := zigzag(80)
+
+	feedPrices("SAVEDUSDT", prices[:50]...)
+	if err := saveState(path); err != nil {
+		t.Fatal(err)
+	}
+	resetSymbol("SAVEDUSDT")
+	if loadState(path).IsZero() {
+		t.Fatal("nothing restored")
+	}
+	restored := feedPrices("SAVEDUSDT", prices[50:]...)
+
+	// A processor that never restarted carries on exactly the same: the
+	// EMAs are persisted as they are, while the moving averages' running
+	// sums are rebuilt on load, to within rounding
+	live := feedPrices("LIVEUSDT", prices...)[50:]
+	for i, got := range restored {
+		want := live[i]
+		if !reflect.DeepEqual(got.EMAs, want.EMAs) || got.EMA != want.EMA || !reflect.DeepEqual(got.MACD, want.MACD) || got.RSI != want.RSI {
+			t.Fatalf("price %d after reload: EMAs %v, MACD %+v, RSI %v; without one %v, %+v, %v",
+				50+i, got.EMAs, got.MACD, got.RSI, want.EMAs, want.MACD, want.RSI)
+		}
+		if math.Abs(got.MovingAverage-want.MovingAverage) > 1e-9 || got.High != want.High || got.Low != want.Low {
+			t.Fatalf("price %d after reload: MA %v, high/low %v/%v; without one %v, %v/%v",
+				50+i, got.MovingAverage, got.High, got.Low, want.MovingAverage, want.High, want.Low)
+		}
+	}
+}
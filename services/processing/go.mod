@@ -2,7 +2,10 @@ module processing
 
 go 1.23
 
-require github.com/nats-io/nats.go v1.38.0
+require (
+	github.com/nats-io/nats.go v1.38.0
+	github.com/shopspring/decimal v1.4.0
+)
 
 require (
 	github.com/klauspost/compress v1.17.11 // indirect
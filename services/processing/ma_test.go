@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// bruteMA averages the last window prices, or all of them while fewer
+func bruteMA(prices []float64, window int) float64 {
+	if len(prices) < window {
+		window = len(prices)
+	}
+	sum := 0.0
+	for _, p := range prices[len(prices)-window:] {
+		sum += p
+	}
+	return sum / float64(window)
+}
+
+func TestMovingAveragesMatchBruteForce(t *testing.T) {
+	configure(t, "MAUSDT")
+	maWindows = []int{1, 7, 20, 50}
+	setMAWindows(maWindows)
+
+	// Past SUM_REBUILD_INTERVAL, so the running sums are rebuilt on the way
+	var prices []float64
+	for i := 0; i < 12000; i++ {
+		price := 20000 + 500*math.Sin(float64(i)/37) + float64(i%13)*0.01
+		prices = append(prices, price)
+		got := feedPrices("MAUSDT", price)[0]
+		for _, w := range maWindows {
+			want := bruteMA(prices, w)
+			if ma := got.MovingAverages[strconv.Itoa(w)]; math.Abs(ma-want) > 1e-6 {
+				t.Fatalf("price %d, window %d: running MA %v, recomputed %v", i, w, ma, want)
+			}
+		}
+		if got.MovingAverage != got.MovingAverages["1"] {
+			t.Fatalf("price %d: moving_average %v isn't the primary window's", i, got.MovingAverage)
+		}
+	}
+}
+
+func TestParseWindows(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []int
+		err   bool
+	}{
+		{value: "20", want: []int{20}},
+		{value: " 20, 50 ,200", want: []int{20, 50, 200}},
+		{value: "20,,50", want: []int{20, 50}},
+		{value: "", err: true},
+		{value: "0", err: true},
+		{value: "1001", err: true},
+		{value: "20,x", err: true},
+		{value: "1,2,3,4,5,6,7,8,9", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWindows(tt.value, 8, 1000)
+		if (err != nil) != tt.err {
+			t.Errorf("parseWindows(%q) error = %v, want error %v", tt.value, err, tt.err)
+			continue
+		}
+		if !tt.err && !slices.Equal(got, tt.want) {
+			t.Errorf("parseWindows(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
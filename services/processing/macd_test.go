@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// The periods process.h fixes for MACD
+const (
+	macdFast   = 12
+	macdSlow   = 26
+	macdSignal = 9
+)
+
+func TestMACDMatchesReference(t *testing.T) {
+	configure(t, "MACDUSDT")
+	prices := zigzag(200)
+	got := feedPrices("MACDUSDT", prices...)
+
+	// The signal line is the 9-period EMA of fast minus slow, which only
+	// exists once the slow EMA is seeded
+	fast := referenceEMA(prices, macdFast)
+	slow := referenceEMA(prices, macdSlow)
+	start := macdSlow - 1
+	lines := make([]float64, 0, len(prices)-start)
+	for i := start; i < len(prices); i++ {
+		lines = append(lines, fast[i]-slow[i])
+	}
+	signal := referenceEMA(lines, macdSignal)
+
+	ready := macdSlow + macdSignal - 2
+	for i, p := range got {
+		if i < ready {
+			if p.MACD != nil {
+				t.Fatalf("MACD %+v after %d prices, want none before %d", *p.MACD, i+1, ready+1)
+			}
+			continue
+		}
+		if p.MACD == nil {
+			t.Fatalf("no MACD after %d prices", i+1)
+		}
+		line, sig := lines[i-start], signal[i-start]
+		if math.Abs(p.MACD.MACD-line) > 1e-9 || math.Abs(p.MACD.Signal-sig) > 1e-9 ||
+			math.Abs(p.MACD.Histogram-(line-sig)) > 1e-9 {
+			t.Fatalf("MACD after %d prices = %+v, want %v/%v/%v", i+1, *p.MACD, line, sig, line-sig)
+		}
+	}
+}
+
+func TestMACDFlatPrices(t *testing.T) {
+	configure(t, "FLATUSDT")
+	prices := make([]float64, 60)
+	for i := range prices {
+		prices[i] = 42
+	}
+	last := feedPrices("FLATUSDT", prices...)[len(prices)-1]
+	if last.MACD == nil || *last.MACD != (MACD{}) {
+		t.Fatalf("MACD of a flat series = %+v, want all zero", last.MACD)
+	}
+}
@@ -2,49 +2,162 @@ package main
 
 /*
 #cgo LDFLAGS: -L. -lprocess -lpthread -lstdc++
+#include <stdlib.h>
 #include "process.h"
 */
 import "C"
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
+	"math"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/nats-io/nats.go"
 )
 
 var (
-	currentSymbol string
-	symbolMu      sync.RWMutex
+	trackedSymbols map[string]bool
+
+	// Symbols whose feed streams trades and a ticker, from status.connection
+	bothStreams = make(map[string]bool)
+
+	// Guards trackedSymbols and bothStreams. Trades are processed under the read lock and
+	// symbol changes and session resets take the write lock, so a reset
+	// can't land between updating a symbol and reading back its stats.
+	stateMu sync.RWMutex
+
+	// Moving-average windows in ticks, primary first
+	maWindows = []int{20}
+
+	// EMA periods in ticks, primary first
+	emaPeriods = []int{20}
+
+	// Bollinger Band width in standard deviations
+	bollingerK = 2.0
+
+	// maWindows and emaPeriods as ProcessedMessage keys, made once rather
+	// than per trade
+	maKeys, emaKeys []string
 )
 
 // TradeMessage from ingestion service
 type TradeMessage struct {
-	Symbol string  `json:"symbol"`
-	Price  float64 `json:"price"`
-	Time   int64   `json:"time"`
+	Symbol   string  `json:"symbol"`
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Time     int64   `json:"time"`
+	Side     string  `json:"side,omitempty"`
+	Backfill bool    `json:"backfill"`
+	Ticker   bool    `json:"ticker"`
 }
 
 // ProcessedMessage published after C++ processing
 type ProcessedMessage struct {
-	Symbol        string  `json:"symbol"`
-	Price         float64 `json:"price"`
-	MovingAverage float64 `json:"moving_average"`
-	High          float64 `json:"high"`
-	Low           float64 `json:"low"`
-	Time          int64   `json:"time"`
+	Symbol         string             `json:"symbol"`
+	Price          float64            `json:"price"`
+	Quantity       float64            `json:"quantity"` // of this trade, 0 if the exchange doesn't report it
+	MovingAverage  float64            `json:"moving_average"`
+	MovingAverages map[string]float64 `json:"moving_averages"` // keyed by window
+	High           float64            `json:"high"`
+	Low            float64            `json:"low"`
+	RSI            float64            `json:"rsi"` // -1 until enough samples
+	EMA            float64            `json:"ema"` // primary period, -1 until enough samples
+	EMAs           map[string]float64 `json:"emas"`
+	VWAP           float64            `json:"vwap"`      // session, 0 until a trade with quantity
+	MACD           *MACD              `json:"macd"`      // nil until enough samples
+	Bollinger      *Bollinger         `json:"bollinger"` // nil until the primary MA window is full
+	Time           int64              `json:"time"`
+	Side           string             `json:"side,omitempty"`     // aggressor, "buy" or "sell", when the exchange reports it
+	Backfill       bool               `json:"backfill,omitempty"` // seeded from exchange history, not live
+	Ticker         bool               `json:"ticker,omitempty"`   // last price from a ticker stream, not a trade
+}
+
+// Bollinger holds the bands k standard deviations around the SMA of the
+// primary MA window
+type Bollinger struct {
+	Upper  float64 `json:"upper"`
+	Middle float64 `json:"middle"`
+	Lower  float64 `json:"lower"`
+	Period int     `json:"period"`
+	K      float64 `json:"k"`
+}
+
+// MACD is the 12/26 EMA difference with its 9-period signal line
+type MACD struct {
+	MACD      float64 `json:"macd"`
+	Signal    float64 `json:"signal"`
+	Histogram float64 `json:"histogram"`
 }
 
 func main() {
+	setupLogging()
+
 	natsURL := os.Getenv("NATS_URL")
 	if natsURL == "" {
 		natsURL = "nats://localhost:4222"
 	}
 
-	log.Println("Processing service starting...")
+	statePath := os.Getenv("STATE_FILE")
+	if statePath == "" {
+		statePath = defaultStatePath()
+	}
+
+	if v := os.Getenv("MA_WINDOWS"); v != "" {
+		windows, err := parseWindows(v, C.MAX_MA_WINDOWS, C.PROCESSOR_BUFFER_SIZE)
+		if err != nil {
+			fatal("Invalid MA_WINDOWS", "value", v, "err", err)
+		}
+		maWindows = windows
+	}
+	setMAWindows(maWindows)
+
+	if v := os.Getenv("EMA_PERIODS"); v != "" {
+		periods, err := parseWindows(v, C.MAX_EMA_PERIODS, C.PROCESSOR_BUFFER_SIZE)
+		if err != nil {
+			fatal("Invalid EMA_PERIODS", "value", v, "err", err)
+		}
+		emaPeriods = periods
+	}
+	setEMAPeriods(emaPeriods)
+
+	if v := os.Getenv("BOLLINGER_K"); v != "" {
+		k, err := strconv.ParseFloat(v, 64)
+		if err != nil || k <= 0 {
+			fatal("Invalid BOLLINGER_K", "value", v)
+		}
+		bollingerK = k
+	}
+
+	if v := os.Getenv("SESSION_RESET"); v != "" {
+		at, err := parseSessionReset(v)
+		if err != nil {
+			fatal("Invalid SESSION_RESET", "value", v, "err", err)
+		}
+		sessionResetAt = at
+	}
+
+	slog.Info("Processing service starting", "ma_windows", maWindows, "ema_periods", emaPeriods, "bollinger_k", bollingerK)
+
+	// Continue the previous session if a snapshot exists, unless a reset
+	// was due while stopped
+	savedAt := loadState(statePath)
+	if sessionResetAt >= 0 {
+		if !savedAt.IsZero() && savedAt.Before(sessionStart(time.Now(), sessionResetAt)) {
+			resetSession("missed while stopped", false)
+		}
+		slog.Info("Daily session reset enabled", "at", fmt.Sprintf("%02d:%02d UTC", int(sessionResetAt.Hours()), int(sessionResetAt.Minutes())%60))
+		go sessionLoop()
+	}
+	go snapshotLoop(statePath)
 
 	// Connect to NATS with retry
 	var nc *nats.Conn
@@ -54,28 +167,68 @@ func main() {
 		if err == nil {
 			break
 		}
-		log.Printf("NATS connection failed, retrying in 2s... (%v)", err)
+		slog.Warn("NATS connection failed, retrying in 2s", "err", err)
 		time.Sleep(2 * time.Second)
 	}
 	if err != nil {
-		log.Fatalf("Failed to connect to NATS: %v", err)
+		fatal("Failed to connect to NATS", "err", err)
 	}
 	defer nc.Close()
-	log.Println("Connected to NATS")
+	slog.Info("Connected to NATS", "url", natsURL)
 
 	// Subscribe to symbol change for processor reset
 	nc.Subscribe("control.symbol", func(msg *nats.Msg) {
 		var req struct {
-			Symbol string `json:"symbol"`
+			Symbol  string   `json:"symbol"`
+			Symbols []string `json:"symbols"`
 		}
 		if err := json.Unmarshal(msg.Data, &req); err != nil {
 			return
 		}
-		symbolMu.Lock()
-		currentSymbol = req.Symbol
-		symbolMu.Unlock()
-		C.reset_processor()
-		log.Printf("Processor reset for symbol change to %s", req.Symbol)
+		if len(req.Symbols) == 0 && req.Symbol != "" {
+			req.Symbols = []string{req.Symbol}
+		}
+
+		tracked := make(map[string]bool)
+		for _, sym := range req.Symbols {
+			tracked[sym] = true
+		}
+
+		// Drop state for symbols that are no longer tracked
+		stateMu.Lock()
+		for sym := range trackedSymbols {
+			if !tracked[sym] {
+				resetSymbol(sym)
+			}
+		}
+		trackedSymbols = tracked
+		stateMu.Unlock()
+		slog.Info("Processor reset for symbol change", "symbols", req.Symbols)
+	})
+
+	// Feed status says whether a symbol's ticker updates come alongside its
+	// trades
+	nc.Subscribe("status.connection", func(msg *nats.Msg) {
+		var status struct {
+			Symbol string `json:"symbol"`
+			Stream string `json:"stream"`
+		}
+		if err := json.Unmarshal(msg.Data, &status); err != nil || status.Symbol == "" {
+			return
+		}
+		both := status.Stream == "both"
+		stateMu.Lock()
+		if both {
+			bothStreams[status.Symbol] = true
+		} else {
+			delete(bothStreams, status.Symbol)
+		}
+		stateMu.Unlock()
+	})
+
+	// Subscribe to session resets asked for through the API
+	nc.Subscribe("control.session", func(msg *nats.Msg) {
+		resetSession("requested", true)
 	})
 
 	// Subscribe to raw trades
@@ -85,33 +238,186 @@ func main() {
 			return
 		}
 
-		// Ignore trades from old symbol after a symbol change
-		symbolMu.RLock()
-		sym := currentSymbol
-		symbolMu.RUnlock()
-		if sym != "" && trade.Symbol != sym {
+		if !validPrice(trade.Price) {
+			slog.Warn("Ignoring invalid price", "symbol", trade.Symbol, "price", trade.Price)
 			return
 		}
 
-		// Process through C++
-		C.add_price(C.double(trade.Price))
+		// Ignore trades from dropped symbols after a symbol change. Checked
+		// under the lock held until the stats are read, so a trade can't
+		// revive a symbol dropped meanwhile.
+		stateMu.RLock()
+		defer stateMu.RUnlock()
+		if trackedSymbols != nil && !trackedSymbols[trade.Symbol] {
+			return
+		}
 
-		// Get stats
-		processed := ProcessedMessage{
-			Symbol:        trade.Symbol,
-			Price:         trade.Price,
-			MovingAverage: float64(C.get_moving_average()),
-			High:          float64(C.get_high()),
-			Low:           float64(C.get_low()),
-			Time:          trade.Time,
+		// History only fills in before what this processor has already seen,
+		// restored from a snapshot or live
+		if trade.Backfill && trade.Time <= seenUntil(trade.Symbol) {
+			return
 		}
 
-		data, _ := json.Marshal(processed)
+		data, _ := json.Marshal(processTrade(trade))
 		nc.Publish("trades.processed", data)
 	})
 
-	log.Println("Processing service running, subscribed to trades.raw")
+	slog.Info("Processing service running", "subject", "trades.raw")
+
+	// Keep running until stopped, then persist the final state
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	slog.Info("Shutting down")
+
+	// Finish in-flight trades so the snapshot includes them
+	if err := nc.Drain(); err != nil {
+		slog.Error("NATS drain failed", "err", err)
+	}
+	if err := saveState(statePath); err != nil {
+		slog.Error("State snapshot failed", "err", err)
+	}
+}
+
+// parseWindows parses a comma-separated list of averaging windows, allowing
+// at most maxCount windows of up to maxWindow ticks each
+func parseWindows(value string, maxCount, maxWindow int) ([]int, error) {
+	var windows []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		if w < 1 || w > maxWindow {
+			return nil, fmt.Errorf("window %d out of range 1-%d", w, maxWindow)
+		}
+		windows = append(windows, w)
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no windows given")
+	}
+	if len(windows) > maxCount {
+		return nil, fmt.Errorf("at most %d windows supported", maxCount)
+	}
+	return windows, nil
+}
+
+// windowKeys returns windows as the keys ProcessedMessage's maps use
+func windowKeys(windows []int) []string {
+	keys := make([]string, len(windows))
+	for i, w := range windows {
+		keys[i] = strconv.Itoa(w)
+	}
+	return keys
+}
+
+// setMAWindows configures the C++ moving-average windows
+func setMAWindows(windows []int) {
+	maKeys = windowKeys(windows)
+	cw := make([]C.int, len(windows))
+	for i, w := range windows {
+		cw[i] = C.int(w)
+	}
+	C.set_ma_windows(&cw[0], C.int(len(cw)))
+}
 
-	// Keep running
-	select {}
+// setEMAPeriods configures the C++ EMA periods
+func setEMAPeriods(periods []int) {
+	emaKeys = windowKeys(periods)
+	cp := make([]C.int, len(periods))
+	for i, p := range periods {
+		cp[i] = C.int(p)
+	}
+	C.set_ema_periods(&cp[0], C.int(len(cp)))
+}
+
+// resetSymbol clears the C++ processor state for one symbol
+func resetSymbol(symbol string) {
+	sym := C.CString(symbol)
+	defer C.free(unsafe.Pointer(sym))
+	C.reset_symbol(sym)
+	forgetVWAP(symbol)
+	forgetSeen(symbol)
+}
+
+// processTrade folds a trade into symbol's indicators and returns them as
+// published. Callers hold stateMu's read lock.
+func processTrade(trade TradeMessage) ProcessedMessage {
+	// Process through C++, folding and reading back in one call
+	sym := C.CString(trade.Symbol)
+	defer C.free(unsafe.Pointer(sym))
+	session := inSession(trade.Time)
+	var stats C.ProcessorStats
+	if trade.Ticker && bothStreams[trade.Symbol] {
+		// The trades already feed the tick windows; a ticker last price
+		// would count one of them twice
+		C.process_ticker(sym, C.double(trade.Price), C.int(boolInt(session)), C.double(bollingerK), &stats)
+	} else {
+		C.process_trade(sym, C.double(trade.Price), C.int(boolInt(session)), C.double(bollingerK), &stats)
+		if session {
+			addVWAP(trade.Symbol, trade.Price, trade.Quantity)
+		}
+	}
+	markSeen(trade.Symbol, trade.Time)
+
+	processed := ProcessedMessage{
+		Symbol:        trade.Symbol,
+		Price:         trade.Price,
+		Quantity:      trade.Quantity,
+		MovingAverage: float64(stats.moving_average),
+		High:          float64(stats.high),
+		Low:           float64(stats.low),
+		RSI:           float64(stats.rsi),
+		VWAP:          vwap(trade.Symbol),
+		Time:          trade.Time,
+		Side:          trade.Side,
+		Backfill:      trade.Backfill,
+		Ticker:        trade.Ticker,
+	}
+	if stats.has_macd != 0 {
+		processed.MACD = &MACD{
+			MACD:      float64(stats.macd),
+			Signal:    float64(stats.macd_signal),
+			Histogram: float64(stats.macd_histogram),
+		}
+	}
+	if stats.has_bollinger != 0 {
+		processed.Bollinger = &Bollinger{
+			Upper:  float64(stats.bollinger_upper),
+			Middle: float64(stats.bollinger_middle),
+			Lower:  float64(stats.bollinger_lower),
+			Period: maWindows[0],
+			K:      bollingerK,
+		}
+	}
+
+	processed.MovingAverages = make(map[string]float64, len(maKeys))
+	for i, key := range maKeys {
+		processed.MovingAverages[key] = float64(stats.moving_averages[i])
+	}
+	processed.EMAs = make(map[string]float64, len(emaKeys))
+	for i, key := range emaKeys {
+		processed.EMAs[key] = float64(stats.emas[i])
+	}
+	processed.EMA = float64(stats.emas[0])
+	return processed
+}
+
+// validPrice reports whether price can be folded in: a zero, negative or
+// infinite one would corrupt high/low and every average
+func validPrice(price float64) bool {
+	return price > 0 && !math.IsInf(price, 0)
+}
+
+// boolInt returns b as a C-style flag
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
 }
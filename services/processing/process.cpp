@@ -1,70 +1,608 @@
 #include "process.h"
 #include <vector>
+#include <deque>
 #include <mutex>
+#include <cmath>
 #include <limits>
+#include <map>
+#include <string>
 
-// Buffer size for moving average calculation
-const int BUFFER_SIZE = 20;
+// Retained prices per symbol
+const int BUFFER_SIZE = PROCESSOR_BUFFER_SIZE;
 
-// Thread-safe price processor
+// Number of price changes used for RSI
+const int RSI_PERIOD = 14;
+
+// Pushes between exact recomputations of the running sums, bounding the
+// floating-point drift of incremental add/subtract
+const int SUM_REBUILD_INTERVAL = 10000;
+
+// Exponential moving average, seeded with the simple average of the first
+// period prices
+struct Ema {
+    int period = 0;
+    int count = 0;
+    double seed_sum = 0.0;
+    double value = 0.0;
+};
+
+// Fold one price into an EMA
+static void update_ema(Ema& e, double price) {
+    if (e.count < e.period) {
+        e.count++;
+        e.seed_sum += price;
+        if (e.count == e.period) {
+            e.value = e.seed_sum / e.period;
+        }
+        return;
+    }
+
+    double alpha = 2.0 / (e.period + 1);
+    e.value += alpha * (price - e.value);
+}
+
+// Ema with the given period and no samples yet
+static Ema make_ema(int period) {
+    Ema e;
+    e.period = period;
+    return e;
+}
+
+// Per-symbol price state
+struct Processor {
+    std::deque<double> price_buffer;  // newest last, up to the largest MA window
+    std::vector<double> window_sums;  // running sum per configured MA window
+    std::vector<double> window_sq_sums;  // running sum of squares per window
+    int pushes_since_rebuild = 0;
+    double high_price = 0.0;
+    double low_price = std::numeric_limits<double>::max();
+
+    // RSI state (Wilder's smoothing)
+    double last_price = 0.0;
+    int rsi_changes = 0;
+    double avg_gain = 0.0;
+    double avg_loss = 0.0;
+
+    // One EMA per configured period
+    std::vector<Ema> emas;
+
+    // MACD: fast/slow EMAs of price, signal EMA of their difference
+    Ema macd_fast = make_ema(MACD_FAST_PERIOD);
+    Ema macd_slow = make_ema(MACD_SLOW_PERIOD);
+    Ema macd_signal = make_ema(MACD_SIGNAL_PERIOD);
+};
+
+// Fold one price into the MACD EMAs; the signal EMA starts once the slow
+// EMA is seeded
+static void update_macd(Processor& p, double price) {
+    update_ema(p.macd_fast, price);
+    update_ema(p.macd_slow, price);
+    if (p.macd_slow.count < p.macd_slow.period) {
+        return;
+    }
+    update_ema(p.macd_signal, p.macd_fast.value - p.macd_slow.value);
+}
+
+// Copy an EMA to and from its persisted form
+static void save_ema(const Ema& e, EmaState& out) {
+    out.period = e.period;
+    out.count = e.count;
+    out.seed_sum = e.seed_sum;
+    out.value = e.value;
+}
+
+static void load_ema(const EmaState& in, Ema& e) {
+    e.period = in.period;
+    e.count = in.count;
+    e.seed_sum = in.seed_sum;
+    e.value = in.value;
+}
+
+// Fold one price change into the Wilder averages
+static void update_rsi(Processor& p, double price) {
+    if (p.last_price <= 0.0) {
+        p.last_price = price;
+        return;
+    }
+
+    double change = price - p.last_price;
+    double gain = change > 0 ? change : 0.0;
+    double loss = change < 0 ? -change : 0.0;
+    p.last_price = price;
+    p.rsi_changes++;
+
+    if (p.rsi_changes <= RSI_PERIOD) {
+        // Seed with the simple average of the first RSI_PERIOD changes
+        p.avg_gain += gain / RSI_PERIOD;
+        p.avg_loss += loss / RSI_PERIOD;
+        return;
+    }
+
+    p.avg_gain = (p.avg_gain * (RSI_PERIOD - 1) + gain) / RSI_PERIOD;
+    p.avg_loss = (p.avg_loss * (RSI_PERIOD - 1) + loss) / RSI_PERIOD;
+}
+
+// Thread-safe price processors keyed by symbol
 static std::mutex mtx;
-static std::vector<double> price_buffer;
-static double high_price = 0.0;
-static double low_price = std::numeric_limits<double>::max();
+static std::map<std::string, Processor> processors;
+
+// Configured moving-average windows, primary first
+static std::vector<int> ma_windows = {20};
+static int max_window = 20;
+
+// Configured EMA periods, primary first
+static std::vector<int> ema_periods = {20};
+
+// Match a processor's EMAs to the configured periods, keeping existing state
+static void sync_emas(Processor& p) {
+    std::vector<Ema> emas;
+    for (int period : ema_periods) {
+        Ema e;
+        e.period = period;
+        for (const Ema& old : p.emas) {
+            if (old.period == period) {
+                e = old;
+                break;
+            }
+        }
+        emas.push_back(e);
+    }
+    p.emas = emas;
+}
+
+// Recompute a processor's running sums from its buffer
+static void rebuild_sums(Processor& p) {
+    while (static_cast<int>(p.price_buffer.size()) > max_window) {
+        p.price_buffer.pop_front();
+    }
+
+    p.window_sums.assign(ma_windows.size(), 0.0);
+    p.window_sq_sums.assign(ma_windows.size(), 0.0);
+    int n = static_cast<int>(p.price_buffer.size());
+    for (size_t i = 0; i < ma_windows.size(); i++) {
+        int start = n > ma_windows[i] ? n - ma_windows[i] : 0;
+        for (int j = start; j < n; j++) {
+            p.window_sums[i] += p.price_buffer[j];
+            p.window_sq_sums[i] += p.price_buffer[j] * p.price_buffer[j];
+        }
+    }
+    p.pushes_since_rebuild = 0;
+}
+
+// Push a price, keeping every window's running sums in O(1)
+static void push_price(Processor& p, double price) {
+    if (p.window_sums.size() != ma_windows.size()) {
+        rebuild_sums(p);
+    }
+
+    p.price_buffer.push_back(price);
+    int n = static_cast<int>(p.price_buffer.size());
+    for (size_t i = 0; i < ma_windows.size(); i++) {
+        p.window_sums[i] += price;
+        p.window_sq_sums[i] += price * price;
+        if (n > ma_windows[i]) {
+            double old = p.price_buffer[n - 1 - ma_windows[i]];
+            p.window_sums[i] -= old;
+            p.window_sq_sums[i] -= old * old;
+        }
+    }
+
+    if (n > max_window) {
+        p.price_buffer.pop_front();
+    }
+
+    if (++p.pushes_since_rebuild >= SUM_REBUILD_INTERVAL) {
+        rebuild_sums(p);
+    }
+}
+
+// Fold a trade into a processor. Session trades also update the session
+// high/low; earlier ones only feed the price history.
+static void fold_trade(Processor& p, double price, bool session) {
+    if (session) {
+        // Update high/low
+        if (price > p.high_price) {
+            p.high_price = price;
+        }
+        if (price < p.low_price) {
+            p.low_price = price;
+        }
+    }
+
+    push_price(p, price);
+
+    update_rsi(p, price);
+
+    if (p.emas.size() != ema_periods.size()) {
+        sync_emas(p);
+    }
+    for (Ema& e : p.emas) {
+        update_ema(e, price);
+    }
+
+    update_macd(p, price);
+}
+
+// Look up a processor, returning nullptr if the symbol has no data
+static const Processor* find_processor(const char* symbol) {
+    auto it = processors.find(symbol);
+    if (it == processors.end()) {
+        return nullptr;
+    }
+    return &it->second;
+}
+
+// The indicators below read a processor found by find_processor, with mtx
+// held; a nullptr one reads as having no data
+
+// Simple moving average over the i-th configured window, or over every
+// price while fewer have been seen
+static double moving_average(const Processor* p, size_t i) {
+    if (p == nullptr || p->price_buffer.empty() || i >= p->window_sums.size()) {
+        return 0.0;
+    }
+
+    int n = static_cast<int>(p->price_buffer.size());
+    int w = ma_windows[i] < n ? ma_windows[i] : n;
+    return p->window_sums[i] / w;
+}
+
+// Bollinger Bands over the primary window, or 0 until it is full
+static int bollinger(const Processor* p, double k, double* upper, double* middle, double* lower) {
+    int w = ma_windows[0];
+    if (p == nullptr || static_cast<int>(p->price_buffer.size()) < w || p->window_sq_sums.empty()) {
+        return 0;
+    }
+
+    double mean = p->window_sums[0] / w;
+    double variance = p->window_sq_sums[0] / w - mean * mean;
+    if (variance < 0.0) {
+        variance = 0.0;  // rounding on a flat window
+    }
+    double band = k * std::sqrt(variance);
+
+    *middle = mean;
+    *upper = mean + band;
+    *lower = mean - band;
+    return 1;
+}
+
+// An EMA's value, or -1 until it is seeded
+static double ema_value(const Ema& e) {
+    return e.count < e.period ? -1.0 : e.value;
+}
+
+static double high(const Processor* p) {
+    if (p == nullptr) {
+        return 0.0;
+    }
+    return p->high_price;
+}
+
+static double low(const Processor* p) {
+    // Return 0 if no prices have been added yet
+    if (p == nullptr || p->low_price == std::numeric_limits<double>::max()) {
+        return 0.0;
+    }
+    return p->low_price;
+}
+
+// MACD lines, or 0 until the signal EMA is seeded
+static int macd_lines(const Processor* p, double* macd, double* signal, double* histogram) {
+    if (p == nullptr || p->macd_signal.count < p->macd_signal.period) {
+        return 0;
+    }
+
+    *macd = p->macd_fast.value - p->macd_slow.value;
+    *signal = p->macd_signal.value;
+    *histogram = *macd - *signal;
+    return 1;
+}
+
+// Wilder-smoothed RSI, or -1 until RSI_PERIOD changes have been seen
+static double rsi(const Processor* p) {
+    if (p == nullptr || p->rsi_changes < RSI_PERIOD) {
+        return -1.0;
+    }
+
+    if (p->avg_loss == 0.0) {
+        // Flat market reads neutral, only gains reads fully overbought
+        return p->avg_gain == 0.0 ? 50.0 : 100.0;
+    }
+    double rs = p->avg_gain / p->avg_loss;
+    return 100.0 - 100.0 / (1.0 + rs);
+}
+
+// Look up a processor for a positive price, creating it, or find an
+// existing one otherwise; one map search either way. nullptr if none.
+static Processor* lookup_processor(const char* symbol, double price) {
+    std::string key(symbol);
+    auto it = processors.lower_bound(key);
+    if (it == processors.end() || it->first != key) {
+        if (!(price > 0.0)) {
+            return nullptr;
+        }
+        it = processors.emplace_hint(it, std::move(key), Processor());
+    }
+    return &it->second;
+}
+
+// Fill out with every indicator of p, k standard deviations wide for
+// Bollinger Bands
+static void fill_stats(const Processor* p, double k, ProcessorStats* out) {
+    out->moving_average = moving_average(p, 0);
+    out->ma_count = static_cast<int>(ma_windows.size());
+    for (size_t i = 0; i < ma_windows.size(); i++) {
+        out->moving_averages[i] = moving_average(p, i);
+    }
+    out->ema_count = static_cast<int>(ema_periods.size());
+    for (size_t i = 0; i < ema_periods.size(); i++) {
+        out->emas[i] = p != nullptr && i < p->emas.size() ? ema_value(p->emas[i]) : -1.0;
+    }
+    out->high = high(p);
+    out->low = low(p);
+    out->rsi = rsi(p);
+    out->has_macd = macd_lines(p, &out->macd, &out->macd_signal, &out->macd_histogram);
+    out->has_bollinger = bollinger(p, k, &out->bollinger_upper, &out->bollinger_middle, &out->bollinger_lower);
+}
 
 extern "C" {
 
-void add_price(double price) {
+void add_price(const char* symbol, double price) {
+    add_trade(symbol, price);
+}
+
+void add_trade(const char* symbol, double price) {
+    // Reject non-positive and NaN prices
+    if (!(price > 0.0)) {
+        return;
+    }
+
+    std::lock_guard<std::mutex> lock(mtx);
+    fold_trade(processors[symbol], price, true);
+}
+
+void add_prior_trade(const char* symbol, double price) {
+    if (!(price > 0.0)) {
+        return;
+    }
+
+    std::lock_guard<std::mutex> lock(mtx);
+    fold_trade(processors[symbol], price, false);
+}
+
+void set_ma_windows(const int* windows, int count) {
     std::lock_guard<std::mutex> lock(mtx);
 
-    // Update high/low
-    if (price > high_price) {
-        high_price = price;
+    ma_windows.clear();
+    for (int i = 0; i < count && i < MAX_MA_WINDOWS; i++) {
+        int w = windows[i];
+        if (w < 1) {
+            w = 1;
+        }
+        if (w > BUFFER_SIZE) {
+            w = BUFFER_SIZE;
+        }
+        ma_windows.push_back(w);
     }
-    if (price < low_price) {
-        low_price = price;
+    if (ma_windows.empty()) {
+        ma_windows.push_back(20);
     }
 
-    // Add to circular buffer
-    if (price_buffer.size() >= BUFFER_SIZE) {
-        price_buffer.erase(price_buffer.begin());
+    max_window = 0;
+    for (int w : ma_windows) {
+        if (w > max_window) {
+            max_window = w;
+        }
     }
-    price_buffer.push_back(price);
+
+    for (auto& entry : processors) {
+        rebuild_sums(entry.second);
+    }
+}
+
+double get_moving_average(const char* symbol) {
+    std::lock_guard<std::mutex> lock(mtx);
+    return moving_average(find_processor(symbol), 0);
 }
 
-double get_moving_average(void) {
+double get_moving_average_window(const char* symbol, int window) {
     std::lock_guard<std::mutex> lock(mtx);
+    const Processor* p = find_processor(symbol);
 
-    if (price_buffer.empty()) {
+    if (p == nullptr || p->price_buffer.empty() || window < 1) {
         return 0.0;
     }
 
+    // Configured windows are maintained incrementally
+    for (size_t i = 0; i < ma_windows.size() && i < p->window_sums.size(); i++) {
+        if (ma_windows[i] == window) {
+            return moving_average(p, i);
+        }
+    }
+
+    int n = static_cast<int>(p->price_buffer.size());
+    int w = window < n ? window : n;
     double sum = 0.0;
-    for (double p : price_buffer) {
-        sum += p;
+    for (int j = n - w; j < n; j++) {
+        sum += p->price_buffer[j];
     }
-    return sum / price_buffer.size();
+    return sum / w;
 }
 
-double get_high(void) {
+int get_bollinger(const char* symbol, double k, double* upper, double* middle, double* lower) {
     std::lock_guard<std::mutex> lock(mtx);
-    return high_price;
+    return bollinger(find_processor(symbol), k, upper, middle, lower);
 }
 
-double get_low(void) {
+void set_ema_periods(const int* periods, int count) {
     std::lock_guard<std::mutex> lock(mtx);
-    // Return 0 if no prices have been added yet
-    if (low_price == std::numeric_limits<double>::max()) {
-        return 0.0;
+
+    ema_periods.clear();
+    for (int i = 0; i < count && i < MAX_EMA_PERIODS; i++) {
+        int p = periods[i];
+        if (p < 1) {
+            p = 1;
+        }
+        if (p > BUFFER_SIZE) {
+            p = BUFFER_SIZE;
+        }
+        ema_periods.push_back(p);
+    }
+    if (ema_periods.empty()) {
+        ema_periods.push_back(20);
+    }
+
+    for (auto& entry : processors) {
+        sync_emas(entry.second);
+    }
+}
+
+double get_ema(const char* symbol, int period) {
+    std::lock_guard<std::mutex> lock(mtx);
+    const Processor* p = find_processor(symbol);
+    if (p == nullptr) {
+        return -1.0;
+    }
+
+    for (const Ema& e : p->emas) {
+        if (e.period == period) {
+            return ema_value(e);
+        }
+    }
+    return -1.0;
+}
+
+double get_high(const char* symbol) {
+    std::lock_guard<std::mutex> lock(mtx);
+    return high(find_processor(symbol));
+}
+
+double get_low(const char* symbol) {
+    std::lock_guard<std::mutex> lock(mtx);
+    return low(find_processor(symbol));
+}
+
+int get_macd(const char* symbol, double* macd, double* signal, double* histogram) {
+    std::lock_guard<std::mutex> lock(mtx);
+    return macd_lines(find_processor(symbol), macd, signal, histogram);
+}
+
+double get_rsi(const char* symbol) {
+    std::lock_guard<std::mutex> lock(mtx);
+    return rsi(find_processor(symbol));
+}
+
+int process_trade(const char* symbol, double price, int session, double k, ProcessorStats* out) {
+    std::lock_guard<std::mutex> lock(mtx);
+    Processor* p = lookup_processor(symbol, price);
+    if (p != nullptr && price > 0.0) {
+        fold_trade(*p, price, session != 0);
+    }
+    fill_stats(p, k, out);
+    return p != nullptr;
+}
+
+int process_ticker(const char* symbol, double price, int session, double k, ProcessorStats* out) {
+    std::lock_guard<std::mutex> lock(mtx);
+    Processor* p = lookup_processor(symbol, price);
+    if (p != nullptr && price > 0.0 && session != 0) {
+        if (price > p->high_price) {
+            p->high_price = price;
+        }
+        if (price < p->low_price) {
+            p->low_price = price;
+        }
+    }
+    fill_stats(p, k, out);
+    return p != nullptr;
+}
+
+int get_state(const char* symbol, ProcessorState* out) {
+    std::lock_guard<std::mutex> lock(mtx);
+    const Processor* p = find_processor(symbol);
+    if (p == nullptr) {
+        return 0;
+    }
+
+    out->count = static_cast<int>(p->price_buffer.size());
+    for (int i = 0; i < out->count; i++) {
+        out->prices[i] = p->price_buffer[i];
+    }
+    out->high = p->high_price;
+    out->low = p->low_price;
+    out->last_price = p->last_price;
+    out->rsi_changes = p->rsi_changes;
+    out->avg_gain = p->avg_gain;
+    out->avg_loss = p->avg_loss;
+
+    out->ema_count = 0;
+    for (const Ema& e : p->emas) {
+        if (out->ema_count >= MAX_EMA_PERIODS) {
+            break;
+        }
+        save_ema(e, out->emas[out->ema_count++]);
+    }
+
+    save_ema(p->macd_fast, out->macd[0]);
+    save_ema(p->macd_slow, out->macd[1]);
+    save_ema(p->macd_signal, out->macd[2]);
+    return 1;
+}
+
+void set_state(const char* symbol, const ProcessorState* in) {
+    std::lock_guard<std::mutex> lock(mtx);
+    Processor& p = processors[symbol];
+
+    int count = in->count;
+    if (count > BUFFER_SIZE) {
+        count = BUFFER_SIZE;
+    }
+    p.price_buffer.assign(in->prices, in->prices + (count > 0 ? count : 0));
+    rebuild_sums(p);
+    p.high_price = in->high;
+    p.low_price = in->low;
+    p.last_price = in->last_price;
+    p.rsi_changes = in->rsi_changes;
+    p.avg_gain = in->avg_gain;
+    p.avg_loss = in->avg_loss;
+
+    p.emas.clear();
+    for (int i = 0; i < in->ema_count && i < MAX_EMA_PERIODS; i++) {
+        Ema e;
+        load_ema(in->emas[i], e);
+        p.emas.push_back(e);
+    }
+    sync_emas(p);
+
+    // Snapshots from before MACD have no periods here; start those fresh
+    Ema* macd[3] = {&p.macd_fast, &p.macd_slow, &p.macd_signal};
+    for (int i = 0; i < 3; i++) {
+        if (in->macd[i].period == macd[i]->period) {
+            load_ema(in->macd[i], *macd[i]);
+        } else {
+            *macd[i] = make_ema(macd[i]->period);
+        }
+    }
+}
+
+void reset_symbol(const char* symbol) {
+    std::lock_guard<std::mutex> lock(mtx);
+    processors.erase(symbol);
+}
+
+void reset_session(void) {
+    std::lock_guard<std::mutex> lock(mtx);
+    for (auto& entry : processors) {
+        Processor& p = entry.second;
+        p.high_price = 0.0;
+        p.low_price = std::numeric_limits<double>::max();
     }
-    return low_price;
 }
 
 void reset_processor(void) {
     std::lock_guard<std::mutex> lock(mtx);
-    price_buffer.clear();
-    high_price = 0.0;
-    low_price = std::numeric_limits<double>::max();
+    processors.clear();
 }
 
 } // extern "C"
@@ -5,19 +5,138 @@
 extern "C" {
 #endif
 
-// Add a new price to the buffer
-void add_price(double price);
+// Number of recent prices retained per symbol, which bounds the largest
+// supported moving-average window
+#define PROCESSOR_BUFFER_SIZE 1000
 
-// Get the simple moving average of buffered prices
-double get_moving_average(void);
+// Maximum number of moving-average windows computed simultaneously
+#define MAX_MA_WINDOWS 8
 
-// Get the highest price seen
-double get_high(void);
+// Maximum number of EMA periods computed simultaneously
+#define MAX_EMA_PERIODS 8
 
-// Get the lowest price seen
-double get_low(void);
+// MACD periods: fast and slow EMAs and the signal EMA of their difference
+#define MACD_FAST_PERIOD 12
+#define MACD_SLOW_PERIOD 26
+#define MACD_SIGNAL_PERIOD 9
 
-// Reset all data
+// Persisted state of one exponential moving average
+typedef struct {
+    int period;
+    int count;
+    double seed_sum;
+    double value;
+} EmaState;
+
+// Snapshot of one symbol's processor state, used for persistence
+typedef struct {
+    double prices[PROCESSOR_BUFFER_SIZE];
+    int count;
+    double high;
+    double low;
+    double last_price;
+    int rsi_changes;
+    double avg_gain;
+    double avg_loss;
+    EmaState emas[MAX_EMA_PERIODS];
+    int ema_count;
+    EmaState macd[3]; // fast, slow, signal
+} ProcessorState;
+
+// Indicators for one symbol as they stand after a trade
+typedef struct {
+    double moving_average;                   // over the primary window
+    double moving_averages[MAX_MA_WINDOWS];  // per configured window, in order
+    int ma_count;
+    double emas[MAX_EMA_PERIODS];            // per configured period, -1 until seeded
+    int ema_count;
+    double high;
+    double low;
+    double rsi;                              // -1 until seeded
+    int has_macd;
+    double macd;
+    double macd_signal;
+    double macd_histogram;
+    int has_bollinger;
+    double bollinger_upper;
+    double bollinger_middle;
+    double bollinger_lower;
+} ProcessorStats;
+
+// Add a new price to the symbol's buffer
+void add_price(const char* symbol, double price);
+
+// Add a trade of the current session, updating the session high/low as
+// well. The session VWAP is kept in Go, in exact decimals.
+void add_trade(const char* symbol, double price);
+
+// Add a trade from before the current session: it feeds the averages and
+// RSI but not the session high/low
+void add_prior_trade(const char* symbol, double price);
+
+// Configure the moving-average windows maintained for every symbol. The
+// first window is the primary one returned by get_moving_average.
+void set_ma_windows(const int* windows, int count);
+
+// Get the simple moving average over the primary window
+double get_moving_average(const char* symbol);
+
+// Get the simple moving average over the last window prices
+double get_moving_average_window(const char* symbol, int window);
+
+// Get Bollinger Bands over the primary MA window: the SMA plus/minus k
+// population standard deviations. Returns 0 until the window is full.
+int get_bollinger(const char* symbol, double k, double* upper, double* middle, double* lower);
+
+// Configure the EMA periods maintained for every symbol. The first period
+// is the primary one.
+void set_ema_periods(const int* periods, int count);
+
+// Get the exponential moving average for a configured period, or -1 until
+// period prices have been seen (the first value is their simple average)
+double get_ema(const char* symbol, int period);
+
+// Get the highest price seen for the symbol
+double get_high(const char* symbol);
+
+// Get the lowest price seen for the symbol
+double get_low(const char* symbol);
+
+// Get the MACD line, its signal line and the histogram (MACD - signal).
+// Returns 0 until the slow EMA and then the signal EMA have been seeded,
+// i.e. for the first MACD_SLOW_PERIOD + MACD_SIGNAL_PERIOD - 1 prices.
+int get_macd(const char* symbol, double* macd, double* signal, double* histogram);
+
+// Get the Wilder-smoothed RSI for the symbol (0-100), or -1 until
+// RSI_PERIOD price changes have been seen
+double get_rsi(const char* symbol);
+
+// Fold a trade into the symbol, as add_trade does when session is nonzero
+// and add_prior_trade otherwise, and fill out with every indicator, k
+// standard deviations wide for Bollinger Bands. One call takes the lock
+// and looks the symbol up once, for the per-trade path. Returns 0 if the
+// symbol has no data.
+int process_trade(const char* symbol, double price, int session, double k, ProcessorStats* out);
+
+// As process_trade, but for a ticker's last price while the symbol's trades
+// are streamed too: only the session high/low take it, so the tick-based
+// indicators count each trade once
+int process_ticker(const char* symbol, double price, int session, double k, ProcessorStats* out);
+
+// Copy the symbol's state into out; returns 0 if the symbol has no data
+int get_state(const char* symbol, ProcessorState* out);
+
+// Replace the symbol's state with in
+void set_state(const char* symbol, const ProcessorState* in);
+
+// Reset all data for the symbol
+void reset_symbol(const char* symbol);
+
+// Clear every symbol's session high/low, keeping the price
+// history and the indicators built on it
+void reset_session(void);
+
+// Reset all data for every symbol
 void reset_processor(void);
 
 #ifdef __cplusplus
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// configure sets the C++ windows the way main does and drops symbol's state
+// once the test is done
+func configure(tb testing.TB, symbol string) {
+	tb.Helper()
+	maWindows, emaPeriods = []int{20, 50}, []int{12, 20}
+	setMAWindows(maWindows)
+	setEMAPeriods(emaPeriods)
+	resetSymbol(symbol)
+	tb.Cleanup(func() { resetSymbol(symbol) })
+}
+
+// tradeAt is the i-th trade of a zigzag, so RSI and MACD see gains and losses
+func tradeAt(symbol string, i int) TradeMessage {
+	return TradeMessage{
+		Symbol:   symbol,
+		Price:    100 + float64(i%17) - float64(i%5)*0.25,
+		Quantity: 0.1 + float64(i%3)*0.05,
+		Time:     time.Now().UnixMilli(),
+	}
+}
+
+func benchmarkTrades(b *testing.B, fold func(TradeMessage) ProcessedMessage) {
+	configure(b, "BENCHUSDT")
+	trades := make([]TradeMessage, 256)
+	for i := range trades {
+		trades[i] = tradeAt("BENCHUSDT", i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fold(trades[i%len(trades)])
+	}
+}
+
+// BenchmarkProcessTrade folds trades with the single process_trade call
+func BenchmarkProcessTrade(b *testing.B) {
+	benchmarkTrades(b, processTrade)
+}
+
+func TestTickerAlongsideTrades(t *testing.T) {
+	configure(t, "TICKUSDT")
+	bothStreams["TICKUSDT"] = true
+	t.Cleanup(func() { delete(bothStreams, "TICKUSDT") })
+
+	for i := 0; i < 30; i++ {
+		processTrade(TradeMessage{Symbol: "TICKUSDT", Price: 100, Quantity: 1, Time: time.Now().UnixMilli()})
+	}
+	got := processTrade(TradeMessage{Symbol: "TICKUSDT", Price: 130, Time: time.Now().UnixMilli(), Ticker: true})
+	if got.MovingAverage != 100 || got.RSI != 50 {
+		t.Errorf("ticker folded into the windows: moving average %v, RSI %v", got.MovingAverage, got.RSI)
+	}
+	if got.High != 130 || got.Price != 130 {
+		t.Errorf("ticker didn't move the session high: high %v, price %v", got.High, got.Price)
+	}
+	if got.VWAP != 100 {
+		t.Errorf("ticker counted in VWAP: %v", got.VWAP)
+	}
+
+	// Without a trade stream the ticker is the feed and is folded
+	delete(bothStreams, "TICKUSDT")
+	if got := processTrade(TradeMessage{Symbol: "TICKUSDT", Price: 130, Time: time.Now().UnixMilli(), Ticker: true}); got.MovingAverage == 100 {
+		t.Error("ticker-only update left the moving average alone")
+	}
+}
+
+func TestValidPrice(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  bool
+	}{
+		{64000.5, true},
+		{1e-8, true},
+		{0, false},
+		{math.Copysign(0, -1), false},
+		{-1, false},
+		{math.NaN(), false},
+		{math.Inf(1), false},
+		{math.Inf(-1), false},
+	}
+	for _, tt := range tests {
+		if got := validPrice(tt.price); got != tt.want {
+			t.Errorf("validPrice(%v) = %v, want %v", tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestRejectsNonPositivePrices(t *testing.T) {
+	configure(t, "BADUSDT")
+	configure(t, "GOODUSDT")
+	configure(t, "ZEROUSDT")
+
+	// The same trades with bogus prices mixed into one symbol's feed leave
+	// every indicator exactly where the clean feed has it. They get past
+	// the subscriber's validPrice here, so the C++ side has to drop them;
+	// without a quantity, so they don't reach the VWAP sums either.
+	var bad, good ProcessedMessage
+	for i := 0; i < 80; i++ {
+		if i%10 == 5 {
+			for _, price := range []float64{0, -1, math.NaN()} {
+				processTrade(TradeMessage{Symbol: "BADUSDT", Price: price, Time: time.Now().UnixMilli()})
+			}
+		}
+		bad, good = processTrade(tradeAt("BADUSDT", i)), processTrade(tradeAt("GOODUSDT", i))
+	}
+	bad.Symbol, bad.Time, good.Symbol, good.Time = "", 0, "", 0
+	if !reflect.DeepEqual(bad, good) {
+		t.Fatalf("bogus prices changed the indicators:\n%+v\nwant\n%+v", bad, good)
+	}
+
+	// Nor does a zero price start a symbol with a zero low
+	if got := processTrade(TradeMessage{Symbol: "ZEROUSDT", Price: 0, Time: time.Now().UnixMilli()}); got.High != 0 || got.Low != 0 {
+		t.Fatalf("zero price for a new symbol gave high/low %v/%v", got.High, got.Low)
+	}
+	if got := processTrade(TradeMessage{Symbol: "ZEROUSDT", Price: 50, Quantity: 1, Time: time.Now().UnixMilli()}); got.Low != 50 || got.MovingAverage != 50 {
+		t.Fatalf("first real trade after a zero price: low %v, moving average %v, want 50", got.Low, got.MovingAverage)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// feedPrices folds prices into symbol as live trades, returning what each
+// published
+func feedPrices(symbol string, prices ...float64) []ProcessedMessage {
+	out := make([]ProcessedMessage, len(prices))
+	for i, price := range prices {
+		out[i] = processTrade(TradeMessage{Symbol: symbol, Price: price, Quantity: 1, Time: time.Now().UnixMilli()})
+	}
+	return out
+}
+
+func TestRSIWilderSeries(t *testing.T) {
+	configure(t, "RSIUSDT")
+
+	// Wilder's 14-period RSI over StockCharts' worked example series. Its
+	// table rounds the averages as it goes (70.53 first); these are the
+	// exact values, to two places
+	closes := []float64{44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08, 45.89, 46.03, 45.61, 46.28,
+		46.28, 46.00, 46.03, 46.41, 46.22, 45.64}
+	want := map[int]float64{14: 70.46, 15: 66.25, 16: 66.48, 17: 69.35, 18: 66.29, 19: 57.92}
+
+	got := feedPrices("RSIUSDT", closes...)
+	for i, p := range got {
+		expected, ok := want[i]
+		if !ok {
+			if p.RSI != -1 {
+				t.Errorf("close %d: RSI %v before 14 changes, want -1", i, p.RSI)
+			}
+			continue
+		}
+		if math.Abs(p.RSI-expected) > 0.01 {
+			t.Errorf("close %d: RSI %.4f, want %.2f", i, p.RSI, expected)
+		}
+	}
+}
+
+func TestRSIEdges(t *testing.T) {
+	tests := []struct {
+		name   string
+		prices []float64
+		want   float64
+	}{
+		{"only gains", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, 100},
+		{"only losses", []float64{15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}, 0},
+		{"flat", []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5}, 50},
+		{"one change short", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, "EDGEUSDT")
+			got := feedPrices("EDGEUSDT", tt.prices...)
+			if rsi := got[len(got)-1].RSI; rsi != tt.want {
+				t.Errorf("RSI %v, want %v", rsi, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+/*
+#include "process.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Session reset time of day in UTC, negative when sessions only end on a
+// restart
+var sessionResetAt time.Duration = -1
+
+// Unix ms the session was last reset on request, 0 if it hasn't been.
+// Guarded by stateMu.
+var sessionRequestedAt int64
+
+// parseSessionReset parses an HH:MM UTC time of day
+func parseSessionReset(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("want HH:MM in UTC")
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// sessionStart returns the latest reset at or before now. UTC has no DST,
+// so every day is 24 hours and the reset is always at the same offset
+// from midnight.
+func sessionStart(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	if start.After(now) {
+		start = start.Add(-24 * time.Hour)
+	}
+	return start
+}
+
+// inSession reports whether a trade at t (unix ms) belongs to the current
+// session: one from before the latest reset doesn't, and every trade does
+// while there has been none. Callers hold stateMu.
+func inSession(t int64) bool {
+	if t < sessionRequestedAt {
+		return false
+	}
+	if sessionResetAt < 0 {
+		return true
+	}
+	return t >= sessionStart(time.Now(), sessionResetAt).UnixMilli()
+}
+
+// resetSession clears the session high/low and VWAP of every symbol. A
+// requested reset starts the session now, so backfilled trades from before
+// it stay out as they do for a scheduled one.
+func resetSession(reason string, requested bool) {
+	now := time.Now()
+	stateMu.Lock()
+	C.reset_session()
+	resetVWAPs()
+	if requested {
+		sessionRequestedAt = now.UnixMilli()
+	}
+	stateMu.Unlock()
+
+	if sessionResetAt < 0 {
+		slog.Info("Session reset", "reason", reason)
+		return
+	}
+	slog.Info("Session reset", "reason", reason, "next", sessionStart(now, sessionResetAt).Add(24*time.Hour).Format(time.RFC3339))
+}
+
+// sessionLoop resets the session every day at sessionResetAt. The wait is
+// recomputed after each reset so clock adjustments don't accumulate.
+func sessionLoop() {
+	for {
+		next := sessionStart(time.Now(), sessionResetAt).Add(24 * time.Hour)
+		time.Sleep(time.Until(next))
+		if time.Now().Before(next) {
+			continue // the wall clock moved back
+		}
+		resetSession("scheduled", false)
+	}
+}
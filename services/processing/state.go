@@ -0,0 +1,269 @@
+package main
+
+/*
+#include <stdlib.h>
+#include "process.h"
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/shopspring/decimal"
+)
+
+// How often processor state is written to disk
+const snapshotInterval = 10 * time.Second
+
+// SymbolState is the persisted form of one symbol's processor state, the
+// C++ side's and the session VWAP sums
+type SymbolState struct {
+	Prices     []float64       `json:"prices"`
+	High       float64         `json:"high"`
+	Low        float64         `json:"low"`
+	LastPrice  float64         `json:"last_price"`
+	RSIChanges int             `json:"rsi_changes"`
+	AvgGain    float64         `json:"avg_gain"`
+	AvgLoss    float64         `json:"avg_loss"`
+	EMAs       []EMAState      `json:"emas"`
+	VWAPValue  decimal.Decimal `json:"vwap_pv"` // saved as a string; older snapshots' numbers still load
+	VWAPVolume decimal.Decimal `json:"vwap_qty"`
+	MACD       []EMAState      `json:"macd"` // fast, slow, signal
+}
+
+// EMAState is the persisted form of one exponential moving average
+type EMAState struct {
+	Period  int     `json:"period"`
+	Count   int     `json:"count"`
+	SeedSum float64 `json:"seed_sum"`
+	Value   float64 `json:"value"`
+}
+
+// Snapshot is the on-disk state file
+type Snapshot struct {
+	SavedAt int64                  `json:"saved_at"`
+	Symbols map[string]SymbolState `json:"symbols"`
+}
+
+var (
+	seenSymbols = make(map[string]int64) // time of the newest trade processed
+	seenMu      sync.Mutex
+)
+
+// markSeen records that symbol has processor state worth persisting, last
+// updated by a trade at t (unix ms)
+func markSeen(symbol string, t int64) {
+	seenMu.Lock()
+	if last, ok := seenSymbols[symbol]; !ok || t > last {
+		seenSymbols[symbol] = t
+	}
+	seenMu.Unlock()
+}
+
+// seenUntil returns the time of the newest trade processed for symbol, 0 if
+// there is none
+func seenUntil(symbol string) int64 {
+	seenMu.Lock()
+	defer seenMu.Unlock()
+	return seenSymbols[symbol]
+}
+
+// forgetSeen stops persisting symbol
+func forgetSeen(symbol string) {
+	seenMu.Lock()
+	delete(seenSymbols, symbol)
+	seenMu.Unlock()
+}
+
+// defaultStatePath returns ~/.crypto-analysis/state.json
+func defaultStatePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "state.json"
+	}
+	return filepath.Join(home, ".crypto-analysis", "state.json")
+}
+
+// loadState restores processor state from path, starting fresh if the file
+// is missing or unreadable. It returns when the state was saved, zero when
+// nothing was restored.
+func loadState(path string) time.Time {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		slog.Info("No saved state, starting fresh", "path", path)
+		return time.Time{}
+	}
+	if err != nil {
+		slog.Warn("Failed to read state, starting fresh", "path", path, "err", err)
+		return time.Time{}
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		slog.Warn("Corrupt state, starting fresh", "path", path, "err", err)
+		return time.Time{}
+	}
+
+	for symbol, st := range snap.Symbols {
+		var cs C.ProcessorState
+		n := len(st.Prices)
+		if n > C.PROCESSOR_BUFFER_SIZE {
+			st.Prices = st.Prices[n-C.PROCESSOR_BUFFER_SIZE:]
+			n = C.PROCESSOR_BUFFER_SIZE
+		}
+		for i, p := range st.Prices {
+			cs.prices[i] = C.double(p)
+		}
+		cs.count = C.int(n)
+		cs.high = C.double(st.High)
+		cs.low = C.double(st.Low)
+		cs.last_price = C.double(st.LastPrice)
+		cs.rsi_changes = C.int(st.RSIChanges)
+		cs.avg_gain = C.double(st.AvgGain)
+		cs.avg_loss = C.double(st.AvgLoss)
+		for i, e := range st.EMAs {
+			if i >= C.MAX_EMA_PERIODS {
+				break
+			}
+			cs.emas[i] = toEmaState(e)
+			cs.ema_count = C.int(i + 1)
+		}
+
+		for i, e := range st.MACD {
+			if i >= len(cs.macd) {
+				break
+			}
+			cs.macd[i] = toEmaState(e)
+		}
+
+		sym := C.CString(symbol)
+		C.set_state(sym, &cs)
+		C.free(unsafe.Pointer(sym))
+		setVWAPState(symbol, vwapSums{value: st.VWAPValue, volume: st.VWAPVolume})
+		markSeen(symbol, snap.SavedAt)
+	}
+	slog.Info("Restored state", "symbols", len(snap.Symbols), "path", path)
+	return time.UnixMilli(snap.SavedAt)
+}
+
+// saveState atomically writes processor state for every seen symbol to path
+func saveState(path string) error {
+	data, err := json.Marshal(takeSnapshot())
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so a crash never leaves a partial file
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// takeSnapshot reads back every seen symbol's state. It holds stateMu so
+// no trade or reset lands halfway through.
+func takeSnapshot() Snapshot {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	snap := Snapshot{
+		SavedAt: time.Now().UnixMilli(),
+		Symbols: make(map[string]SymbolState),
+	}
+
+	seenMu.Lock()
+	symbols := make([]string, 0, len(seenSymbols))
+	for symbol := range seenSymbols {
+		symbols = append(symbols, symbol)
+	}
+	seenMu.Unlock()
+
+	for _, symbol := range symbols {
+		var cs C.ProcessorState
+		sym := C.CString(symbol)
+		ok := C.get_state(sym, &cs)
+		C.free(unsafe.Pointer(sym))
+		if ok == 0 {
+			continue
+		}
+
+		sums := vwapState(symbol)
+		st := SymbolState{
+			Prices:     make([]float64, int(cs.count)),
+			High:       float64(cs.high),
+			Low:        float64(cs.low),
+			LastPrice:  float64(cs.last_price),
+			RSIChanges: int(cs.rsi_changes),
+			AvgGain:    float64(cs.avg_gain),
+			AvgLoss:    float64(cs.avg_loss),
+			VWAPValue:  sums.value,
+			VWAPVolume: sums.volume,
+		}
+		for i := range st.Prices {
+			st.Prices[i] = float64(cs.prices[i])
+		}
+		for i := 0; i < int(cs.ema_count); i++ {
+			st.EMAs = append(st.EMAs, fromEmaState(cs.emas[i]))
+		}
+		for _, e := range cs.macd {
+			st.MACD = append(st.MACD, fromEmaState(e))
+		}
+		snap.Symbols[symbol] = st
+	}
+	return snap
+}
+
+// snapshotLoop saves state every snapshotInterval
+func snapshotLoop(path string) {
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := saveState(path); err != nil {
+			slog.Error("State snapshot failed", "err", err)
+		}
+	}
+}
+
+// toEmaState converts a persisted EMA to its C form
+func toEmaState(e EMAState) C.EmaState {
+	return C.EmaState{
+		period:   C.int(e.Period),
+		count:    C.int(e.Count),
+		seed_sum: C.double(e.SeedSum),
+		value:    C.double(e.Value),
+	}
+}
+
+// fromEmaState converts a C EMA to its persisted form
+func fromEmaState(e C.EmaState) EMAState {
+	return EMAState{
+		Period:  int(e.period),
+		Count:   int(e.count),
+		SeedSum: float64(e.seed_sum),
+		Value:   float64(e.value),
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentTradesResetsAndSnapshots hammers processing the way the
+// trades.raw, control.symbol and control.session handlers and the snapshot
+// loop do at once. Run with -race.
+func TestConcurrentTradesResetsAndSnapshots(t *testing.T) {
+	symbols := []string{"AUSDT", "BUSDT", "CUSDT"}
+	for _, sym := range symbols {
+		configure(t, sym)
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var wg sync.WaitGroup
+	run := func(n int, f func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				f(i)
+			}
+		}()
+	}
+	for _, sym := range symbols {
+		run(2000, func(i int) {
+			stateMu.RLock()
+			defer stateMu.RUnlock()
+			if got := processTrade(tradeAt(sym, i)); got.Symbol != sym || !(got.High >= got.Low) {
+				t.Errorf("%s trade %d: %+v", sym, i, got)
+			}
+		})
+	}
+	run(50, func(i int) {
+		stateMu.Lock()
+		resetSymbol(symbols[i%len(symbols)])
+		stateMu.Unlock()
+	})
+	run(50, func(int) { resetSession("test", false) })
+	run(20, func(int) {
+		if err := saveState(path); err != nil {
+			t.Error(err)
+		}
+	})
+	wg.Wait()
+
+	// A reset may have come last; one more trade each brings them back
+	for _, sym := range symbols {
+		processTrade(tradeAt(sym, 0))
+	}
+	if err := saveState(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if time.Since(time.UnixMilli(snap.SavedAt)) > time.Minute {
+		t.Errorf("snapshot saved at %v", time.UnixMilli(snap.SavedAt))
+	}
+	for _, sym := range symbols {
+		st, ok := snap.Symbols[sym]
+		if !ok {
+			t.Errorf("%s missing from the snapshot", sym)
+			continue
+		}
+		if len(st.Prices) == 0 || len(st.Prices) > 50 {
+			t.Errorf("%s: %d prices held, want 1 to 50, the widest window", sym, len(st.Prices))
+		}
+	}
+}
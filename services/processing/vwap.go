@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// vwapSums is a symbol's session VWAP numerator and denominator. They are
+// summed in decimals: prices and quantities arrive as exchange decimals,
+// which float64 sums round a little on every one of millions of ticks.
+type vwapSums struct {
+	value  decimal.Decimal // price times quantity
+	volume decimal.Decimal
+}
+
+var (
+	vwaps  = make(map[string]vwapSums)
+	vwapMu sync.Mutex
+)
+
+// addVWAP folds a session trade into symbol's VWAP; trades without a
+// quantity don't count
+func addVWAP(symbol string, price, quantity float64) {
+	if !(quantity > 0) {
+		return
+	}
+	// NewFromFloat picks the shortest decimal that round-trips, which is
+	// the exchange's own text for any price or quantity it quoted
+	p, q := decimal.NewFromFloat(price), decimal.NewFromFloat(quantity)
+	vwapMu.Lock()
+	defer vwapMu.Unlock()
+	s := vwaps[symbol]
+	s.value = s.value.Add(p.Mul(q))
+	s.volume = s.volume.Add(q)
+	vwaps[symbol] = s
+}
+
+// vwap returns symbol's session VWAP for display, 0 until a trade with
+// quantity
+func vwap(symbol string) float64 {
+	vwapMu.Lock()
+	defer vwapMu.Unlock()
+	s, ok := vwaps[symbol]
+	if !ok || !s.volume.IsPositive() {
+		return 0
+	}
+	v, _ := s.value.DivRound(s.volume, vwapPlaces).Float64()
+	return v
+}
+
+// Decimal places the VWAP division keeps, well past any exchange's tick
+const vwapPlaces = 16
+
+// resetVWAPs clears every symbol's session VWAP
+func resetVWAPs() {
+	vwapMu.Lock()
+	clear(vwaps)
+	vwapMu.Unlock()
+}
+
+// forgetVWAP drops symbol's session VWAP
+func forgetVWAP(symbol string) {
+	vwapMu.Lock()
+	delete(vwaps, symbol)
+	vwapMu.Unlock()
+}
+
+// vwapState returns symbol's sums for a snapshot
+func vwapState(symbol string) vwapSums {
+	vwapMu.Lock()
+	defer vwapMu.Unlock()
+	return vwaps[symbol]
+}
+
+// setVWAPState restores symbol's sums from a snapshot
+func setVWAPState(symbol string, s vwapSums) {
+	vwapMu.Lock()
+	vwaps[symbol] = s
+	vwapMu.Unlock()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// exactVWAP keeps the sums the way the prices and quantities were quoted,
+// as decimals parsed from their text
+type exactVWAP struct {
+	value, volume decimal.Decimal
+}
+
+// trade folds a trade quoted as price and quantity into e, and into
+// symbol's VWAP as the floats an exchange frame decodes to
+func (e *exactVWAP) trade(symbol, price, quantity string) {
+	p, q := decimal.RequireFromString(price), decimal.RequireFromString(quantity)
+	e.value = e.value.Add(p.Mul(q))
+	e.volume = e.volume.Add(q)
+	pf, _ := strconv.ParseFloat(price, 64)
+	qf, _ := strconv.ParseFloat(quantity, 64)
+	addVWAP(symbol, pf, qf)
+}
+
+func (e exactVWAP) vwap() float64 {
+	return e.value.Div(e.volume).InexactFloat64()
+}
+
+func checkVWAP(t *testing.T, symbol string, want exactVWAP) {
+	t.Helper()
+	got := vwapState(symbol)
+	if !got.value.Equal(want.value) || !got.volume.Equal(want.volume) {
+		t.Fatalf("sums %s / %s, want exactly %s / %s", got.value, got.volume, want.value, want.volume)
+	}
+	if v, w := vwap(symbol), want.vwap(); math.Abs(v-w) > 1e-12*w {
+		t.Fatalf("vwap %v, want %v", v, w)
+	}
+}
+
+func TestVWAPManySmallTrades(t *testing.T) {
+	t.Cleanup(func() { forgetVWAP("SMALLUSDT") })
+
+	// A million cent-priced dust trades: float sums of these drift in the
+	// low digits long before the session ends
+	var want exactVWAP
+	for i := range 1_000_000 {
+		want.trade("SMALLUSDT", fmt.Sprintf("0.%02d", 1+i%97), fmt.Sprintf("0.000%d", 1+i%9))
+	}
+	checkVWAP(t, "SMALLUSDT", want)
+}
+
+func TestVWAPMixedScales(t *testing.T) {
+	t.Cleanup(func() { forgetVWAP("MIXEDUSDT") })
+
+	// Dust prices, prices finer than 1e-8 and large ones sum exactly side
+	// by side
+	var want exactVWAP
+	trades := [][2]string{
+		{"0.00001234", "1000000"},
+		{"0.000000001234", "25000000"},
+		{"64123.45", "0.00012"},
+		{"12345678.9", "0.5"},
+		{"0.1", "0.2"},
+	}
+	for range 1000 {
+		for _, tr := range trades {
+			want.trade("MIXEDUSDT", tr[0], tr[1])
+		}
+	}
+	checkVWAP(t, "MIXEDUSDT", want)
+}
+
+func TestVWAPSnapshotRoundTrip(t *testing.T) {
+	t.Cleanup(func() { forgetVWAP("SNAPUSDT") })
+
+	var want exactVWAP
+	for i := range 5000 {
+		want.trade("SNAPUSDT", fmt.Sprintf("100.%03d", i%1000), "0.013")
+	}
+	saved := vwapState("SNAPUSDT")
+	forgetVWAP("SNAPUSDT")
+	setVWAPState("SNAPUSDT", saved)
+	for i := range 5000 {
+		want.trade("SNAPUSDT", fmt.Sprintf("99.%03d", i%1000), "0.007")
+	}
+	checkVWAP(t, "SNAPUSDT", want)
+
+	// Trades without a quantity don't count
+	addVWAP("SNAPUSDT", 1e6, 0)
+	checkVWAP(t, "SNAPUSDT", want)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// asciiGlyphs swaps every non-ASCII glyph the views draw for an ASCII
+// stand-in one cell wide, so layouts measured before the swap still line
+// up. Sparkline blocks become a ramp from _ (lowest) to # (full).
+var asciiGlyphs = strings.NewReplacer(
+	"▁", "_", "▂", ".", "▃", "-", "▄", "=", "▅", "+", "▆", "*", "▇", "%", "█", "#",
+	"─", "-", "━", "=", "│", "|", "┤", "|", "└", "+",
+	"•", "*", "◆", "*", "▲", "^", "▼", "v", "↑", "^", "↓", "v", "▸", ">",
+	"⚠", "!", "⚡", "!", "⏸", "|", "—", "-", "σ", "s", "×", "x",
+	"●", "*", "◌", ".", "○", "o", "⊘", "x", "✖", "X", "…", ".",
+)
+
+// asciiBorder is lipgloss's normal border drawn in ASCII
+var asciiBorder = lipgloss.Border{
+	Top: "-", Bottom: "-", Left: "|", Right: "|",
+	TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+}
+
+// asciify rewrites view content for -ascii, braille chart cells included:
+// blank cells become spaces and any with dots a *
+func asciify(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == 0x2800:
+			return ' '
+		case r > 0x2800 && r <= 0x28ff:
+			return '*'
+		}
+		return r
+	}, asciiGlyphs.Replace(s))
+}
+
+// unicodeTerminal guesses whether the terminal draws Unicode from the
+// locale: LC_ALL, LC_CTYPE or LANG, whichever is set first, must name
+// UTF-8. An unset locale counts as Unicode, as in most containers, but
+// TERM=dumb never does.
+func unicodeTerminal() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := strings.ToLower(os.Getenv(name)); v != "" {
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chart sizing
+const (
+	defaultChartRows = 12 // rows drawn before the terminal size is known
+	minChartRows     = 4
+	chartChrome      = 11 // box border and padding, header, footer and help lines
+)
+
+// brailleDots maps a dot's row (0-3, top down) and column (0-1) within a
+// braille cell to its bit, added to U+2800
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// chartLabelWidth is the width of the widest y-axis label for the history
+func (m model) chartLabelWidth() int {
+	lo, hi := 0.0, 0.0
+	if len(m.history) > 0 {
+		lo, hi = slices.Min(m.history), slices.Max(m.history)
+	}
+	sym := m.data.Symbol
+	// Runes, as fmt pads, since a sign like € is several bytes
+	return max(utf8.RuneCountInString(FormatQuoted(sym, lo)), utf8.RuneCountInString(FormatQuoted(sym, hi)))
+}
+
+// chartCols returns how many braille cells fit beside the y-axis labels
+func (m model) chartCols() int {
+	width := m.width
+	if width == 0 {
+		width = 80
+	}
+	return max(width-boxChrome-m.chartLabelWidth()-2, minSparkWidth)
+}
+
+// chartPoints returns how many points fit across the chart, two per cell
+func (m model) chartPoints() int {
+	return min(2*m.chartCols(), *historyPoints)
+}
+
+// chartRows returns how many rows of braille cells fill the terminal
+func (m model) chartRows() int {
+	if m.height == 0 {
+		return defaultChartRows
+	}
+	return max(m.height-chartChrome, minChartRows)
+}
+
+// renderLineChart plots points as a braille line cols cells wide and rows
+// cells tall between lo and hi, right-aligned so the latest point is at the
+// right edge. Consecutive points are joined by a vertical run of dots.
+func renderLineChart(points []float64, cols, rows int, lo, hi float64) []string {
+	width, height := 2*cols, 4*rows
+	cells := make([][]rune, rows)
+	for r := range cells {
+		cells[r] = make([]rune, cols)
+	}
+
+	// Dot row of a price, 0 at the top
+	dotRow := func(v float64) int {
+		if hi == lo {
+			return height / 2
+		}
+		return height - 1 - int(math.Round((v-lo)/(hi-lo)*float64(height-1)))
+	}
+
+	offset := width - len(points)
+	prev := -1
+	for i, v := range points {
+		x, y := offset+i, dotRow(v)
+		from, to := y, y
+		if prev >= 0 {
+			from, to = min(prev, y), max(prev, y)
+		}
+		for dy := from; dy <= to; dy++ {
+			cells[dy/4][x/2] |= brailleDots[dy%4][x%2]
+		}
+		prev = y
+	}
+
+	lines := make([]string, rows)
+	for r, row := range cells {
+		var line strings.Builder
+		for _, bits := range row {
+			if bits == 0 {
+				line.WriteByte(' ')
+			} else {
+				line.WriteRune(0x2800 + bits)
+			}
+		}
+		lines[r] = line.String()
+	}
+	return lines
+}
+
+// viewChart fills the terminal with a line chart of the primary symbol's
+// price history
+func (m model) viewChart() string {
+	coinName := m.data.CoinName
+	if coinName == "" {
+		coinName = "Crypto"
+	}
+	s := m.theme.Header.Render(fmt.Sprintf("◆ %s Price Chart", coinName)+m.pausedTag()) + "\n\n"
+
+	cols, rows := m.chartCols(), m.chartRows()
+	points := m.history
+	if n := m.chartPoints(); len(points) > n {
+		points = points[len(points)-n:]
+	}
+	if len(points) < 2 {
+		s += m.theme.Label.Render("waiting for data...") + strings.Repeat("\n", rows+1)
+		s += m.theme.Help.Render("\n'g': back to dashboard • 'q': quit")
+		return m.box(s)
+	}
+
+	sym := m.data.Symbol
+	lo, hi := slices.Min(points), slices.Max(points)
+	style := m.theme.Value
+	switch last, first := points[len(points)-1], points[0]; {
+	case last > first:
+		style = m.theme.Up
+	case last < first:
+		style = m.theme.Down
+	}
+
+	labelWidth := m.chartLabelWidth()
+	for r, line := range renderLineChart(points, cols, rows, lo, hi) {
+		label, tick := "", "│"
+		switch r {
+		case 0:
+			label, tick = FormatQuoted(sym, hi), "┤"
+		case rows - 1:
+			label, tick = FormatQuoted(sym, lo), "┤"
+		}
+		s += m.theme.Label.Render(fmt.Sprintf("%*s %s", labelWidth, label, tick)) + style.Render(line) + "\n"
+	}
+	s += m.theme.Label.Render(strings.Repeat(" ", labelWidth+1)+"└"+strings.Repeat("─", cols)) + "\n"
+
+	change := points[len(points)-1] - points[0]
+	s += fmt.Sprintf("%s %s  %s %s",
+		m.theme.Label.Render(fmt.Sprintf("%d ticks, last", len(points))),
+		m.theme.Price.Render(FormatQuoted(sym, points[len(points)-1])),
+		m.theme.Label.Render("change"),
+		m.renderPnL(sym, change, points[0]))
+	if stale := m.staleTag(m.data.Price, m.data.AgeMs); stale != "" {
+		s += "  " + stale
+	}
+	s += m.theme.Help.Render("\n'g': back to dashboard • space: pause • 'q': quit")
+	return m.box(s)
+}
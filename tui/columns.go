@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Width of the coin column every portfolio row starts with
+const coinColumnWidth = 10
+
+// column is one optional portfolio table column: its header, its width,
+// and its cell for a priced coin, right-aligned to the width
+type column struct {
+	title string
+	width int
+	cell  func(m model, coin CoinRow) (string, lipgloss.Style)
+}
+
+// Portfolio columns -columns picks from, by name
+var portfolioColumns = map[string]column{
+	"price": {"Price", 14, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		if isStale(coin.Price, coin.AgeMs) {
+			return FormatQuoted(coin.Symbol, coin.Price), m.theme.Label
+		}
+		return FormatQuoted(coin.Symbol, coin.Price), m.theme.Price
+	}},
+	"change": {"Change", 12, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		switch {
+		case coin.Change > 0:
+			return "▲ +" + formatPriceDelta(coin.Symbol, coin.Change, coin.Price), m.theme.Up
+		case coin.Change < 0:
+			return "▼ " + formatPriceDelta(coin.Symbol, coin.Change, coin.Price), m.theme.Down
+		}
+		return "━ 0.00", m.theme.Label
+	}},
+	"change%": {"Change %", 9, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		prev := coin.Price - coin.Change
+		if !(prev > 0) || coin.Change == 0 {
+			return "0.00%", m.theme.Label
+		}
+		pct := coin.Change / prev * 100
+		if pct > 0 {
+			return fmt.Sprintf("%+.2f%%", pct), m.theme.Up
+		}
+		return fmt.Sprintf("%.2f%%", pct), m.theme.Down
+	}},
+	"ma": {"Moving Avg", 14, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		return FormatQuoted(coin.Symbol, coin.MovingAverage), m.theme.Value
+	}},
+	"high": {"High", 14, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		return FormatQuoted(coin.Symbol, coin.High), m.theme.Up
+	}},
+	"low": {"Low", 14, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		return FormatQuoted(coin.Symbol, coin.Low), m.theme.Down
+	}},
+	"rsi": {"RSI", 6, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		switch rsi := coin.RSI; {
+		case rsi < 0:
+			return placeholder, m.theme.Label
+		case rsi > 70:
+			return fmt.Sprintf("%.1f", rsi), m.theme.Down
+		case rsi < 30:
+			return fmt.Sprintf("%.1f", rsi), m.theme.Up
+		default:
+			return fmt.Sprintf("%.1f", rsi), m.theme.Value
+		}
+	}},
+	"volume": {"Volume", 10, func(m model, coin CoinRow) (string, lipgloss.Style) {
+		if m.tickerOnly() {
+			return placeholder, m.theme.Label
+		}
+		return formatAmount(coin.Volume), m.theme.Value
+	}},
+}
+
+// Names -columns accepts, in the order the help lists them
+var columnNames = []string{"price", "change", "change%", "ma", "high", "low", "rsi", "volume"}
+
+// parseColumns splits a comma-separated column list, rejecting unknown
+// names and repeats
+func parseColumns(list string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := portfolioColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q (want %s)", name, strings.Join(columnNames, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q listed twice", name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no columns")
+	}
+	return names, nil
+}
+
+// visibleColumns returns the -columns that fit the terminal, dropping
+// from the right, and whether any were dropped. Before the terminal size
+// is known every column shows.
+func (m model) visibleColumns() ([]column, bool) {
+	names, _ := parseColumns(*columnList)
+	room := m.width - boxChrome - coinColumnWidth - len(" STALE")
+	var cols []column
+	for _, name := range names {
+		col := portfolioColumns[name]
+		if m.width > 0 && room < col.width+1 {
+			return cols, true
+		}
+		room -= col.width + 1
+		cols = append(cols, col)
+	}
+	return cols, false
+}
+
+// renderPortfolioTable lays out the tracked coins, one row each, in the
+// -columns that fit
+func (m model) renderPortfolioTable() string {
+	cols, cut := m.visibleColumns()
+
+	titles := []string{fmt.Sprintf("%-*s", coinColumnWidth, "Coin")}
+	width := coinColumnWidth
+	for _, col := range cols {
+		titles = append(titles, fmt.Sprintf("%*s", col.width, col.title))
+		width += col.width + 1
+	}
+	if cut {
+		titles = append(titles, "…")
+	}
+	table := m.theme.Label.Render(strings.Join(titles, " ")) + "\n"
+	table += m.theme.Label.Render(strings.Repeat("─", width)) + "\n"
+
+	for _, coin := range m.data.Coins {
+		cells := []string{m.theme.Value.Render(fmt.Sprintf("%-*s", coinColumnWidth, pairLabel(coin.Symbol)))}
+		if !coin.priced() {
+			for _, col := range cols {
+				cells = append(cells, m.theme.Label.Render(fmt.Sprintf("%*s", col.width, placeholder)))
+			}
+			table += strings.Join(cells, " ") + " " + m.theme.Label.Render("initializing") + "\n"
+			continue
+		}
+		for _, col := range cols {
+			text, style := col.cell(m, coin)
+			cells = append(cells, style.Render(fmt.Sprintf("%*s", col.width, text)))
+		}
+		row := strings.Join(cells, " ")
+		if isStale(coin.Price, coin.AgeMs) {
+			row += " " + m.theme.Error.Bold(true).Render("STALE")
+		}
+		table += row + "\n"
+	}
+	return table
+}
+
+// formatAmount shortens a quantity to a few significant digits with a k,
+// M or B suffix, so large volumes fit a column
+func formatAmount(v float64) string {
+	format := numberFormats[*locale]
+	switch {
+	case v >= 1e9:
+		return format.apply(fmt.Sprintf("%.2f", v/1e9)) + "B"
+	case v >= 1e6:
+		return format.apply(fmt.Sprintf("%.2f", v/1e6)) + "M"
+	case v >= 1e4:
+		return format.apply(fmt.Sprintf("%.1f", v/1e3)) + "k"
+	default:
+		return format.apply(fmt.Sprintf("%.3f", v))
+	}
+}
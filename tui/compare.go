@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Gap between the two coin columns, in cells
+const compareGap = 4
+
+// comparedCoins returns the pair of tracked coins the compare view shows.
+// Pairs run in list order, (first, second), (first, third) and so on, and
+// 'n' steps through them.
+func (m model) comparedCoins() (CoinRow, CoinRow, bool) {
+	coins := m.data.Coins
+	if len(coins) < 2 {
+		return CoinRow{}, CoinRow{}, false
+	}
+	k := m.comparePair % (len(coins) * (len(coins) - 1) / 2)
+	for i := range coins {
+		for j := i + 1; j < len(coins); j++ {
+			if k == 0 {
+				return coins[i], coins[j], true
+			}
+			k--
+		}
+	}
+	return CoinRow{}, CoinRow{}, false
+}
+
+// trackRatio records the compared pair's price ratio, starting over when
+// the pair changes
+func (m *model) trackRatio() {
+	a, b, ok := m.comparedCoins()
+	if !ok || !(a.Price > 0) || !(b.Price > 0) {
+		return
+	}
+	if pair := a.Symbol + "/" + b.Symbol; pair != m.ratioPair {
+		m.ratioPair = pair
+		m.ratioHistory = nil
+	}
+	m.ratioHistory = append(m.ratioHistory, a.Price/b.Price)
+	if n := m.sparkWidth(); len(m.ratioHistory) > n {
+		m.ratioHistory = m.ratioHistory[len(m.ratioHistory)-n:]
+	}
+}
+
+// viewCompare shows two tracked coins side by side with the ratio of their
+// prices, which reveals relative strength the absolute prices hide
+func (m model) viewCompare() string {
+	a, b, ok := m.comparedCoins()
+	if !ok || m.data.Error != "" || m.switching {
+		return m.viewDashboard()
+	}
+
+	header := m.theme.Header.Render(fmt.Sprintf("◆ %s vs %s", pairLabel(a.Symbol), pairLabel(b.Symbol)) + m.pausedTag())
+	if banner := m.renderSpikeBanner(); banner != "" {
+		header = banner + "\n" + header
+	}
+
+	colA, colB := m.renderCompareColumn(a), m.renderCompareColumn(b)
+	width := max(lipgloss.Width(colA), lipgloss.Width(colB)) + compareGap
+	columns := lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(width).Render(colA), colB)
+
+	label := fmt.Sprintf("Ratio %s/%s:", coinShort(a.Symbol), coinShort(b.Symbol))
+	ratio := m.theme.Label.Render(label) + " " + m.theme.Label.Render("waiting for prices...")
+	if len(m.ratioHistory) > 0 {
+		latest := m.ratioHistory[len(m.ratioHistory)-1]
+		ratio = m.theme.Label.Render(label) + " " + m.theme.Price.Render(fmt.Sprintf("%.6g", latest))
+		if first := m.ratioHistory[0]; len(m.ratioHistory) > 1 && first > 0 {
+			change := (latest - first) / first * 100
+			switch {
+			case change > 0:
+				ratio += "  " + m.theme.Up.Render(fmt.Sprintf("▲ %+.3f%% (%s stronger)", change, coinShort(a.Symbol)))
+			case change < 0:
+				ratio += "  " + m.theme.Down.Render(fmt.Sprintf("▼ %+.3f%% (%s stronger)", change, coinShort(b.Symbol)))
+			default:
+				ratio += "  " + m.theme.Label.Render("━ flat")
+			}
+		}
+	}
+
+	help := "space: pause • 'x'/esc: back • 'q': quit"
+	if len(m.data.Coins) > 2 {
+		help = "'n': next pair • " + help
+	}
+	content := fmt.Sprintf(
+		"%s\n\n%s\n\n%s\n\n%s\n%s\n\n%s",
+		header,
+		columns,
+		ratio,
+		m.theme.Label.Render("Ratio History:"),
+		m.renderSeries(m.ratioHistory, returnVolatility(m.ratioHistory), 1),
+		m.renderHelp(help),
+	)
+	return m.box(content)
+}
+
+// renderCompareColumn lists one side's price and stats
+func (m model) renderCompareColumn(coin CoinRow) string {
+	sym := coin.Symbol
+	if !coin.priced() {
+		dash := m.theme.Label.Render(placeholder)
+		return strings.Join([]string{
+			m.theme.Value.Bold(true).Render(pairLabel(sym)),
+			dash + "  " + m.theme.Label.Render("initializing"),
+			m.theme.Label.Render("Moving Avg:") + " " + dash,
+			m.theme.Label.Render("High:") + " " + dash,
+			m.theme.Label.Render("Low:") + " " + dash,
+		}, "\n")
+	}
+	change := m.theme.Label.Render("━ 0.00")
+	if coin.Change > 0 {
+		change = m.theme.Up.Render("▲ +" + formatPriceDelta(sym, coin.Change, coin.Price))
+	} else if coin.Change < 0 {
+		change = m.theme.Down.Render("▼ " + formatPriceDelta(sym, coin.Change, coin.Price))
+	}
+
+	price := m.theme.Price.Render(FormatQuoted(sym, coin.Price)) + "  " + change
+	if stale := m.staleTag(coin.Price, coin.AgeMs); stale != "" {
+		price = m.theme.Label.Render(FormatQuoted(sym, coin.Price)) + "  " + stale
+	}
+
+	lines := []string{
+		m.theme.Value.Bold(true).Render(pairLabel(sym)),
+		price,
+		m.theme.Label.Render("Moving Avg:") + " " + m.theme.Value.Render(FormatQuoted(sym, coin.MovingAverage)),
+		m.theme.Label.Render("High:") + " " + m.theme.Up.Render(FormatQuoted(sym, coin.High)),
+		m.theme.Label.Render("Low:") + " " + m.theme.Down.Render(FormatQuoted(sym, coin.Low)),
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds defaults for the command-line options; flags given on the
+// command line override it
+type Config struct {
+	Symbols     []string `yaml:"symbols"`
+	Refresh     string   `yaml:"refresh"`
+	MAWindow    int      `yaml:"ma_window"`
+	Alerts      []string `yaml:"alerts"`
+	Headless    bool     `yaml:"headless"`
+	ASCII       bool     `yaml:"ascii"`
+	SparkColors string   `yaml:"spark_colors"`
+	APIToken    string   `yaml:"api_token"`
+	Theme       string   `yaml:"theme"`
+	Locale      string   `yaml:"locale"`
+	Unit        string   `yaml:"unit"`
+	Columns     []string `yaml:"columns"`
+	Indicators  []string `yaml:"indicators"`
+	TradeQty    float64  `yaml:"trade_qty"`
+	Port        int      `yaml:"port"`
+	LogLevel    string   `yaml:"log_level"`
+	LogFile     string   `yaml:"log_file"`
+	Stale       string   `yaml:"stale"`
+	SparkPoints int      `yaml:"spark_points"`
+	History     int      `yaml:"history"`
+
+	Notify NotifyConfig `yaml:"notify"` // where fired alerts go
+}
+
+// NotifyConfig selects the backends fired alerts go to, and their settings
+type NotifyConfig struct {
+	Backends      []string `yaml:"backends"`
+	Webhook       string   `yaml:"webhook"`
+	Slack         string   `yaml:"slack"`
+	TelegramToken string   `yaml:"telegram_token"`
+	TelegramChat  string   `yaml:"telegram_chat"`
+}
+
+// defaultConfigPath returns ~/.crypto-analysis/config.yaml
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "config.yaml"
+	}
+	return filepath.Join(home, ".crypto-analysis", "config.yaml")
+}
+
+// loadConfig reads and validates the config at path. A missing file is
+// only an error when required is set.
+func loadConfig(path string, required bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// loadSettings applies the config at path over the flag defaults, leaving
+// the flags in cmdline alone. Without a config every other flag takes its
+// default; a missing file is only an error when required is set.
+func loadSettings(path string, required bool, cmdline map[string]bool) error {
+	cfg, err := loadConfig(path, required)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	return cfg.apply(cmdline)
+}
+
+// validate reports the first invalid value
+func (c *Config) validate() error {
+	for _, s := range c.Symbols {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("symbols: empty symbol")
+		}
+	}
+	if c.Refresh != "" {
+		d, err := time.ParseDuration(c.Refresh)
+		if err != nil {
+			return fmt.Errorf("refresh: %w", err)
+		}
+		if d < minRefresh {
+			return fmt.Errorf("refresh: must be at least %v", minRefresh)
+		}
+	}
+	if c.Stale != "" {
+		d, err := time.ParseDuration(c.Stale)
+		if err != nil {
+			return fmt.Errorf("stale: %w", err)
+		}
+		if d < 0 {
+			return fmt.Errorf("stale: must not be negative")
+		}
+	}
+	if c.MAWindow < 0 {
+		return fmt.Errorf("ma_window: must not be negative")
+	}
+	if c.SparkPoints < 0 {
+		return fmt.Errorf("spark_points: must not be negative")
+	}
+	if c.History < 0 || c.History == 1 {
+		return fmt.Errorf("history: must be at least 2")
+	}
+	for _, rule := range c.Alerts {
+		if err := validateAlertRule(rule); err != nil {
+			return fmt.Errorf("alerts: %w", err)
+		}
+	}
+	if c.SparkColors != "" && c.SparkColors != "volatility" && c.SparkColors != "direction" {
+		return fmt.Errorf("spark_colors: must be volatility or direction")
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("port: must be 1 to 65535")
+	}
+	if c.TradeQty < 0 {
+		return fmt.Errorf("trade_qty: must not be negative")
+	}
+	if c.Theme != "" {
+		if _, err := themeByName(c.Theme); err != nil {
+			return fmt.Errorf("theme: %w", err)
+		}
+	}
+	if _, ok := numberFormats[c.Locale]; c.Locale != "" && !ok {
+		return fmt.Errorf("locale: must be plain, en, de, fr or ch")
+	}
+	if len(c.Columns) > 0 {
+		if _, err := parseColumns(strings.Join(c.Columns, ",")); err != nil {
+			return fmt.Errorf("columns: %w", err)
+		}
+	}
+	if _, err := parseIndicators(strings.Join(c.Indicators, ",")); err != nil {
+		return fmt.Errorf("indicators: %w", err)
+	}
+	if !validUnit(strings.ToLower(c.Unit)) {
+		return fmt.Errorf("unit: must be sats or a symbol like btcusdt")
+	}
+	if err := validateNotify(strings.Join(c.Notify.Backends, ","), c.Notify.Webhook, c.Notify.Slack, c.Notify.TelegramToken, c.Notify.TelegramChat); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	if c.LogLevel != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+			return fmt.Errorf("log_level: must be debug, info, warn or error")
+		}
+	}
+	return nil
+}
+
+// validateAlertRule checks a rule has the symbol>price or symbol<price
+// form the API accepts
+func validateAlertRule(rule string) error {
+	parts := strings.SplitN(rule, ">", 2)
+	if len(parts) != 2 {
+		parts = strings.SplitN(rule, "<", 2)
+	}
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return fmt.Errorf("alert %q must look like symbol>price or symbol<price", rule)
+	}
+	if price, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil || price <= 0 {
+		return fmt.Errorf("alert %q has an invalid price", rule)
+	}
+	return nil
+}
+
+// commandLineFlags returns the names of the flags given on the command
+// line, which the config never overrides
+func commandLineFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// apply sets every flag not in cmdline from the config, or back to its
+// default where the config leaves it out, so a reload also drops removed
+// settings
+func (c *Config) apply(cmdline map[string]bool) error {
+	values := map[string]string{
+		"symbol":       strings.Join(c.Symbols, ","),
+		"refresh":      c.Refresh,
+		"ma-window":    positive(c.MAWindow),
+		"spark-colors": c.SparkColors,
+		"api-token":    c.APIToken,
+		"theme":        c.Theme,
+		"locale":       c.Locale,
+		"unit":         strings.ToLower(c.Unit),
+		"columns":      strings.Join(c.Columns, ","),
+		"indicators":   strings.Join(c.Indicators, ","),
+		"port":         positive(c.Port),
+		"log-level":    c.LogLevel,
+		"log-file":     c.LogFile,
+		"stale":        c.Stale,
+		"spark-points": positive(c.SparkPoints),
+		"history":      positive(c.History),
+		"trade-qty":    "",
+		"headless":     "",
+		"ascii":        "",
+	}
+	if c.TradeQty > 0 {
+		values["trade-qty"] = strconv.FormatFloat(c.TradeQty, 'f', -1, 64)
+	}
+	if c.Headless {
+		values["headless"] = "true"
+	}
+	if c.ASCII {
+		values["ascii"] = "true"
+	}
+	values["notify"] = strings.Join(c.Notify.Backends, ",")
+	values["notify-webhook"] = c.Notify.Webhook
+	values["notify-slack"] = c.Notify.Slack
+	values["notify-telegram-token"] = c.Notify.TelegramToken
+	values["notify-telegram-chat"] = c.Notify.TelegramChat
+
+	for name, value := range values {
+		if cmdline[name] {
+			continue
+		}
+		if value == "" {
+			value = flag.Lookup(name).DefValue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if !cmdline["alert"] {
+		alertRules = append(stringList(nil), c.Alerts...)
+	}
+	return nil
+}
+
+// positive formats n, or nothing when it is not positive
+func positive(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// flagValues returns every flag's current value by name
+func flagValues() map[string]string {
+	values := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) { values[f.Name] = f.Value.String() })
+	return values
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exchangeMsg names the exchange the ingestion service streams from
+type exchangeMsg string
+
+// fetchExchange asks the API which exchange feeds it, for the confirmation
+// screen before any dashboard data has been fetched
+func fetchExchange() tea.Cmd {
+	return func() tea.Msg {
+		resp, err := http.Get(serverURL + "/api/status")
+		if err != nil {
+			return exchangeMsg("")
+		}
+		defer resp.Body.Close()
+		var status StatusResponse
+		json.NewDecoder(resp.Body).Decode(&status)
+		return exchangeMsg(status.Exchange)
+	}
+}
+
+// viewConfirm summarizes what is about to be tracked, and how, before the
+// symbols are sent to the API
+func (m model) viewConfirm() string {
+	s := m.theme.Header.Render("Confirm Selection") + "\n\n"
+
+	names := make([]string, len(m.pending))
+	for i, symbol := range m.pending {
+		names[i] = m.coinName(symbol)
+	}
+	label := "Coin:"
+	if len(names) > 1 {
+		label = "Coins:"
+	}
+
+	exchange := m.theme.Label.Render("unknown")
+	if m.data.Exchange != "" {
+		exchange = m.theme.Value.Render(strings.ToUpper(m.data.Exchange[:1]) + m.data.Exchange[1:])
+	}
+	window := m.theme.Value.Render(fmt.Sprintf("%d", *maWindow))
+	if *maWindow == 0 {
+		window = m.theme.Label.Render("the processor's primary window")
+	}
+	stale := m.theme.Value.Render(staleAfter.String())
+	if *staleAfter <= 0 {
+		stale = m.theme.Label.Render("off")
+	}
+
+	rows := [][2]string{
+		{label, m.theme.Value.Render(strings.Join(names, ", "))},
+		{"Exchange:", exchange},
+		{"MA window:", window},
+		{"Refresh:", m.theme.Value.Render(refresh.String())},
+		{"Stale after:", stale},
+	}
+	for _, row := range rows {
+		s += m.theme.Label.Render(fmt.Sprintf("%-13s", row[0])) + row[1] + "\n"
+	}
+
+	if m.switching {
+		s += "\n" + m.theme.Label.Render("Starting...")
+		return m.box(s)
+	}
+	s += m.theme.Help.Render("\nenter: start • esc: back to selection • q: cancel")
+	return m.box(s)
+}
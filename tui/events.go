@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Events the event panel keeps, newest on top; older ones are evicted
+const eventRows = 10
+
+// event is one log record as the event panel shows it
+type event struct {
+	time  time.Time
+	level slog.Level
+	text  string // the message and its attributes
+}
+
+// eventLog keeps the latest info-and-above log records, whatever -log-level
+// lets through to the log itself
+type eventLog struct {
+	mu     sync.Mutex
+	events []event // oldest first, at most eventRows
+}
+
+var events eventLog
+
+func (l *eventLog) add(e event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+	if len(l.events) > eventRows {
+		l.events = append(l.events[:0], l.events[len(l.events)-eventRows:]...)
+	}
+}
+
+// recent returns the kept events, newest first
+func (l *eventLog) recent() []event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := make([]event, len(l.events))
+	for i, e := range l.events {
+		list[len(list)-1-i] = e
+	}
+	return list
+}
+
+// eventHandler hands records to the log's handler and copies those at info
+// and above into events
+type eventHandler struct {
+	next  slog.Handler
+	attrs []slog.Attr // from WithAttrs, shown before each record's own
+}
+
+func (h *eventHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo || h.next.Enabled(ctx, level)
+}
+
+func (h *eventHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelInfo {
+		parts := []string{r.Message}
+		add := func(a slog.Attr) bool {
+			parts = append(parts, fmt.Sprintf("%s=%v", a.Key, a.Value))
+			return true
+		}
+		for _, a := range h.attrs {
+			add(a)
+		}
+		r.Attrs(add)
+		events.add(event{time: r.Time, level: r.Level, text: strings.Join(parts, " ")})
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *eventHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &eventHandler{next: h.next.WithAttrs(attrs), attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)}
+}
+
+// WithGroup only groups the log's attributes; the panel shows keys bare
+func (h *eventHandler) WithGroup(name string) slog.Handler {
+	return &eventHandler{next: h.next.WithGroup(name), attrs: h.attrs}
+}
+
+// renderEvents lists the latest alerts, feed changes and other notable log
+// records with when they happened
+func (m model) renderEvents() string {
+	title := m.theme.Label.Render("Events:")
+	list := events.recent()
+	if len(list) == 0 {
+		return title + " " + m.theme.Label.Render("nothing yet")
+	}
+
+	lines := []string{title}
+	for _, e := range list {
+		style := m.theme.Value
+		switch {
+		case e.level >= slog.LevelError:
+			style = m.theme.Error
+		case e.level >= slog.LevelWarn:
+			style = m.theme.Price
+		}
+		lines = append(lines, m.theme.Time.Render(e.time.Local().Format("15:04:05"))+" "+style.Render(e.text))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var exportIndicators = flag.Bool("export-indicators", false, "add the processor's indicators at each trade (MAs, EMAs, RSI, MACD, Bollinger, VWAP) as extra CSV columns to -export and 'e' exports")
+
+// Trades requested per export, the API's largest HISTORY_SIZE; it caps
+// requests at its own
+const exportLimit = 100000
+
+// exportedMsg reports the files written by an export
+type exportedMsg struct {
+	paths []string
+	err   error
+}
+
+// defaultExportPath names an export after the symbol and current time
+func defaultExportPath(symbol string) string {
+	return fmt.Sprintf("%s-%s.csv", symbol, time.Now().Format("20060102-150405"))
+}
+
+// exportSymbols writes each symbol's history to its own CSV file in the
+// background so the dashboard keeps redrawing
+func exportSymbols(symbols []string) tea.Cmd {
+	return func() tea.Msg {
+		var paths []string
+		for _, symbol := range symbols {
+			path := defaultExportPath(symbol)
+			if _, err := exportHistory(symbol, path); err != nil {
+				return exportedMsg{paths: paths, err: err}
+			}
+			paths = append(paths, path)
+		}
+		return exportedMsg{paths: paths}
+	}
+}
+
+// exportHistory fetches the API's in-memory trade history for symbol (the
+// primary one when empty) and writes it oldest first to path as CSV with
+// timestamp, price and volume columns, then the indicator columns with
+// -export-indicators. It returns the number of rows.
+func exportHistory(symbol, path string) (int, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(exportLimit))
+	query.Set("source", "memory")
+	if *exportIndicators {
+		query.Set("indicators", "true")
+	}
+	if symbol != "" {
+		query.Set("symbol", symbol)
+	}
+
+	resp, err := http.Get(serverURL + "/api/history?" + query.Encode())
+	if err != nil {
+		return 0, fmt.Errorf("server not running")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("history request failed: %s", resp.Status)
+	}
+
+	var trades []HistoryTrade
+	if err := json.NewDecoder(resp.Body).Decode(&trades); err != nil {
+		return 0, fmt.Errorf("decoding history: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var columns indicatorColumns
+	if *exportIndicators {
+		columns = newIndicatorColumns(trades)
+	}
+
+	// csv.Writer buffers, so rows go to the file as they are written
+	w := csv.NewWriter(f)
+	w.Write(append([]string{"timestamp", "price", "volume"}, columns.header()...))
+	for i := len(trades) - 1; i >= 0; i-- {
+		t := trades[i]
+		volume := ""
+		if t.Quantity > 0 {
+			volume = strconv.FormatFloat(t.Quantity, 'f', -1, 64)
+		}
+		w.Write(append([]string{
+			t.Timestamp.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(t.Price, 'f', -1, 64),
+			volume,
+		}, columns.row(t.Indicators)...))
+	}
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return len(trades), nil
+}
+
+// indicatorColumns lays out an indicator export: a column per MA window
+// and EMA period any exported trade has, then RSI, MACD, Bollinger and VWAP.
+// The zero value adds no columns.
+type indicatorColumns struct {
+	enabled          bool
+	windows, periods []int
+}
+
+func newIndicatorColumns(trades []HistoryTrade) indicatorColumns {
+	mas, emas := map[string]float64{}, map[string]float64{}
+	for _, t := range trades {
+		if t.Indicators == nil {
+			continue
+		}
+		for key := range t.Indicators.MovingAverages {
+			mas[key] = 0
+		}
+		for key := range t.Indicators.EMAs {
+			emas[key] = 0
+		}
+	}
+	return indicatorColumns{enabled: true, windows: sortedWindows(mas), periods: sortedWindows(emas)}
+}
+
+func (c indicatorColumns) header() []string {
+	if !c.enabled {
+		return nil
+	}
+	var header []string
+	for _, w := range c.windows {
+		header = append(header, fmt.Sprintf("ma_%d", w))
+	}
+	for _, p := range c.periods {
+		header = append(header, fmt.Sprintf("ema_%d", p))
+	}
+	return append(header, "rsi", "macd", "macd_signal", "macd_histogram", "bb_upper", "bb_middle", "bb_lower", "vwap")
+}
+
+// row formats one trade's indicators in header order, leaving indicators
+// that weren't ready yet (or a trade without any) empty
+func (c indicatorColumns) row(ind *TradeIndicators) []string {
+	if !c.enabled {
+		return nil
+	}
+	if ind == nil {
+		ind = &TradeIndicators{RSI: -1}
+	}
+	cell := func(v float64, ready bool) string {
+		if !ready {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	var row []string
+	for _, w := range c.windows {
+		v, ok := ind.MovingAverages[strconv.Itoa(w)]
+		row = append(row, cell(v, ok && v > 0))
+	}
+	for _, p := range c.periods {
+		v, ok := ind.EMAs[strconv.Itoa(p)]
+		row = append(row, cell(v, ok && v >= 0))
+	}
+	row = append(row, cell(ind.RSI, ind.RSI >= 0))
+	if macd := ind.MACD; macd != nil {
+		row = append(row, cell(macd.MACD, true), cell(macd.Signal, true), cell(macd.Histogram, true))
+	} else {
+		row = append(row, "", "", "")
+	}
+	if bb := ind.Bollinger; bb != nil {
+		row = append(row, cell(bb.Upper, true), cell(bb.Middle, true), cell(bb.Lower, true))
+	} else {
+		row = append(row, "", "", "")
+	}
+	return append(row, cell(ind.VWAP, ind.VWAP > 0))
+}
+
+// exportStatus describes an export result for the dashboard
+func exportStatus(msg exportedMsg) string {
+	if msg.err != nil {
+		return "Export failed: " + msg.err.Error()
+	}
+	return "Exported " + strings.Join(msg.paths, ", ")
+}
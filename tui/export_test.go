@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// historyServer points serverURL at a fake API whose /api/history returns
+// trades (newest first, as the API does) and records the query it got
+func historyServer(t *testing.T, trades []HistoryTrade) *http.Request {
+	t.Helper()
+	got := new(http.Request)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/history" {
+			http.NotFound(w, r)
+			return
+		}
+		*got = *r
+		json.NewEncoder(w).Encode(trades)
+	}))
+	t.Cleanup(srv.Close)
+
+	old := serverURL
+	serverURL = srv.URL
+	t.Cleanup(func() { serverURL = old })
+	return got
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	trades := []HistoryTrade{
+		{Symbol: "BTCUSDT", Price: 64123.45, Quantity: 0.00012, Timestamp: base.Add(2 * time.Second)},
+		{Symbol: "BTCUSDT", Price: 0.1 + 0.2, Quantity: 0, Timestamp: base.Add(time.Second)},
+		{Symbol: "BTCUSDT", Price: 64000, Quantity: 1.5, Timestamp: base.In(time.FixedZone("EST", -5*3600))},
+	}
+	req := historyServer(t, trades)
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	n, err := exportHistory("BTCUSDT", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(trades) {
+		t.Fatalf("exported %d rows, want %d", n, len(trades))
+	}
+	q := req.URL.Query()
+	if q.Get("symbol") != "BTCUSDT" || q.Get("source") != "memory" || q.Get("indicators") != "" {
+		t.Errorf("history query = %v", q)
+	}
+
+	rows := readCSV(t, path)
+	if want := []string{"timestamp", "price", "volume"}; !reflect.DeepEqual(rows[0], want) {
+		t.Fatalf("header = %v, want %v", rows[0], want)
+	}
+	if len(rows)-1 != len(trades) {
+		t.Fatalf("%d data rows, want %d", len(rows)-1, len(trades))
+	}
+	// Rows are oldest first and parse back to exactly what the API sent
+	for i, row := range rows[1:] {
+		want := trades[len(trades)-1-i]
+		ts, err := time.Parse(time.RFC3339Nano, row[0])
+		if err != nil || !ts.Equal(want.Timestamp) {
+			t.Errorf("row %d timestamp %q, want %v", i, row[0], want.Timestamp)
+		}
+		if price, err := strconv.ParseFloat(row[1], 64); err != nil || price != want.Price {
+			t.Errorf("row %d price %q, want %v", i, row[1], want.Price)
+		}
+		switch {
+		case want.Quantity == 0 && row[2] != "":
+			t.Errorf("row %d volume %q, want empty for an unknown quantity", i, row[2])
+		case want.Quantity > 0:
+			if v, err := strconv.ParseFloat(row[2], 64); err != nil || v != want.Quantity {
+				t.Errorf("row %d volume %q, want %v", i, row[2], want.Quantity)
+			}
+		}
+	}
+}
+
+func TestExportIndicatorColumns(t *testing.T) {
+	defer func(v bool) { *exportIndicators = v }(*exportIndicators)
+	*exportIndicators = true
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	trades := []HistoryTrade{
+		{Price: 101, Timestamp: now.Add(time.Second), Indicators: &TradeIndicators{
+			MovingAverages: map[string]float64{"20": 100.5, "50": 99.25},
+			EMAs:           map[string]float64{"12": 100.75},
+			RSI:            55.5,
+			VWAP:           100.1,
+			MACD:           &MACDInfo{MACD: 0.5, Signal: 0.25, Histogram: 0.25},
+			Bollinger:      &BollingerInfo{Upper: 103, Middle: 100.5, Lower: 98},
+		}},
+		{Price: 100, Timestamp: now, Indicators: &TradeIndicators{
+			MovingAverages: map[string]float64{"20": 100},
+			EMAs:           map[string]float64{"12": -1},
+			RSI:            -1,
+		}},
+		{Price: 99, Timestamp: now.Add(-time.Second)},
+	}
+	req := historyServer(t, trades)
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if _, err := exportHistory("", path); err != nil {
+		t.Fatal(err)
+	}
+	if q := req.URL.Query(); q.Get("indicators") != "true" || q.Has("symbol") {
+		t.Errorf("history query = %v", q)
+	}
+
+	want := [][]string{
+		{"timestamp", "price", "volume", "ma_20", "ma_50", "ema_12", "rsi", "macd", "macd_signal", "macd_histogram", "bb_upper", "bb_middle", "bb_lower", "vwap"},
+		// No indicators at all, then ones still warming up: both stay empty
+		{"2026-03-01T11:59:59Z", "99", "", "", "", "", "", "", "", "", "", "", "", ""},
+		{"2026-03-01T12:00:00Z", "100", "", "100", "", "", "", "", "", "", "", "", "", ""},
+		{"2026-03-01T12:00:01Z", "101", "", "100.5", "99.25", "100.75", "55.5", "0.5", "0.25", "0.25", "103", "100.5", "98", "100.1"},
+	}
+	if rows := readCSV(t, path); !reflect.DeepEqual(rows, want) {
+		t.Fatalf("export =\n%v\nwant\n%v", rows, want)
+	}
+}
+
+func TestExportHistoryError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown symbol", http.StatusNotFound)
+	}))
+	defer srv.Close()
+	defer func(old string) { serverURL = old }(serverURL)
+	serverURL = srv.URL
+
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if _, err := exportHistory("NOPE", path); err == nil {
+		t.Fatal("export of a failed request succeeded")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("failed export left %s behind", path)
+	}
+}
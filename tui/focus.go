@@ -0,0 +1,51 @@
+package main
+
+import "fmt"
+
+// cycleFocus steps step places through the portfolio table followed by
+// each tracked coin, wrapping around at either end
+func (m *model) cycleFocus(step int) {
+	n := len(m.data.Coins)
+	if n < 2 {
+		return
+	}
+	pos := 0 // the table
+	for i, coin := range m.data.Coins {
+		if coin.Symbol == m.focus {
+			pos = i + 1
+		}
+	}
+	pos = ((pos+step)%(n+1) + n + 1) % (n + 1)
+
+	m.focus = ""
+	if pos > 0 {
+		m.focus = m.data.Coins[pos-1].Symbol
+	}
+}
+
+// focusTag shows which tracked coin the dashboard is on, e.g. "2/4"
+func (m model) focusTag() string {
+	if m.focus == "" {
+		return ""
+	}
+	for i, coin := range m.data.Coins {
+		if coin.Symbol == m.data.Symbol {
+			return fmt.Sprintf("  %d/%d", i+1, len(m.data.Coins))
+		}
+	}
+	return ""
+}
+
+// coinName returns the display name of symbol from the coin list, falling
+// back to its pair label
+func (m model) coinName(symbol string) string {
+	for _, coin := range m.coins {
+		if coin.Symbol == symbol {
+			return coin.Name
+		}
+	}
+	if symbol == "" {
+		return "Crypto"
+	}
+	return pairLabel(symbol)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// parseSymbols splits a comma-separated symbol list
+func parseSymbols(value string) []string {
+	var symbols []string
+	for _, s := range strings.Split(value, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// runHeadless tracks symbols and prints a status line per refresh to
+// stdout until SIGINT/SIGTERM, for use under Docker or systemd without a
+// TTY. Everything else goes to the structured log. SIGHUP calls reload to
+// re-read the config and applies what changed.
+func runHeadless(symbols []string, reload func() error) error {
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+
+	if err := postSymbols(symbols); err != nil {
+		return err
+	}
+	alertIDs := make(map[string]int)
+	for _, rule := range alertRules {
+		if _, ok := alertIDs[rule]; !ok {
+			alertIDs[rule] = registerAlert(rule)
+		}
+	}
+	slog.Info("Tracking (headless)", "symbols", symbols)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	ticker := time.NewTicker(*refresh)
+	defer ticker.Stop()
+
+	lastState := ""
+	notified := make(map[int]int)
+	stale := make(map[string]bool)
+	checkStale := func(symbol string, price float64, ageMs int64) {
+		now := isStale(price, ageMs)
+		if now && !stale[symbol] {
+			slog.Warn("Price stale", "symbol", symbol, "age_ms", ageMs)
+		} else if !now && stale[symbol] {
+			slog.Info("Price live again", "symbol", symbol)
+		}
+		stale[symbol] = now
+	}
+	for {
+		select {
+		case <-sig:
+			slog.Info("Shutting down")
+			return nil
+		case <-hup:
+			before, rules := flagValues(), append([]string(nil), alertRules...)
+			if err := reload(); err != nil {
+				slog.Error("Config reload failed", "err", err)
+				continue
+			}
+			applyReload(before, rules, alertIDs, ticker)
+			continue
+		case <-ticker.C:
+		}
+
+		data := DashboardData(fetchData("")().(dataMsg))
+		if data.Error != "" {
+			slog.Warn("Fetch failed", "err", data.Error)
+			continue
+		}
+		if data.FeedState != lastState {
+			slog.Info("Feed state", "exchange", feedLabel(data.Exchange), "state", data.FeedState)
+			lastState = data.FeedState
+			if backends := notifiers(); data.FeedState == "failed" && len(backends) > 0 {
+				go dispatchNotice(backends, newFeedNotice(data.Symbol, data.Exchange))
+			}
+		}
+		for _, a := range data.Alerts {
+			if a.Fires > notified[a.ID] {
+				notified[a.ID] = a.Fires
+				logAlertFired(a)
+				if backends := notifiers(); len(backends) > 0 {
+					go dispatchAlert(backends, a)
+				}
+			}
+		}
+
+		if len(data.Coins) > 1 {
+			for _, coin := range data.Coins {
+				checkStale(coin.Symbol, coin.Price, coin.AgeMs)
+				logger.Println(formatHeadlessLine(coin.Symbol, coin.Price, coin.MovingAverage, coin.High, coin.Low))
+			}
+			continue
+		}
+		checkStale(data.Symbol, data.Price, data.AgeMs)
+		if data.Price > 0 {
+			logger.Println(formatHeadlessLine(data.Symbol, data.Price, data.MovingAverage, data.High, data.Low))
+		}
+	}
+}
+
+// Settings a reload applies at once, in order: the API address and token
+// first, since applying the symbols and alerts calls the API
+var liveSettings = []string{"port", "api-token", "log-level", "refresh", "ma-window", "stale", "locale", "unit", "symbol", "alert",
+	"notify", "notify-webhook", "notify-slack", "notify-telegram-token", "notify-telegram-chat"}
+
+// applyReload acts on the flags a config reload changed from before, with
+// rules the alert rules before it and alertIDs the API's alert for each
+// registered rule. Symbols go through the API, which reconnects ingestion;
+// settings the headless loop doesn't read live wait for a restart.
+func applyReload(before map[string]string, rules []string, alertIDs map[string]int, ticker *time.Ticker) {
+	after := flagValues()
+	changed := false
+	for _, name := range liveSettings {
+		if after[name] == before[name] {
+			continue
+		}
+		changed = true
+		from, to := before[name], after[name]
+		switch name {
+		case "port":
+			serverURL = fmt.Sprintf("http://localhost:%d", *apiPort)
+		case "notify-webhook", "notify-slack", "notify-telegram-token":
+			from, to = "(hidden)", "(hidden)"
+		case "api-token":
+			from, to = "(hidden)", "(hidden)"
+			http.DefaultClient.Transport = nil
+			if *apiToken != "" {
+				http.DefaultClient.Transport = tokenTransport{token: *apiToken, base: http.DefaultTransport}
+			}
+		case "log-level":
+			var level slog.Level
+			level.UnmarshalText([]byte(*logLevel))
+			minLevel.Set(level)
+		case "refresh":
+			ticker.Reset(*refresh)
+		case "symbol":
+			symbols := parseSymbols(*symbolFlag)
+			if len(symbols) == 0 {
+				slog.Warn("Config reload left no symbols, keeping the current ones")
+				flag.Set("symbol", from)
+				continue
+			}
+			if err := postSymbols(symbols); err != nil {
+				slog.Warn("Symbol change failed", "symbols", symbols, "err", err)
+				flag.Set("symbol", from)
+				continue
+			}
+		case "alert":
+			syncAlerts(rules, alertIDs)
+		}
+		slog.Info("Config changed", "setting", name, "from", from, "to", to)
+	}
+
+	names := make([]string, 0, len(after))
+	for name := range after {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if after[name] != before[name] && !slices.Contains(liveSettings, name) {
+			changed = true
+			slog.Warn("Config change needs a restart", "setting", name)
+		}
+	}
+	if !changed {
+		slog.Info("Config reloaded, nothing changed")
+	}
+}
+
+// syncAlerts registers alert rules added by a reload and removes the API
+// alerts of rules it dropped
+func syncAlerts(before []string, alertIDs map[string]int) {
+	for _, rule := range before {
+		if !slices.Contains(alertRules, rule) {
+			if id := alertIDs[rule]; id >= 0 {
+				removeAlert(rule, id)
+			}
+			delete(alertIDs, rule)
+		}
+	}
+	for _, rule := range alertRules {
+		if _, ok := alertIDs[rule]; !ok {
+			alertIDs[rule] = registerAlert(rule)
+		}
+	}
+}
+
+// formatHeadlessLine renders one status line
+func formatHeadlessLine(symbol string, price, ma, high, low float64) string {
+	return fmt.Sprintf("%-10s price=%s ma=%s high=%s low=%s", symbol,
+		FormatPrice(symbol, price), FormatPrice(symbol, ma), FormatPrice(symbol, high), FormatPrice(symbol, low))
+}
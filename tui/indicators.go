@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+// Indicators the stats panel can show, in its order, with the menu's
+// description of each
+var indicatorNames = []string{"signals", "ma", "vwap", "24h", "rsi", "macd", "bollinger", "atr"}
+
+var indicatorTitles = map[string]string{
+	"signals":   "Signals (RSI and MACD summed up)",
+	"ma":        "Moving averages and EMAs",
+	"vwap":      "Session VWAP",
+	"24h":       "Rolling 24h range",
+	"rsi":       "RSI (14)",
+	"macd":      "MACD (12,26,9)",
+	"bollinger": "Bollinger Bands",
+	"atr":       "ATR",
+}
+
+// parseIndicators splits a comma-separated indicator list into a set,
+// rejecting unknown names; "none" selects nothing
+func parseIndicators(list string) (map[string]bool, error) {
+	set := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" {
+			continue
+		}
+		if _, ok := indicatorTitles[name]; !ok {
+			return nil, fmt.Errorf("unknown indicator %q (want %s or none)", name, strings.Join(indicatorNames, ", "))
+		}
+		set[name] = true
+	}
+	return set, nil
+}
+
+// indicatorSet is -indicators, parsed once as it is set: the indicators
+// the stats panel shows
+type indicatorSet map[string]bool
+
+// allIndicators selects every indicator, -indicators' default
+func allIndicators() indicatorSet {
+	set := make(indicatorSet, len(indicatorNames))
+	for _, name := range indicatorNames {
+		set[name] = true
+	}
+	return set
+}
+
+func (s *indicatorSet) String() string { return strings.Join(formatIndicators(*s), ",") }
+
+func (s *indicatorSet) Set(list string) error {
+	set, err := parseIndicators(list)
+	if err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
+
+// formatIndicators lists a selection as -indicators takes it, in the stats
+// panel's order
+func formatIndicators(set map[string]bool) []string {
+	var names []string
+	for _, name := range indicatorNames {
+		if set[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
+
+// indicatorMenu is the 'i' overlay over the stats panel, toggling which
+// indicators it shows
+type indicatorMenu struct {
+	cursor   int
+	selected map[string]bool
+}
+
+func newIndicatorMenu() *indicatorMenu {
+	selected := make(map[string]bool, len(shownIndicators))
+	for name, on := range shownIndicators {
+		selected[name] = on
+	}
+	return &indicatorMenu{selected: selected}
+}
+
+// Update moves the cursor and toggles the indicator under it. done is set
+// once the menu is closed, leaving the selection to apply.
+func (im *indicatorMenu) Update(msg tea.KeyMsg) (done bool) {
+	switch msg.String() {
+	case "up", "k":
+		if im.cursor > 0 {
+			im.cursor--
+		}
+	case "down", "j":
+		if im.cursor < len(indicatorNames)-1 {
+			im.cursor++
+		}
+	case " ", "x":
+		name := indicatorNames[im.cursor]
+		im.selected[name] = !im.selected[name]
+	case "a":
+		// All, or none once all are on
+		all := len(formatIndicators(im.selected)) == len(indicatorNames)
+		for _, name := range indicatorNames {
+			im.selected[name] = !all
+		}
+	case "enter", "esc", "i", "q":
+		return true
+	}
+	return false
+}
+
+func (im *indicatorMenu) View(theme Theme) string {
+	lines := []string{theme.Header.Render("Indicators")}
+	for i, name := range indicatorNames {
+		cursor, check, style := "  ", "[ ]", theme.Label
+		if i == im.cursor {
+			cursor = "> "
+		}
+		if im.selected[name] {
+			check, style = "[x]", theme.Value
+		}
+		lines = append(lines, cursor+style.Render(check+" "+indicatorTitles[name]))
+	}
+	return theme.Box.Render(strings.Join(lines, "\n"))
+}
+
+// indicatorHelp is the key help while the menu is open
+const indicatorHelp = "↑/↓: move • space: show/hide • 'a': all/none • enter/esc: done, saving to the config"
+
+// indicatorsSavedMsg describes the result of saving the selection
+type indicatorsSavedMsg string
+
+// saveIndicators writes the selection to the config file as its
+// indicators key, so the next start shows the same
+func saveIndicators(names []string) tea.Cmd {
+	return func() tea.Msg {
+		if err := setConfigKey(settingsPath, "indicators", names); err != nil {
+			return indicatorsSavedMsg("Saving indicators failed: " + err.Error())
+		}
+		return indicatorsSavedMsg("Indicators saved to " + settingsPath)
+	}
+}
+
+// setConfigKey sets key in the YAML config at path to value, creating the
+// file if needed and keeping the rest of it, comments included
+func setConfigKey(path, key string, value any) error {
+	var doc yaml.Node
+	var mode os.FileMode = 0o600 // it may hold tokens
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: not a mapping", path)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+	node.Style = yaml.FlowStyle
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1], found = &node, true
+		}
+	}
+	if !found {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &node)
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
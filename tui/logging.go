@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// minLevel is the minimum level logged, changeable while running
+var minLevel slog.LevelVar
+
+// setupLogging installs a structured logger at the named level (debug,
+// info, warn or error). Logs go to path when given, otherwise to stderr so
+// they stay out of the dashboard and the headless status lines on stdout;
+// with quiet set they are dropped instead. A log file stays open until
+// exit. Records at info and above also feed the dashboard's event panel.
+func setupLogging(level, path string, quiet bool) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("-log-level must be debug, info, warn or error")
+	}
+
+	var out io.Writer = os.Stderr
+	switch {
+	case path != "":
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("-log-file: %w", err)
+		}
+		out = f
+	case quiet:
+		out = io.Discard
+	}
+
+	minLevel.Set(lvl)
+	slog.SetDefault(slog.New(&eventHandler{next: slog.NewTextHandler(out, &slog.HandlerOptions{Level: &minLevel})}))
+	return nil
+}
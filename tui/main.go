@@ -3,103 +3,320 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
 )
 
-const serverURL = "http://localhost:8080"
+// API base URL, set from -port
+var serverURL = "http://localhost:8080"
 
-// Styles
+// Config file the dashboard saves its settings to, -config or the default
+var settingsPath string
+
+// Fastest allowed API polling interval
+const minRefresh = 50 * time.Millisecond
+
+// Command-line options
 var (
-	boxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("10")).
-			Padding(1, 2)
+	maWindow        = flag.Int("ma-window", 0, "moving-average window in ticks (0 uses the server's windows)")
+	headless        = flag.Bool("headless", false, "log updates to stdout instead of running the dashboard (default when stdout is not a terminal)")
+	symbolFlag      = flag.String("symbol", "", "comma-separated symbols to track, skipping coin selection")
+	exportPath      = flag.String("export", "", "write the first -symbol's (or the primary symbol's) recent trades to this CSV file and exit")
+	once            = flag.Bool("once", false, "print each -symbol's (or the primary symbol's) stats as a JSON line once it has a price, then exit")
+	onceTimeout     = flag.Duration("once-timeout", 10*time.Second, "how long -once waits for a price before failing")
+	listCoinsFlag   = flag.Bool("list-coins", false, "print the selectable coins as JSON and exit")
+	selectOnly      = flag.Bool("select", false, "switch the API to -symbol and exit without the dashboard")
+	refresh         = flag.Duration("refresh", 500*time.Millisecond, "how often to poll the API")
+	configPath      = flag.String("config", "", "YAML config file with defaults for these flags (default ~/.crypto-analysis/config.yaml)")
+	sparkPoints     = flag.Int("spark-points", 0, "points the sparkline shows (0 fills the terminal width)")
+	historyPoints   = flag.Int("history", 1000, "price points kept for the sparkline, its shading and the chart, however many are shown")
+	sparkColor      = flag.String("spark-colors", "volatility", "sparkline coloring: volatility (shade by move size relative to recent volatility) or direction (up/down only)")
+	apiToken        = flag.String("api-token", "", "bearer token sent to the API when it requires one")
+	themeName       = flag.String("theme", "dark", "color theme: dark, light or mono (no color)")
+	asciiOnly       = flag.Bool("ascii", false, "draw with ASCII only, for terminals without Unicode (default when the locale isn't UTF-8 or TERM is dumb)")
+	locale          = flag.String("locale", "plain", "number format: plain (65000.12), en (65,000.12), de (65.000,12), fr (65 000,12) or ch (65'000.12)")
+	tradeQty        = flag.Float64("trade-qty", 0.01, "quantity the 'b' and 's' keys paper-trade")
+	apiPort         = flag.Int("port", 8080, "port the API listens on")
+	logLevel        = flag.String("log-level", "info", "minimum log level: debug, info, warn or error")
+	logFile         = flag.String("log-file", "", "append logs to this file instead of stderr (the dashboard only logs to stderr when it is redirected)")
+	staleAfter      = flag.Duration("stale", 10*time.Second, "mark prices STALE when the feed hasn't updated for this long (0 disables)")
+	columnList      = flag.String("columns", "price,change,ma,high,low", "portfolio table columns, in order: "+strings.Join(columnNames, ", ")+"; those that don't fit the terminal are dropped from the right")
+	displayUnit     = flag.String("unit", "", "show prices in sats (through BTC in the pair's quote when it isn't BTC) or in a tracked symbol's base, like btcusdt, dividing by its price (default each pair's own quote)")
+	quiet           = flag.Bool("quiet", false, "print only errors, to stderr, for scripts: the -select and -export confirmations go to the log at debug level, and the log defaults to error level")
+	alertBell       = flag.Bool("alert-bell", false, "ring the terminal bell and flash the alert line inverted for a second when an alert fires in the dashboard")
+	alertRules      stringList
+	shownIndicators = allIndicators()
+)
 
-	priceStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("15"))
+func init() {
+	flag.Var(&alertRules, "alert", "price alert like btcusdt>70000 (repeatable)")
+	flag.Var(&shownIndicators, "indicators", "indicators the single-coin stats panel shows, or none: "+strings.Join(indicatorNames, ", ")+"; 'i' picks them in the dashboard and saves them to the config. Only the panel changes; the API still sends every indicator")
+}
 
-	upStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("10"))
+// stringList is a repeatable string flag
+type stringList []string
 
-	downStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("9"))
+func (l *stringList) String() string { return strings.Join(*l, ",") }
 
-	labelStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8"))
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
 
-	valueStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("15"))
+// tokenTransport adds the API bearer token to every request
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
 
-	headerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("10")).
-			MarginBottom(1)
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
 
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")).
-			MarginTop(1)
+// API response types
+type PriceResponse struct {
+	Price  float64 `json:"price"`
+	Status string  `json:"status"` // "initializing" until the first valid price
+	AgeMs  int64   `json:"age_ms"` // -1 before the first live tick
+}
 
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("9"))
+// StatsResponse is the /api/stats schema this client understands
+type StatsResponse struct {
+	SchemaVersion int     `json:"schema_version"`
+	Volume        float64 `json:"volume"`
+	TicksPerMin   int     `json:"ticks_per_minute"`
+	HistoryLen    int     `json:"history_len"`
+	PriceDecimals *int    `json:"price_decimals"` // absent from older APIs
+	Indicators    struct {
+		MovingAverage  float64            `json:"moving_average"`
+		MovingAverages map[string]float64 `json:"moving_averages"`
+		RSI            float64            `json:"rsi"`
+		EMAs           map[string]float64 `json:"emas"`
+		VWAP           float64            `json:"vwap"`
+		MACD           *MACDInfo          `json:"macd"`
+		Bollinger      *BollingerInfo     `json:"bollinger"`
+		ATR            *ATRInfo           `json:"atr"`
+	} `json:"indicators"`
+	Session struct {
+		High            float64 `json:"high"`
+		HighTime        int64   `json:"high_time"`
+		Low             float64 `json:"low"`
+		LowTime         int64   `json:"low_time"`
+		FromHighPercent float64 `json:"from_high_percent"`
+		FromLowPercent  float64 `json:"from_low_percent"`
+	} `json:"session"`
+	Rolling24h struct {
+		High            float64 `json:"high"`
+		Low             float64 `json:"low"`
+		FromHighPercent float64 `json:"from_high_percent"`
+		FromLowPercent  float64 `json:"from_low_percent"`
+		ChangePercent   float64 `json:"change_percent"`
+	} `json:"rolling_24h"`
+}
 
-	selectedStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("10")).
-			Bold(true)
+// Latest /api/stats schema version this client reads
+const statsSchemaVersion = 1
 
-	itemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8"))
+type BollingerInfo struct {
+	Upper  float64 `json:"upper"`
+	Middle float64 `json:"middle"`
+	Lower  float64 `json:"lower"`
+	Period int     `json:"period"`
+	K      float64 `json:"k"`
+}
 
-	timeStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("6"))
-)
+type ATRInfo struct {
+	Value    float64 `json:"value"`
+	Period   int     `json:"period"`
+	Interval string  `json:"interval"`
+}
 
-// API response types
-type PriceResponse struct {
-	Price float64 `json:"price"`
+type MACDInfo struct {
+	MACD      float64 `json:"macd"`
+	Signal    float64 `json:"signal"`
+	Histogram float64 `json:"histogram"`
 }
 
-type StatsResponse struct {
+type SymbolResponse struct {
+	Symbol    string             `json:"symbol"`
+	Name      string             `json:"name"`
+	Symbols   []string           `json:"symbols"`
+	TickSizes map[string]float64 `json:"tick_sizes"`
+}
+
+// CoinRow is one entry of /api/prices
+type CoinRow struct {
+	Symbol        string  `json:"symbol"`
+	Price         float64 `json:"price"`
 	MovingAverage float64 `json:"moving_average"`
 	High          float64 `json:"high"`
 	Low           float64 `json:"low"`
+	AgeMs         int64   `json:"age_ms"`
+	Status        string  `json:"status"`
+	PriceDecimals *int    `json:"price_decimals"`
+	RSI           float64 `json:"rsi"`    // -1 until enough samples
+	Volume        float64 `json:"volume"` // live quantity since the API began tracking it
+	Change        float64 `json:"-"`
 }
 
-type SymbolResponse struct {
-	Symbol string `json:"symbol"`
-	Name   string `json:"name"`
+// priced reports whether the API has had a valid price for the coin
+func (c CoinRow) priced() bool {
+	return c.Status != "initializing" && c.Price > 0
+}
+
+type StatusResponse struct {
+	Symbol         string  `json:"symbol"`
+	Exchange       string  `json:"exchange"`
+	State          string  `json:"state"`
+	LastMessage    int64   `json:"last_message"` // unix ms
+	MessagesPerSec float64 `json:"messages_per_sec"`
+	RTTMs          float64 `json:"rtt_ms"`
+	Stream         string  `json:"stream"` // "trade", "ticker" or "both"; empty from older APIs
+	Port           int     `json:"port"`
+}
+
+type AlertInfo struct {
+	ID           int     `json:"id"`
+	Symbol       string  `json:"symbol"`
+	Direction    string  `json:"direction"`
+	Threshold    float64 `json:"threshold"`
+	Triggered    bool    `json:"triggered"`
+	Fires        int     `json:"fires"`
+	TriggerPrice float64 `json:"trigger_price"`
+}
+
+// PaperPosition is one simulated holding from /api/portfolio
+type PaperPosition struct {
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"`
+	AvgEntry      float64 `json:"avg_entry"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// PaperPortfolio is the /api/portfolio response
+type PaperPortfolio struct {
+	Positions     []PaperPosition `json:"positions"`
+	RealizedPnL   float64         `json:"realized_pnl"`
+	UnrealizedPnL float64         `json:"unrealized_pnl"`
+	TotalPnL      float64         `json:"total_pnl"`
+}
+
+// SpikeInfo is an active sudden move from /api/alerts?type=spike
+type SpikeInfo struct {
+	Symbol        string  `json:"symbol"`
+	ChangePercent float64 `json:"change_percent"`
+	Window        string  `json:"window"`
+}
+
+type BookLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+type OrderBookResponse struct {
+	Bids    []BookLevel `json:"bids"`
+	Asks    []BookLevel `json:"asks"`
+	BestBid float64     `json:"best_bid"`
+	BestAsk float64     `json:"best_ask"`
+	Spread  float64     `json:"spread"`
+	SpreadP float64     `json:"spread_percent"`
 }
 
 type CoinInfo struct {
 	Symbol string `json:"symbol"`
 	Name   string `json:"name"`
+	Short  string `json:"short"` // base asset, e.g. BTC
+	Base   string `json:"base"`
+	Quote  string `json:"quote"` // e.g. USDT or BTC
 }
 
 type HistoryTrade struct {
 	Symbol    string    `json:"symbol"`
 	Price     float64   `json:"price"`
+	Quantity  float64   `json:"quantity"`
 	Timestamp time.Time `json:"timestamp"`
+	Side      string    `json:"side"`     // aggressor, "buy" or "sell", empty when unknown
+	Backfill  bool      `json:"backfill"` // a historical close rather than a live trade
+
+	// The processor's indicators as of this trade, with ?indicators=true
+	Indicators *TradeIndicators `json:"indicators"`
+}
+
+// TradeIndicators are the indicators /api/history carries per trade
+type TradeIndicators struct {
+	MovingAverages map[string]float64 `json:"moving_averages"`
+	EMAs           map[string]float64 `json:"emas"` // -1 until ready
+	RSI            float64            `json:"rsi"`  // -1 until ready
+	VWAP           float64            `json:"vwap"` // 0 until a trade with quantity
+	MACD           *MACDInfo          `json:"macd"`
+	Bollinger      *BollingerInfo     `json:"bollinger"`
 }
 
 // Dashboard data
 type DashboardData struct {
-	Symbol        string
-	CoinName      string
-	Price         float64
-	PrevPrice     float64
-	High          float64
-	Low           float64
-	MovingAverage float64
-	Change        float64
-	ChangePercent float64
-	Connected     bool
-	Error         string
+	Symbol         string
+	CoinName       string
+	Price          float64
+	Initializing   bool // no valid price yet, so Price and the stats are zero placeholders
+	PrevPrice      float64
+	AgeMs          int64 // since the API's latest live tick, -1 before the first
+	High           float64
+	Low            float64
+	HighTime       int64 // unix ms the session high was set, 0 if unknown
+	LowTime        int64
+	FromHigh       float64 // percent below the session high
+	FromLow        float64 // percent above the session low
+	High24h        float64
+	Low24h         float64
+	FromHigh24h    float64
+	FromLow24h     float64
+	Volume         float64 // live quantity traded since the API began tracking the symbol
+	TicksPerMin    int     // live ticks the API saw in the last minute
+	HistoryLen     int     // trades the API holds, the widest -ma-window it can average
+	Change24h      float64 // percent
+	VWAP           float64
+	MACD           *MACDInfo // nil until the processor has enough samples
+	Bollinger      *BollingerInfo
+	ATR            *ATRInfo // nil until enough candles have closed
+	MovingAverage  float64
+	MovingAverages map[string]float64 // keyed by window
+	RSI            float64
+	EMAs           map[string]float64 // keyed by period, -1 until ready
+	Change         float64
+	ChangePercent  float64
+	Connected      bool
+	FeedState      string
+	FeedLast       int64   // unix ms of the exchange's latest message, 0 if unknown
+	FeedRate       float64 // exchange messages per second
+	FeedRTTMs      float64 // ping round trip to the exchange, 0 until measured
+	FeedStream     string  // exchange streams ingestion subscribes to
+	APIPort        int     // as reported by the API
+	Exchange       string
+	Coins          []CoinRow // populated when more than one symbol is tracked
+	Alerts         []AlertInfo
+	Spikes         []SpikeInfo
+	Paper          *PaperPortfolio
+	OrderBook      *OrderBookResponse // nil when the exchange has no depth stream
+	Tape           []HistoryTrade     // latest live trades, newest first
+	Error          string
 }
 
 // View modes
@@ -108,7 +325,10 @@ type viewMode int
 const (
 	dashboardView viewMode = iota
 	coinSelectView
+	confirmView
 	historyView
+	chartView
+	compareView
 )
 
 // Messages
@@ -116,58 +336,149 @@ type tickMsg time.Time
 type dataMsg DashboardData
 type coinsMsg []CoinInfo
 type symbolChangedMsg struct{}
+type coinAddedMsg struct {
+	symbol string
+	err    string
+}
 type historyMsg []HistoryTrade
+type flashDoneMsg struct{} // an alert flash has run its time
+
+// sparkSeedMsg carries the primary symbol's recent prices, oldest first
+type sparkSeedMsg struct {
+	symbol  string
+	prices  []float64
+	volumes []float64 // quantity of each seeded trade
+}
 
 // Model
 type model struct {
-	mode         viewMode
-	data         DashboardData
-	history      []float64
-	dbHistory    []HistoryTrade
-	quitting     bool
-	coins        []CoinInfo
-	coinCursor   int
-	switching    bool
+	mode          viewMode
+	data          DashboardData
+	history       []float64
+	volumes       []float64 // quantity traded over each history point, parallel to history
+	lastVolume    float64   // the API's volume total at the latest point, -1 before it
+	dbHistory     []HistoryTrade
+	quitting      bool
+	coins         []CoinInfo
+	coinCursor    int // index into visibleCoins()
+	coinSelected  map[string]bool
+	filtering     bool           // typing a coin filter
+	coinFilter    string         // case-insensitive substring of symbol or name
+	addingCoin    bool           // typing a custom symbol
+	coinInput     string         // custom symbol being typed
+	coinError     string         // last custom symbol validation error
+	pending       []string       // symbols awaiting confirmation
+	notified      map[int]int    // fires of each alert already shown as notifications
+	lastAlert     string         // most recent fired alert
+	flashUntil    time.Time      // the alert line is drawn inverted until then, with -alert-bell
+	exportStatus  string         // result of the last 'e' export
+	paperStatus   string         // result of the last 'b'/'s' paper trade
+	resetStatus   string         // result of the last 'r' session reset
+	confirmReset  bool           // 'r' pressed, waiting for y to reset the session
+	menu          *indicatorMenu // 'i' overlay over the stats panel, nil while closed
+	menuStatus    string         // result of saving its last selection
+	switching     bool
 	historyScroll int
+	macdHist      []float64 // recent MACD histogram values, for scaling the bar
+	volatility    float64   // standard deviation of returns over history
+	showBook      bool      // order book panel toggled with 'o'
+	showTape      bool      // trade tape panel toggled with 't'
+	showEvents    bool      // event panel toggled with 'l'
+	width         int       // terminal size, 0 until the first WindowSizeMsg
+	height        int
+	theme         Theme
+	flash         bool           // toggled every tick to blink the spike banner
+	paused        bool           // display frozen with space
+	latest        *DashboardData // newest data fetched while paused
+	comparePair   int            // which pair of tracked coins 'x' compares, stepped with 'n'
+	ratioPair     string         // "a/b" symbols ratioHistory belongs to
+	ratioHistory  []float64      // recent compared price ratios
+	focus         string         // coin shown in full instead of the portfolio table, cycled with tab
+	summary       *MarketSummary // Binance's 24 hours as the dashboard started, nil when unavailable
 }
 
-func initialModel() model {
+// Sparkline sizing
+const (
+	defaultSparkWidth = 20  // points shown before the terminal size is known
+	minSparkWidth     = 10  // points shown on very narrow terminals
+	maxSparkRows      = 6   // tallest multi-row sparkline
+	boxChrome         = 6   // border and horizontal padding around box content
+	maxSparkWidth     = 500 // widest sparkline however wide the terminal
+)
+
+func initialModel(theme Theme) model {
 	return model{
-		mode:    coinSelectView, // Start with coin selection
-		history: make([]float64, 0, 20),
+		theme:      theme,
+		mode:       coinSelectView, // Start with coin selection
+		notified:   make(map[int]int),
+		history:    make([]float64, 0, defaultSparkWidth),
+		volumes:    make([]float64, 0, defaultSparkWidth),
+		lastVolume: -1,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return fetchCoins() // Fetch coins first
+	if m.mode == dashboardView {
+		return tea.Batch(fetchData(m.focus), tick(), seedSparkline(m.focus), fetchSummary(m.focus), registerAlerts(alertRules))
+	}
+	return tea.Batch(fetchCoins(), registerAlerts(alertRules)) // Fetch coins first
 }
 
 func tick() tea.Cmd {
-	return tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
+	return tea.Tick(*refresh, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
-func fetchData() tea.Cmd {
+// fetchData fetches the dashboard data of focus, or of the primary symbol
+// when focus is empty or no longer tracked
+func fetchData(focus string) tea.Cmd {
 	return func() tea.Msg {
 		data := DashboardData{}
 
 		// Fetch symbol info
 		symbolResp, err := http.Get(serverURL + "/api/symbol")
 		if err != nil {
-			data.Error = "Server not running. Start with 'make run'"
+			data.Error = fmt.Sprintf("Server not running on port %d. Start with 'make run' or pass -port", *apiPort)
 			return dataMsg(data)
 		}
 		defer symbolResp.Body.Close()
+		if symbolResp.StatusCode == http.StatusUnauthorized {
+			data.Error = "API requires a token, pass -api-token"
+			return dataMsg(data)
+		}
 
 		var symbolData SymbolResponse
 		if err := json.NewDecoder(symbolResp.Body).Decode(&symbolData); err == nil {
 			data.Symbol = symbolData.Symbol
 			data.CoinName = symbolData.Name
+			setTickSizes(symbolData.TickSizes)
+		}
+		if focus != "" && focus != data.Symbol && slices.Contains(symbolData.Symbols, focus) {
+			data.Symbol, data.CoinName = focus, ""
+		}
+
+		// Ask for this symbol explicitly from here on, so a symbol change
+		// landing mid-fetch can't mix two coins' data
+		symbolQuery := "?symbol=" + url.QueryEscape(data.Symbol)
+
+		// Fetch every tracked coin when watching several
+		if len(symbolData.Symbols) > 1 {
+			pricesResp, err := http.Get(serverURL + "/api/prices")
+			if err != nil {
+				data.Error = "Failed to fetch prices"
+				return dataMsg(data)
+			}
+			defer pricesResp.Body.Close()
+			json.NewDecoder(pricesResp.Body).Decode(&data.Coins)
+			for _, coin := range data.Coins {
+				setPriceDecimals(coin.Symbol, coin.PriceDecimals)
+			}
 		}
+		setUnitPrices(data.Coins)
 
 		// Fetch price
-		priceResp, err := http.Get(serverURL + "/api/price")
+		priceResp, err := http.Get(serverURL + "/api/price" + symbolQuery)
 		if err != nil {
 			data.Error = "Failed to fetch price"
 			return dataMsg(data)
@@ -177,10 +488,16 @@ func fetchData() tea.Cmd {
 		var priceData PriceResponse
 		if err := json.NewDecoder(priceResp.Body).Decode(&priceData); err == nil {
 			data.Price = priceData.Price
+			data.AgeMs = priceData.AgeMs
+			data.Initializing = priceData.Status == "initializing" || priceData.Price <= 0
 		}
 
 		// Fetch stats
-		statsResp, err := http.Get(serverURL + "/api/stats")
+		statsURL := serverURL + "/api/stats" + symbolQuery
+		if *maWindow > 0 {
+			statsURL += fmt.Sprintf("&ma_window=%d", *maWindow)
+		}
+		statsResp, err := http.Get(statsURL)
 		if err != nil {
 			data.Error = "Failed to fetch stats"
 			return dataMsg(data)
@@ -189,9 +506,91 @@ func fetchData() tea.Cmd {
 
 		var statsData StatsResponse
 		if err := json.NewDecoder(statsResp.Body).Decode(&statsData); err == nil {
-			data.MovingAverage = statsData.MovingAverage
-			data.High = statsData.High
-			data.Low = statsData.Low
+			if statsData.SchemaVersion != statsSchemaVersion {
+				data.Error = fmt.Sprintf("API stats schema v%d is not supported (expected v%d), update the TUI or the API", statsData.SchemaVersion, statsSchemaVersion)
+				return dataMsg(data)
+			}
+			setPriceDecimals(data.Symbol, statsData.PriceDecimals)
+			ind := statsData.Indicators
+			data.MovingAverage = ind.MovingAverage
+			data.MovingAverages = ind.MovingAverages
+			data.High = statsData.Session.High
+			data.Low = statsData.Session.Low
+			data.HighTime = statsData.Session.HighTime
+			data.LowTime = statsData.Session.LowTime
+			data.FromHigh = statsData.Session.FromHighPercent
+			data.FromLow = statsData.Session.FromLowPercent
+			data.High24h = statsData.Rolling24h.High
+			data.Low24h = statsData.Rolling24h.Low
+			data.FromHigh24h = statsData.Rolling24h.FromHighPercent
+			data.FromLow24h = statsData.Rolling24h.FromLowPercent
+			data.Change24h = statsData.Rolling24h.ChangePercent
+			data.Volume = statsData.Volume
+			data.TicksPerMin = statsData.TicksPerMin
+			data.HistoryLen = statsData.HistoryLen
+			data.VWAP = ind.VWAP
+			data.MACD = ind.MACD
+			data.Bollinger = ind.Bollinger
+			data.ATR = ind.ATR
+			data.RSI = ind.RSI
+			data.EMAs = ind.EMAs
+		}
+
+		// Fetch exchange feed state
+		statusResp, err := http.Get(serverURL + "/api/status" + symbolQuery)
+		if err == nil {
+			defer statusResp.Body.Close()
+			var statusData StatusResponse
+			if err := json.NewDecoder(statusResp.Body).Decode(&statusData); err == nil {
+				data.FeedState = statusData.State
+				data.FeedLast = statusData.LastMessage
+				data.FeedRate = statusData.MessagesPerSec
+				data.FeedRTTMs = statusData.RTTMs
+				data.FeedStream = statusData.Stream
+				data.Exchange = statusData.Exchange
+				data.APIPort = statusData.Port
+			}
+		}
+
+		// Fetch order book depth
+		bookResp, err := http.Get(fmt.Sprintf("%s/api/orderbook%s&levels=%d", serverURL, symbolQuery, bookLevels))
+		if err == nil {
+			defer bookResp.Body.Close()
+			if bookResp.StatusCode == http.StatusOK {
+				var book OrderBookResponse
+				if err := json.NewDecoder(bookResp.Body).Decode(&book); err == nil {
+					data.OrderBook = &book
+				}
+			}
+		}
+
+		// Fetch the trade tape
+		tapeResp, err := http.Get(fmt.Sprintf("%s/api/trades%s&limit=%d", serverURL, symbolQuery, tapeRows))
+		if err == nil {
+			defer tapeResp.Body.Close()
+			if tapeResp.StatusCode == http.StatusOK {
+				json.NewDecoder(tapeResp.Body).Decode(&data.Tape)
+			}
+		}
+
+		// Fetch alerts
+		alertsResp, err := http.Get(serverURL + "/api/alerts")
+		if err == nil {
+			defer alertsResp.Body.Close()
+			json.NewDecoder(alertsResp.Body).Decode(&data.Alerts)
+		}
+		paperResp, err := http.Get(serverURL + "/api/portfolio")
+		if err == nil {
+			defer paperResp.Body.Close()
+			var paper PaperPortfolio
+			if paperResp.StatusCode == http.StatusOK && json.NewDecoder(paperResp.Body).Decode(&paper) == nil {
+				data.Paper = &paper
+			}
+		}
+		spikesResp, err := http.Get(serverURL + "/api/alerts?type=spike")
+		if err == nil {
+			defer spikesResp.Body.Close()
+			json.NewDecoder(spikesResp.Body).Decode(&data.Spikes)
 		}
 
 		data.Connected = true
@@ -213,6 +612,38 @@ func fetchCoins() tea.Cmd {
 	}
 }
 
+// seedSparkline fetches the API's in-memory history, which includes any
+// exchange backfill, so the sparkline starts full instead of empty. An
+// empty symbol seeds the primary one.
+func seedSparkline(symbol string) tea.Cmd {
+	return func() tea.Msg {
+		query := fmt.Sprintf("?source=memory&limit=%d", *historyPoints)
+		if symbol != "" {
+			query += "&symbol=" + url.QueryEscape(symbol)
+		}
+		resp, err := http.Get(serverURL + "/api/history" + query)
+		if err != nil {
+			return sparkSeedMsg{}
+		}
+		defer resp.Body.Close()
+
+		var trades []HistoryTrade
+		if err := json.NewDecoder(resp.Body).Decode(&trades); err != nil || len(trades) == 0 {
+			return sparkSeedMsg{}
+		}
+		msg := sparkSeedMsg{
+			symbol:  trades[0].Symbol,
+			prices:  make([]float64, len(trades)),
+			volumes: make([]float64, len(trades)),
+		}
+		for i, t := range trades {
+			msg.prices[len(trades)-1-i] = t.Price
+			msg.volumes[len(trades)-1-i] = t.Quantity
+		}
+		return msg
+	}
+}
+
 func fetchHistory() tea.Cmd {
 	return func() tea.Msg {
 		resp, err := http.Get(serverURL + "/api/history")
@@ -227,42 +658,371 @@ func fetchHistory() tea.Cmd {
 	}
 }
 
-func changeSymbol(symbol string) tea.Cmd {
+func addCoin(symbol string) tea.Cmd {
 	return func() tea.Msg {
 		body, _ := json.Marshal(map[string]string{"symbol": symbol})
-		resp, err := http.Post(serverURL+"/api/symbol", "application/json", bytes.NewReader(body))
+		resp, err := http.Post(serverURL+"/api/coins", "application/json", bytes.NewReader(body))
 		if err != nil {
+			return coinAddedMsg{symbol: symbol, err: "Server not running"}
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			reason, _ := io.ReadAll(resp.Body)
+			return coinAddedMsg{symbol: symbol, err: strings.TrimSpace(string(reason))}
+		}
+
+		var added CoinInfo
+		json.NewDecoder(resp.Body).Decode(&added)
+		return coinAddedMsg{symbol: added.Symbol}
+	}
+}
+
+// registerAlerts sends the -alert rules to the API
+func registerAlerts(rules []string) tea.Cmd {
+	if len(rules) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		for _, rule := range rules {
+			registerAlert(rule)
+		}
+		return nil
+	}
+}
+
+// registerAlert sends one rule to the API and returns the alert's ID, or
+// -1 when it was not registered
+func registerAlert(rule string) int {
+	body, _ := json.Marshal(map[string]string{"rule": rule})
+	resp, err := http.Post(serverURL+"/api/alerts", "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("Alert registration failed", "rule", rule, "err", err)
+		return -1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.Warn("Alert rejected", "rule", rule, "status", resp.Status)
+		return -1
+	}
+	var a AlertInfo
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		slog.Warn("Alert registration failed", "rule", rule, "err", err)
+		return -1
+	}
+	slog.Info("Alert registered", "rule", rule, "id", a.ID)
+	return a.ID
+}
+
+// removeAlert deletes a registered alert from the API
+func removeAlert(rule string, id int) {
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/alerts?id=%d", serverURL, id), nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		slog.Warn("Alert removal failed", "rule", rule, "err", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		slog.Warn("Alert removal failed", "rule", rule, "status", resp.Status)
+		return
+	}
+	slog.Info("Alert removed", "rule", rule, "id", id)
+}
+
+// notifyAlert sends a fired alert to the -notify backends
+func notifyAlert(a AlertInfo) tea.Cmd {
+	return func() tea.Msg {
+		dispatchAlert(notifiers(), a)
+		return nil
+	}
+}
+
+// logFeedChanges records the feed changing state and coins going stale or
+// live again between m.data and next, for the log and the event panel
+func (m model) logFeedChanges(next DashboardData) {
+	if next.FeedState != "" && next.FeedState != m.data.FeedState {
+		slog.Info("Feed state", "exchange", feedLabel(next.Exchange), "state", next.FeedState)
+	}
+	stale := make(map[string]bool)
+	for _, coin := range m.data.Coins {
+		stale[coin.Symbol] = isStale(coin.Price, coin.AgeMs)
+	}
+	for _, coin := range next.Coins {
+		now := isStale(coin.Price, coin.AgeMs)
+		if now && !stale[coin.Symbol] {
+			slog.Warn("Price stale", "symbol", coin.Symbol, "age_ms", coin.AgeMs)
+		} else if !now && stale[coin.Symbol] {
+			slog.Info("Price live again", "symbol", coin.Symbol)
+		}
+	}
+}
+
+// How long -alert-bell flashes the alert line
+const alertFlashTime = time.Second
+
+// ringBell sounds the terminal bell. The renderer writes each frame in one
+// go, so the lone BEL lands between frames and leaves the screen alone.
+func ringBell() tea.Msg {
+	os.Stdout.WriteString("\a")
+	return nil
+}
+
+// notifyFeedFailed tells the -notify backends ingestion gave up on
+// symbol's feed
+func notifyFeedFailed(symbol, exchange string) tea.Cmd {
+	return func() tea.Msg {
+		dispatchNotice(notifiers(), newFeedNotice(symbol, exchange))
+		return nil
+	}
+}
+
+// logAlertFired records a fired alert
+func logAlertFired(a AlertInfo) {
+	slog.Warn("Alert fired", "id", a.ID, "symbol", a.Symbol, "direction", a.Direction,
+		"threshold", FormatPrice(a.Symbol, a.Threshold), "price", FormatPrice(a.Symbol, a.TriggerPrice))
+}
+
+// postSymbols asks the API to track symbols
+func postSymbols(symbols []string) error {
+	body, _ := json.Marshal(map[string][]string{"symbols": symbols})
+	resp, err := http.Post(serverURL+"/api/symbol", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("server not running: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		reason, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(reason)))
+	}
+	return nil
+}
+
+func changeSymbols(symbols []string) tea.Cmd {
+	return func() tea.Msg {
+		if err := postSymbols(symbols); err != nil {
+			slog.Warn("Symbol change failed", "symbols", symbols, "err", err)
 			return nil
 		}
-		resp.Body.Close()
+		slog.Info("Symbols changed", "symbols", symbols)
 		return symbolChangedMsg{}
 	}
 }
 
+// sessionResetMsg describes the result of a session reset
+type sessionResetMsg string
+
+// resetSession asks the API to start a new session, clearing the session
+// high/low and VWAP while the history and feeds carry on
+func resetSession() tea.Cmd {
+	return func() tea.Msg {
+		resp, err := http.Post(serverURL+"/api/reset", "application/json", nil)
+		if err != nil {
+			return sessionResetMsg("Session reset failed: server not running")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			reason, _ := io.ReadAll(resp.Body)
+			return sessionResetMsg("Session reset failed: " + strings.TrimSpace(string(reason)))
+		}
+		slog.Info("Session reset")
+		return sessionResetMsg("Session reset at " + time.Now().Format("15:04:05"))
+	}
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
 	case tea.KeyMsg:
 		switch m.mode {
 		case dashboardView:
+			if m.menu != nil {
+				if msg.String() == "ctrl+c" {
+					m.quitting = true
+					return m, tea.Quit
+				}
+				if !m.menu.Update(msg) {
+					return m, nil
+				}
+				names := formatIndicators(m.menu.selected)
+				m.menu = nil
+				if list := strings.Join(names, ","); list != shownIndicators.String() {
+					shownIndicators.Set(list)
+					m.menuStatus = "Saving indicators..."
+					return m, saveIndicators(names)
+				}
+				return m, nil
+			}
+			if m.confirmReset {
+				m.confirmReset = false
+				switch msg.String() {
+				case "y", "enter":
+					m.resetStatus = "Resetting session..."
+					return m, resetSession()
+				case "ctrl+c":
+				default:
+					return m, nil // any other key cancels
+				}
+			}
 			switch msg.String() {
 			case "ctrl+c", "q":
 				m.quitting = true
 				return m, tea.Quit
+			case "r":
+				m.confirmReset = true
+				return m, nil
 			case "c":
 				// Switch to coin selection
 				m.mode = coinSelectView
 				m.coinCursor = 0
+				m.coinSelected = nil
+				m.filtering = false
+				m.coinFilter = ""
 				return m, fetchCoins()
 			case "h":
 				// Switch to history view
 				m.mode = historyView
 				m.historyScroll = 0
 				return m, fetchHistory()
+			case "o":
+				m.showBook = !m.showBook
+				return m, nil
+			case "t":
+				m.showTape = !m.showTape
+				return m, nil
+			case "l":
+				m.showEvents = !m.showEvents
+				return m, nil
+			case "i":
+				// Only the single-coin view has a stats panel
+				if len(m.data.Coins) <= 1 || m.focus != "" {
+					m.menu = newIndicatorMenu()
+				}
+				return m, nil
+			case "+", "=", "-":
+				// Refetch at once so the new average shows without waiting
+				// for the next poll
+				if w := m.stepWindow(msg.String() != "-"); w != *maWindow {
+					*maWindow = w
+					return m, fetchData(m.focus)
+				}
+				return m, nil
+			case " ":
+				return m.togglePause()
+			case "g":
+				// Full-screen chart; reseed so the longer history fills in
+				m.mode = chartView
+				return m, seedSparkline(m.data.Symbol)
+			case "x":
+				// Side-by-side comparison needs two coins
+				if len(m.data.Coins) > 1 {
+					m.mode = compareView
+				}
+				return m, nil
+			case "e":
+				// Export recent trades for every shown coin
+				symbols := []string{m.data.Symbol}
+				if len(m.data.Coins) > 1 && m.focus == "" {
+					symbols = symbols[:0]
+					for _, coin := range m.data.Coins {
+						symbols = append(symbols, coin.Symbol)
+					}
+				}
+				m.exportStatus = "Exporting..."
+				return m, exportSymbols(symbols)
+			case "tab", "right":
+				m.cycleFocus(1)
+				return m, nil
+			case "shift+tab", "left":
+				m.cycleFocus(-1)
+				return m, nil
+			case "esc":
+				m.focus = ""
+				return m, nil
+			case "b", "s":
+				side := "buy"
+				if msg.String() == "s" {
+					side = "sell"
+				}
+				m.paperStatus = "Sending " + side + "..."
+				return m, paperTrade(m.data.Symbol, side, *tradeQty)
 			}
 
 		case coinSelectView:
+			if m.addingCoin {
+				switch msg.Type {
+				case tea.KeyEsc:
+					m.addingCoin = false
+					m.coinInput = ""
+				case tea.KeyEnter:
+					if m.coinInput != "" {
+						m.addingCoin = false
+						return m, addCoin(m.coinInput)
+					}
+				case tea.KeyBackspace:
+					if len(m.coinInput) > 0 {
+						m.coinInput = m.coinInput[:len(m.coinInput)-1]
+					}
+				case tea.KeyRunes:
+					m.coinInput += strings.ToLower(string(msg.Runes))
+				}
+				return m, nil
+			}
+
+			if m.filtering {
+				switch msg.Type {
+				case tea.KeyEsc:
+					m.filtering = false
+					m.coinFilter = ""
+					m.coinCursor = 0
+				case tea.KeyEnter:
+					// Keep the filter and go back to navigating
+					m.filtering = false
+				case tea.KeyUp:
+					if m.coinCursor > 0 {
+						m.coinCursor--
+					}
+				case tea.KeyDown:
+					if m.coinCursor < len(m.visibleCoins())-1 {
+						m.coinCursor++
+					}
+				case tea.KeyBackspace:
+					if len(m.coinFilter) > 0 {
+						m.coinFilter = m.coinFilter[:len(m.coinFilter)-1]
+						m.coinCursor = 0
+					}
+				case tea.KeyRunes, tea.KeySpace:
+					m.coinFilter += strings.ToLower(string(msg.Runes))
+					m.coinCursor = 0
+				}
+				return m, nil
+			}
+
+			visible := m.visibleCoins()
 			switch msg.String() {
-			case "ctrl+c", "q", "esc":
+			case "/":
+				// Narrow the list by typing
+				m.filtering = true
+			case "a":
+				// Enter a custom Binance symbol
+				m.addingCoin = true
+				m.coinInput = ""
+				m.coinError = ""
+			case "esc":
+				// Clear an active filter first, then go back
+				if m.coinFilter != "" {
+					m.coinFilter = ""
+					m.coinCursor = 0
+					return m, nil
+				}
+				m.mode = dashboardView
+				return m, nil
+			case "ctrl+c", "q":
 				// Go back to dashboard
 				m.mode = dashboardView
 				return m, nil
@@ -271,23 +1031,56 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.coinCursor--
 				}
 			case "down", "j":
-				if m.coinCursor < len(m.coins)-1 {
+				if m.coinCursor < len(visible)-1 {
 					m.coinCursor++
 				}
-			case "enter", " ":
-				if len(m.coins) > 0 {
-					m.switching = true
-					selectedCoin := m.coins[m.coinCursor]
-					return m, changeSymbol(selectedCoin.Symbol)
+			case " ":
+				// Toggle coin for multi-coin tracking
+				if len(visible) > 0 {
+					if m.coinSelected == nil {
+						m.coinSelected = make(map[string]bool)
+					}
+					symbol := visible[m.coinCursor].Symbol
+					m.coinSelected[symbol] = !m.coinSelected[symbol]
+				}
+			case "enter":
+				if len(visible) > 0 {
+					// Track toggled coins, or just the one under the cursor
+					var symbols []string
+					for _, coin := range m.coins {
+						if m.coinSelected[coin.Symbol] {
+							symbols = append(symbols, coin.Symbol)
+						}
+					}
+					if len(symbols) == 0 {
+						symbols = []string{visible[m.coinCursor].Symbol}
+					}
+					m.pending = symbols
+					m.mode = confirmView
+					return m, fetchExchange()
 				}
 			}
 
+		case confirmView:
+			switch msg.String() {
+			case "enter", "y":
+				m.switching = true
+				return m, changeSymbols(m.pending)
+			case "esc", "backspace", "n":
+				// The cursor, filter and toggles are as they were left
+				m.mode = coinSelectView
+				return m, nil
+			case "ctrl+c", "q":
+				m.mode = dashboardView
+				return m, nil
+			}
+
 		case historyView:
 			switch msg.String() {
 			case "ctrl+c", "q", "esc":
 				// Go back to dashboard
 				m.mode = dashboardView
-				return m, tea.Batch(fetchData(), tick())
+				return m, tea.Batch(fetchData(m.focus), tick())
 			case "up", "k":
 				if m.historyScroll > 0 {
 					m.historyScroll--
@@ -304,20 +1097,64 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Refresh history
 				return m, fetchHistory()
 			}
+
+		case chartView:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				m.quitting = true
+				return m, tea.Quit
+			case " ":
+				return m.togglePause()
+			case "g", "esc":
+				m.mode = dashboardView
+				return m, nil
+			}
+
+		case compareView:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				m.quitting = true
+				return m, tea.Quit
+			case " ":
+				return m.togglePause()
+			case "n":
+				m.comparePair++
+				m.trackRatio()
+				return m, nil
+			case "x", "esc":
+				m.mode = dashboardView
+				return m, nil
+			}
 		}
 
 	case tickMsg:
-		if m.mode == dashboardView && !m.switching {
-			return m, tea.Batch(fetchData(), tick())
+		m.flash = !m.flash
+		if (m.mode == dashboardView || m.mode == chartView || m.mode == compareView) && !m.switching {
+			return m, tea.Batch(fetchData(m.focus), tick())
 		}
 		return m, tick()
 
 	case dataMsg:
 		newData := DashboardData(msg)
+		if m.paused {
+			// Keep only the newest; resuming snaps to it
+			m.latest = &newData
+			return m, nil
+		}
 
-		// Check if symbol changed (reset history)
-		if m.data.Symbol != "" && m.data.Symbol != newData.Symbol {
-			m.history = make([]float64, 0, 20)
+		// Check if symbol changed (reset history), reseeding when it was
+		// changed elsewhere, e.g. through the API
+		var cmds []tea.Cmd
+		if m.focus != "" && !slices.ContainsFunc(newData.Coins, func(c CoinRow) bool { return c.Symbol == m.focus }) {
+			slog.Info("Focused symbol no longer tracked", "symbol", m.focus)
+			m.focus = ""
+		}
+		if m.data.Symbol != "" && newData.Symbol != "" && m.data.Symbol != newData.Symbol {
+			m.clearHistory()
+			if m.focus == "" {
+				slog.Info("Primary symbol changed", "from", m.data.Symbol, "to", newData.Symbol)
+			}
+			cmds = append(cmds, seedSparkline(newData.Symbol))
 		}
 
 		// Calculate change
@@ -327,21 +1164,83 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		newData.PrevPrice = m.data.Price
 
+		// Per-coin change since the previous fetch
+		prev := make(map[string]float64)
+		for _, coin := range m.data.Coins {
+			prev[coin.Symbol] = coin.Price
+		}
+		for i, coin := range newData.Coins {
+			if p, ok := prev[coin.Symbol]; ok && p > 0 && coin.Price > 0 {
+				newData.Coins[i].Change = coin.Price - p
+			}
+		}
+
+		// MACD histogram history, cleared with the price history
+		if m.data.Symbol != newData.Symbol {
+			m.macdHist = nil
+		}
+		if newData.MACD != nil && shownIndicators["macd"] {
+			m.macdHist = append(m.macdHist, newData.MACD.Histogram)
+			if len(m.macdHist) > maxSparkWidth {
+				m.macdHist = m.macdHist[1:]
+			}
+		}
+
+		if newData.Error != m.data.Error && newData.Error != "" {
+			slog.Warn("Fetch failed", "err", newData.Error)
+		}
+		if newData.Error == "" {
+			m.logFeedChanges(newData)
+			if newData.FeedState == "failed" && m.data.FeedState != "failed" {
+				cmds = append(cmds, notifyFeedFailed(newData.Symbol, newData.Exchange))
+			}
+		}
 		m.data = newData
 
 		// Update history
 		if newData.Price > 0 {
-			m.history = append(m.history, newData.Price)
-			if len(m.history) > 20 {
-				m.history = m.history[1:]
+			m.appendHistory(newData.Price, newData.Volume)
+			m.volatility = returnVolatility(m.history)
+		}
+		m.trackRatio()
+
+		// Notify each new fire once; a re-arming alert can fire and re-arm
+		// between polls, so fires are counted rather than Triggered watched
+		for _, a := range newData.Alerts {
+			if a.Fires > m.notified[a.ID] {
+				m.notified[a.ID] = a.Fires
+				logAlertFired(a)
+				m.lastAlert = fmt.Sprintf("%s crossed %s %s", strings.ToUpper(a.Symbol), a.Direction, FormatPrice(a.Symbol, a.Threshold))
+				cmds = append(cmds, notifyAlert(a))
+				if *alertBell {
+					m.flashUntil = time.Now().Add(alertFlashTime)
+					cmds = append(cmds, ringBell, tea.Tick(alertFlashTime, func(time.Time) tea.Msg { return flashDoneMsg{} }))
+				}
 			}
 		}
+		return m, tea.Batch(cmds...)
+
+	case flashDoneMsg:
+		// Redraw without the flash, however slow -refresh is
 		return m, nil
 
+	case coinAddedMsg:
+		m.coinInput = ""
+		if msg.err != "" {
+			m.coinError = msg.err
+			return m, nil
+		}
+		m.coinError = ""
+		if m.coinSelected == nil {
+			m.coinSelected = make(map[string]bool)
+		}
+		m.coinSelected[msg.symbol] = true
+		return m, fetchCoins()
+
 	case coinsMsg:
 		m.coins = msg
 		// Find current coin and set cursor
-		for i, coin := range m.coins {
+		for i, coin := range m.visibleCoins() {
 			if coin.Symbol == m.data.Symbol {
 				m.coinCursor = i
 				break
@@ -349,15 +1248,54 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case paperTradedMsg:
+		m.paperStatus = string(msg)
+		slog.Info(m.paperStatus)
+		return m, fetchData(m.focus)
+
+	case sessionResetMsg:
+		m.resetStatus = string(msg)
+		return m, fetchData(m.focus)
+
+	case indicatorsSavedMsg:
+		m.menuStatus = string(msg)
+		return m, nil
+
+	case exportedMsg:
+		m.exportStatus = exportStatus(msg)
+		return m, nil
+
 	case historyMsg:
 		m.dbHistory = msg
 		return m, nil
 
+	case sparkSeedMsg:
+		// Only while the polled history is still shorter than the seed
+		if len(msg.prices) > len(m.history) && (m.data.Symbol == "" || m.data.Symbol == msg.symbol) {
+			m.history, m.volumes = msg.prices, msg.volumes
+			m.trimHistory()
+			m.volatility = returnVolatility(m.history)
+		}
+		return m, nil
+
+	case summaryMsg:
+		m.summary = msg
+		return m, nil
+
+	case exchangeMsg:
+		if msg != "" {
+			m.data.Exchange = string(msg)
+		}
+		return m, nil
+
 	case symbolChangedMsg:
 		m.switching = false
 		m.mode = dashboardView
-		m.history = make([]float64, 0, 20)
-		return m, tea.Batch(fetchData(), tick())
+		m.clearHistory()
+		m.comparePair = 0
+		m.focus = ""
+		m.summary = nil
+		return m, tea.Batch(fetchData(""), tick(), seedSparkline(""), fetchSummary(""))
 	}
 
 	return m, nil
@@ -371,38 +1309,86 @@ func (m model) View() string {
 	switch m.mode {
 	case coinSelectView:
 		return m.viewCoinSelect()
+	case confirmView:
+		return m.viewConfirm()
 	case historyView:
 		return m.viewHistory()
+	case chartView:
+		return m.viewChart()
+	case compareView:
+		return m.viewCompare()
 	default:
 		return m.viewDashboard()
 	}
 }
 
 func (m model) viewCoinSelect() string {
-	s := headerStyle.Render("Select Cryptocurrency") + "\n\n"
+	s := m.theme.Header.Render("Select Cryptocurrency") + "\n\n"
+
+	visible := m.visibleCoins()
+	if m.filtering || m.coinFilter != "" {
+		cursor := ""
+		if m.filtering {
+			cursor = "█"
+		}
+		s += m.theme.Label.Render("Filter: ") + m.theme.Value.Render("/"+m.coinFilter+cursor) + "\n\n"
+	}
 
 	if len(m.coins) == 0 {
-		s += labelStyle.Render("Loading coins...")
+		s += m.theme.Label.Render("Loading coins...")
+	} else if len(visible) == 0 {
+		s += m.theme.Label.Render("No coins match") + "\n"
 	} else {
-		for i, coin := range m.coins {
+		for i, coin := range visible {
 			cursor := "  "
-			style := itemStyle
+			style := m.theme.Item
 			if i == m.coinCursor {
 				cursor = "▸ "
-				style = selectedStyle
+				style = m.theme.Selected
+			}
+			check := "[ ] "
+			if m.coinSelected[coin.Symbol] {
+				check = "[x] "
 			}
 			// Mark current coin
 			current := ""
 			if coin.Symbol == m.data.Symbol {
 				current = " (current)"
 			}
-			s += style.Render(fmt.Sprintf("%s%s%s", cursor, coin.Name, current)) + "\n"
+			s += style.Render(fmt.Sprintf("%s%s%s%s", cursor, check, coin.Name, current)) + "\n"
 		}
 	}
 
-	s += helpStyle.Render("\n↑/↓: navigate • enter: select • esc: cancel")
+	if m.addingCoin {
+		s += "\n" + m.theme.Label.Render("Binance symbol: ") + m.theme.Value.Render(m.coinInput+"█") + "\n"
+		s += m.theme.Help.Render("\nenter: add • esc: cancel")
+		return m.box(s)
+	}
+	if m.coinError != "" {
+		s += "\n" + m.theme.Error.Render(m.coinError) + "\n"
+	}
+
+	if m.filtering {
+		s += m.theme.Help.Render("\ntype to filter • ↑/↓: navigate • enter: done • esc: clear")
+		return m.box(s)
+	}
+	s += m.theme.Help.Render("\n↑/↓: navigate • space: toggle • enter: select • /: filter • a: add symbol • esc: cancel")
+
+	return m.box(s)
+}
 
-	return boxStyle.Render(s)
+// visibleCoins returns the coins matching the filter, in list order
+func (m model) visibleCoins() []CoinInfo {
+	if m.coinFilter == "" {
+		return m.coins
+	}
+	var visible []CoinInfo
+	for _, coin := range m.coins {
+		if strings.Contains(coin.Symbol, m.coinFilter) || strings.Contains(strings.ToLower(coin.Name), m.coinFilter) {
+			visible = append(visible, coin)
+		}
+	}
+	return visible
 }
 
 func (m model) viewHistory() string {
@@ -411,17 +1397,17 @@ func (m model) viewHistory() string {
 		coinName = "Crypto"
 	}
 
-	s := headerStyle.Render(fmt.Sprintf("◆ %s Trade History (from TimescaleDB)", coinName)) + "\n\n"
+	s := m.theme.Header.Render(fmt.Sprintf("◆ %s Trade History (from TimescaleDB)", coinName)) + "\n\n"
 
 	if len(m.dbHistory) == 0 {
-		s += labelStyle.Render("Loading history...")
+		s += m.theme.Label.Render("Loading history...")
 	} else {
 		// Show header
 		s += fmt.Sprintf("%s  %s  %s\n",
-			labelStyle.Render("Time"),
-			labelStyle.Render("          Price"),
-			labelStyle.Render("Symbol"))
-		s += labelStyle.Render("─────────────────────────────────────────") + "\n"
+			m.theme.Label.Render("Time"),
+			m.theme.Label.Render("          Price"),
+			m.theme.Label.Render("Symbol"))
+		s += m.theme.Label.Render("─────────────────────────────────────────") + "\n"
 
 		// Show trades with scrolling (15 visible)
 		endIdx := m.historyScroll + 15
@@ -432,25 +1418,22 @@ func (m model) viewHistory() string {
 		for i := m.historyScroll; i < endIdx; i++ {
 			trade := m.dbHistory[i]
 			timeStr := trade.Timestamp.Local().Format("15:04:05")
-			priceStr := fmt.Sprintf("$%.2f", trade.Price)
-			if trade.Price < 1 {
-				priceStr = fmt.Sprintf("$%.6f", trade.Price)
-			}
+			priceStr := FormatQuoted(trade.Symbol, trade.Price)
 
 			s += fmt.Sprintf("%s  %s  %s\n",
-				timeStyle.Render(timeStr),
-				valueStyle.Render(fmt.Sprintf("%14s", priceStr)),
-				labelStyle.Render(trade.Symbol))
+				m.theme.Time.Render(timeStr),
+				m.theme.Value.Render(fmt.Sprintf("%14s", priceStr)),
+				m.theme.Label.Render(trade.Symbol))
 		}
 
-		s += labelStyle.Render("─────────────────────────────────────────") + "\n"
-		s += labelStyle.Render(fmt.Sprintf("Showing %d-%d of %d trades",
+		s += m.theme.Label.Render("─────────────────────────────────────────") + "\n"
+		s += m.theme.Label.Render(fmt.Sprintf("Showing %d-%d of %d trades",
 			m.historyScroll+1, endIdx, len(m.dbHistory)))
 	}
 
-	s += helpStyle.Render("\n↑/↓: scroll • r: refresh • esc: back to dashboard")
+	s += m.theme.Help.Render("\n↑/↓: scroll • r: refresh • esc: back to dashboard")
 
-	return boxStyle.Render(s)
+	return m.box(s)
 }
 
 func (m model) viewDashboard() string {
@@ -458,97 +1441,801 @@ func (m model) viewDashboard() string {
 	if m.data.Error != "" {
 		content := fmt.Sprintf(
 			"%s\n\n%s\n\n%s",
-			headerStyle.Render("◆ Trading Pipeline Dashboard"),
-			errorStyle.Render(m.data.Error),
-			helpStyle.Render("Press 'q' to quit"),
+			m.theme.Header.Render("◆ Trading Pipeline Dashboard"),
+			m.theme.Error.Render(m.data.Error),
+			m.theme.Help.Render("Press 'q' to quit"),
 		)
-		return boxStyle.Render(content)
+		return m.box(content)
 	}
 
 	// Waiting for data
 	if !m.data.Connected {
 		content := fmt.Sprintf(
 			"%s\n\n%s\n\n%s",
-			headerStyle.Render("◆ Trading Pipeline Dashboard"),
-			labelStyle.Render("Connecting to server..."),
-			helpStyle.Render("Press 'q' to quit"),
+			m.theme.Header.Render("◆ Trading Pipeline Dashboard"),
+			m.theme.Label.Render("Connecting to server..."),
+			m.theme.Help.Render("Press 'q' to quit"),
 		)
-		return boxStyle.Render(content)
+		return m.box(content)
 	}
 
 	// Switching indicator
 	if m.switching {
 		content := fmt.Sprintf(
 			"%s\n\n%s\n\n%s",
-			headerStyle.Render("◆ Trading Pipeline Dashboard"),
-			labelStyle.Render("Switching coin..."),
-			helpStyle.Render("Please wait..."),
+			m.theme.Header.Render("◆ Trading Pipeline Dashboard"),
+			m.theme.Label.Render("Switching coin..."),
+			m.theme.Help.Render("Please wait..."),
 		)
-		return boxStyle.Render(content)
+		return m.box(content)
 	}
 
-	// Header
+	if len(m.data.Coins) > 1 && m.focus == "" {
+		return m.viewPortfolio()
+	}
+
+	// Header
 	coinName := m.data.CoinName
 	if coinName == "" {
-		coinName = "Crypto"
+		coinName = m.coinName(m.data.Symbol)
+	}
+	header := m.theme.Header.Render(fmt.Sprintf("◆ %s Real-Time Dashboard", coinName) + m.focusTag() + m.unitTag(m.data.Symbol) + m.pausedTag())
+	if banner := m.renderSpikeBanner(); banner != "" {
+		header = banner + "\n" + header
+	}
+	if summary := m.renderSummaryBanner(); summary != "" {
+		header += "\n" + summary
+	}
+	if m.data.Initializing {
+		return m.viewInitializing(header)
 	}
-	header := headerStyle.Render(fmt.Sprintf("◆ %s Real-Time Dashboard", coinName))
 
 	// Price display
-	priceStr := fmt.Sprintf("$%.2f", m.data.Price)
-	if m.data.Price < 1 {
-		priceStr = fmt.Sprintf("$%.6f", m.data.Price)
-	}
+	sym := m.data.Symbol
+	priceStr := FormatQuoted(sym, m.data.Price)
 
 	// Change indicator
 	var changeStr string
 	if m.data.Change > 0 {
-		changeStr = upStyle.Render(fmt.Sprintf("▲ +%.2f (+%.4f%%)", m.data.Change, m.data.ChangePercent))
+		changeStr = m.theme.Up.Render(fmt.Sprintf("▲ +%s (+%.4f%%)", formatPriceDelta(sym, m.data.Change, m.data.Price), m.data.ChangePercent))
 	} else if m.data.Change < 0 {
-		changeStr = downStyle.Render(fmt.Sprintf("▼ %.2f (%.4f%%)", m.data.Change, m.data.ChangePercent))
+		changeStr = m.theme.Down.Render(fmt.Sprintf("▼ %s (%.4f%%)", formatPriceDelta(sym, m.data.Change, m.data.Price), m.data.ChangePercent))
 	} else {
-		changeStr = labelStyle.Render("━ 0.00 (0.00%)")
+		changeStr = m.theme.Label.Render("━ 0.00 (0.00%)")
 	}
 
-	priceDisplay := priceStyle.Render(priceStr) + "  " + changeStr
+	priceDisplay := m.theme.Price.Render(priceStr) + "  " + changeStr
+	if stale := m.staleTag(m.data.Price, m.data.AgeMs); stale != "" {
+		// Grayed out so a frozen number isn't mistaken for a live one
+		priceDisplay = m.theme.Label.Render(priceStr) + "  " + stale
+	}
 
-	// Stats
+	// Stats, with the indicators -indicators selects
+	show := shownIndicators
 	stats := fmt.Sprintf(
-		"%s %s\n%s %s\n%s %s\n%s %s",
-		labelStyle.Render("Moving Avg:"),
-		valueStyle.Render(fmt.Sprintf("$%.2f", m.data.MovingAverage)),
-		labelStyle.Render("Session High:"),
-		upStyle.Render(fmt.Sprintf("$%.2f", m.data.High)),
-		labelStyle.Render("Session Low:"),
-		downStyle.Render(fmt.Sprintf("$%.2f", m.data.Low)),
-		labelStyle.Render("Spread:"),
-		valueStyle.Render(fmt.Sprintf("$%.2f", m.data.High-m.data.Low)),
+		"%s %s %s%s\n%s %s %s%s\n%s %s",
+		m.theme.Label.Render("Session High:"),
+		m.theme.Up.Render(FormatQuoted(sym, m.data.High)),
+		m.renderFromRange("-", m.data.FromHigh),
+		m.renderSetAt(m.data.HighTime),
+		m.theme.Label.Render("Session Low:"),
+		m.theme.Down.Render(FormatQuoted(sym, m.data.Low)),
+		m.renderFromRange("+", m.data.FromLow),
+		m.renderSetAt(m.data.LowTime),
+		m.theme.Label.Render("Spread:"),
+		m.theme.Value.Render(formatQuotedDelta(sym, m.data.High-m.data.Low, m.data.Price)),
+	)
+	if show["ma"] {
+		stats = m.renderMovingAverages() + "\n" + stats
+	}
+	if show["signals"] {
+		stats = m.renderSignals() + "\n" + stats
+	}
+	if show["vwap"] {
+		stats += "\n" + m.renderVWAP()
+	}
+	if show["24h"] {
+		stats += "\n" + m.render24h()
+	}
+	if show["rsi"] {
+		stats += "\n" + m.theme.Label.Render("RSI (14):") + " " + m.renderRSI(m.data.RSI)
+	}
+	if show["macd"] {
+		stats += "\n" + m.theme.Label.Render("MACD (12,26,9):") + " " + m.renderMACD()
+	}
+	if show["bollinger"] {
+		stats += "\n" + m.renderBollinger()
+	}
+	if show["atr"] {
+		stats += "\n" + m.renderATR()
+	}
+	if len(m.data.Alerts) > 0 {
+		armed := 0
+		for _, a := range m.data.Alerts {
+			if !a.Triggered {
+				armed++
+			}
+		}
+		alertStr := m.theme.Value.Render(fmt.Sprintf("%d armed, %d fired", armed, len(m.data.Alerts)-armed))
+		if m.lastAlert != "" {
+			style := m.theme.Price
+			if time.Now().Before(m.flashUntil) {
+				style = style.Reverse(true)
+			}
+			alertStr += "  " + style.Render("⚠ "+m.lastAlert)
+		}
+		stats += "\n" + m.theme.Label.Render("Alerts:") + " " + alertStr
+	}
+
+	stats += "\n" + m.renderPaper(sym)
+
+	if m.showBook {
+		stats += "\n\n" + m.renderOrderBook()
+	}
+	if m.showTape {
+		stats += "\n\n" + m.renderTape()
+	}
+	if m.showEvents {
+		stats += "\n\n" + m.renderEvents()
+	}
+
+	help := "'c': change coin • 'h': view DB history • 'g': chart • space: pause • 'o': order book • 't': trade tape • 'l': events • 'i': indicators • '+'/'-': MA window • 'b'/'s': paper buy/sell • 'r': reset session • 'e': export CSV • 'q': quit"
+	if m.focus != "" {
+		help = "tab/shift+tab: next/previous coin • esc: all coins • " + help
+	}
+	if m.menu != nil {
+		// The menu covers the stats panel while it is open
+		stats, help = m.menu.View(m.theme), indicatorHelp
+	}
+
+	// Combine, growing the sparkline into any spare terminal height
+	render := func(rows int) string {
+		historyTitle, sparkline := "Price History:", m.renderSparkline(rows)
+		if volume := m.renderVolume(); volume != "" {
+			historyTitle, sparkline = "Price & Volume History:", sparkline+"\n"+volume
+		}
+		content := fmt.Sprintf(
+			"%s\n\n%s\n\n%s\n\n%s\n%s\n\n%s\n%s",
+			header,
+			priceDisplay,
+			stats,
+			m.theme.Label.Render(historyTitle),
+			sparkline,
+			m.renderTicks()+"\n"+m.renderFeedStatus(),
+			m.renderHelp(help),
+		)
+		return m.box(content)
+	}
+
+	view := render(1)
+	if m.height > 0 && len(m.history) >= 2 {
+		rows := 1 + m.height - lipgloss.Height(view)
+		if rows > maxSparkRows {
+			rows = maxSparkRows
+		}
+		if rows > 1 {
+			view = render(rows)
+		}
+	}
+	return view
+}
+
+func (m model) viewPortfolio() string {
+	header := m.theme.Header.Render("◆ Portfolio Real-Time Dashboard" + m.unitTag("") + m.pausedTag())
+	if banner := m.renderSpikeBanner(); banner != "" {
+		header = banner + "\n" + header
+	}
+
+	table := m.renderPortfolioTable()
+
+	eventPanel := ""
+	if m.showEvents {
+		eventPanel = "\n\n" + m.renderEvents()
+	}
+	content := fmt.Sprintf(
+		"%s\n\n%s\n%s\n\n%s\n%s",
+		header,
+		table,
+		m.renderPaperTotals()+eventPanel,
+		m.renderFeedStatus(),
+		m.renderHelp("'c': change coins • tab: focus a coin • 'h': view DB history • 'g': chart • 'x': compare • space: pause • 'l': events • 'b'/'s': paper buy/sell • 'r': reset session • 'e': export CSV • 'q': quit"),
 	)
 
-	// Sparkline
-	sparkline := m.renderSparkline()
+	return m.box(content)
+}
 
-	// Combine
+// togglePause freezes or unfreezes the display. Fetching carries on while
+// paused; resuming applies the newest data and reseeds the sparkline with
+// the ticks missed meanwhile instead of replaying every frame.
+func (m model) togglePause() (tea.Model, tea.Cmd) {
+	m.paused = !m.paused
+	if m.paused {
+		return m, nil
+	}
+	latest := m.latest
+	m.latest = nil
+	if latest == nil {
+		return m, nil
+	}
+	m.clearHistory()
+	next, cmd := m.Update(dataMsg(*latest))
+	return next, tea.Batch(cmd, seedSparkline(latest.Symbol))
+}
+
+// pausedTag marks a frozen display in the header
+func (m model) pausedTag() string {
+	if !m.paused {
+		return ""
+	}
+	return "  ⏸ PAUSED (space to resume)"
+}
+
+// placeholder stands in for values the API can't give before a symbol's
+// first price, so zeroes aren't read as real values
+const placeholder = "—"
+
+// viewInitializing is the dashboard before the shown coin's first price:
+// every value is a placeholder, leaving the feed status to say why
+func (m model) viewInitializing(header string) string {
+	dash := m.theme.Label.Render(placeholder)
+	price := m.theme.Label.Render(placeholder) + "  " + m.theme.Label.Render("Initializing... waiting for the first price")
+
+	show := shownIndicators
+	var stats []string
+	for _, row := range [][2]string{{"ma", "Moving Avg:"}, {"", "Session High:"}, {"", "Session Low:"}, {"", "Spread:"}, {"vwap", "VWAP:"},
+		{"24h", "24h:"}, {"rsi", "RSI (14):"}, {"macd", "MACD (12,26,9):"}, {"bollinger", "Bollinger:"}, {"atr", "ATR:"}} {
+		if row[0] == "" || show[row[0]] {
+			stats = append(stats, m.theme.Label.Render(row[1])+" "+dash)
+		}
+	}
+
+	help := "'c': change coin • 'h': view DB history • 'q': quit"
+	if m.focus != "" {
+		help = "tab/shift+tab: next/previous coin • esc: all coins • " + help
+	}
 	content := fmt.Sprintf(
-		"%s\n\n%s\n\n%s\n\n%s%s\n\n%s",
+		"%s\n\n%s\n\n%s\n\n%s\n\n%s",
 		header,
-		priceDisplay,
-		stats,
-		labelStyle.Render("Price History: "),
-		sparkline,
-		helpStyle.Render("'c': change coin • 'h': view DB history • 'q': quit"),
+		price,
+		strings.Join(stats, "\n"),
+		m.renderTicks()+"\n"+m.renderFeedStatus(),
+		m.renderHelp(help),
 	)
+	return m.box(content)
+}
+
+// isStale reports whether a price last updated ageMs ago (-1 for never
+// live) is older than -stale
+func isStale(price float64, ageMs int64) bool {
+	if *staleAfter <= 0 || !(price > 0) {
+		return false
+	}
+	return ageMs < 0 || time.Duration(ageMs)*time.Millisecond > *staleAfter
+}
 
-	return boxStyle.Render(content)
+// staleTag flags a stale price with how long the feed has been quiet,
+// empty while it is fresh
+func (m model) staleTag(price float64, ageMs int64) string {
+	if !isStale(price, ageMs) {
+		return ""
+	}
+	reason := "no live updates yet"
+	if ageMs >= 0 {
+		reason = "no update for " + (time.Duration(ageMs) * time.Millisecond).Round(time.Second).String()
+	}
+	return m.theme.Error.Bold(true).Render("STALE") + " " + m.theme.Label.Render(reason)
 }
 
-func (m model) renderSparkline() string {
-	if len(m.history) < 2 {
-		return labelStyle.Render("waiting for data...")
+// renderSpikeBanner blinks a warning while any tracked symbol is moving
+// sharply, empty otherwise
+func (m model) renderSpikeBanner() string {
+	if len(m.data.Spikes) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, s := range m.data.Spikes {
+		parts = append(parts, fmt.Sprintf("%s %+.2f%% in %s", coinShort(s.Symbol), s.ChangePercent, s.Window))
+	}
+	style := m.theme.Error.Bold(true)
+	if m.flash {
+		style = style.Reverse(true)
 	}
+	return style.Render("⚡ SPIKE " + strings.Join(parts, " • "))
+}
+
+// Quote assets recognized when splitting a symbol for display
+var quoteAssets = []string{"usdt", "usdc", "fdusd", "busd", "btc", "eth", "bnb", "eur", "try"}
 
-	min, max := m.history[0], m.history[0]
-	for _, v := range m.history {
+// splitSymbol splits a symbol like ethbtc into its base and quote assets;
+// quote is empty when no known quote asset ends the symbol
+func splitSymbol(symbol string) (base, quote string) {
+	for _, q := range quoteAssets {
+		if b := strings.TrimSuffix(symbol, q); b != symbol && b != "" {
+			return b, q
+		}
+	}
+	return symbol, ""
+}
+
+// coinShort returns the base asset of a symbol, e.g. ADA for adausdt
+func coinShort(symbol string) string {
+	base, _ := splitSymbol(symbol)
+	return strings.ToUpper(base)
+}
+
+// pairLabel names a symbol by its base asset when it is priced in dollars
+// and as base/quote otherwise, so ETHBTC and ETHUSDT can be told apart
+func pairLabel(symbol string) string {
+	base, quote := splitSymbol(symbol)
+	if dollarQuotes[quote] || quote == "" {
+		return strings.ToUpper(base)
+	}
+	return strings.ToUpper(base + "/" + quote)
+}
+
+// renderMovingAverages shows the requested SMA window, or every window the
+// server maintains (short to long), followed by the EMAs
+func (m model) renderMovingAverages() string {
+	var lines []string
+	if *maWindow > 0 || len(m.data.MovingAverages) < 2 {
+		label := "Moving Avg:"
+		if *maWindow > 0 {
+			label = fmt.Sprintf("Moving Avg (%d):", *maWindow)
+		}
+		lines = append(lines, m.theme.Label.Render(label)+" "+m.theme.Value.Render(FormatQuoted(m.data.Symbol, m.data.MovingAverage)))
+	} else {
+		for _, w := range sortedWindows(m.data.MovingAverages) {
+			lines = append(lines, m.theme.Label.Render(fmt.Sprintf("Moving Avg (%d):", w))+" "+
+				m.theme.Value.Render(FormatQuoted(m.data.Symbol, m.data.MovingAverages[strconv.Itoa(w)])))
+		}
+	}
+
+	for _, p := range sortedWindows(m.data.EMAs) {
+		value := m.data.EMAs[strconv.Itoa(p)]
+		str := m.theme.Label.Render("warming up...")
+		if value >= 0 {
+			str = m.theme.Value.Render(FormatQuoted(m.data.Symbol, value))
+		}
+		lines = append(lines, m.theme.Label.Render(fmt.Sprintf("EMA (%d):", p))+" "+str)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stepWindow returns the -ma-window one '+' (grow) or '-' press away:
+// about 10% wider or narrower, between 1 and the trades the API holds.
+// Stepping from the server's windows starts at its primary one, the
+// average shown; nothing changes until the API holds a trade.
+func (m model) stepWindow(grow bool) int {
+	w := *maWindow
+	if m.data.HistoryLen == 0 {
+		return w
+	}
+	if w == 0 {
+		w = m.primaryWindow()
+	}
+	step := max(w/10, 1)
+	if !grow {
+		step = -step
+	}
+	return min(max(w+step, 1), m.data.HistoryLen)
+}
+
+// primaryWindow returns the server's primary MA window: the Bollinger
+// Bands' period once they are out, else the window averaging to the
+// moving average shown, else the processor's default of 20
+func (m model) primaryWindow() int {
+	if m.data.Bollinger != nil && m.data.Bollinger.Period > 0 {
+		return m.data.Bollinger.Period
+	}
+	for _, w := range sortedWindows(m.data.MovingAverages) {
+		if m.data.MovingAverages[strconv.Itoa(w)] == m.data.MovingAverage {
+			return w
+		}
+	}
+	return 20
+}
+
+// sortedWindows returns the numeric keys of a window-keyed map, ascending
+func sortedWindows(values map[string]float64) []int {
+	windows := make([]int, 0, len(values))
+	for key := range values {
+		if w, err := strconv.Atoi(key); err == nil {
+			windows = append(windows, w)
+		}
+	}
+	sort.Ints(windows)
+	return windows
+}
+
+// Shown in place of what only the trade stream gives: VWAP, volume and the
+// tape
+const needsTrades = "requires trade stream"
+
+// tickerOnly reports whether ingestion follows only the ticker stream, so
+// prices arrive without the trades behind them
+func (m model) tickerOnly() bool {
+	return m.data.FeedStream == "ticker"
+}
+
+// renderVWAP shows the session VWAP and where the price sits relative to it
+func (m model) renderVWAP() string {
+	if m.tickerOnly() {
+		return m.theme.Label.Render("VWAP:") + " " + m.theme.Label.Render(needsTrades)
+	}
+	if m.data.VWAP <= 0 {
+		return m.theme.Label.Render("VWAP:") + " " + m.theme.Label.Render("collecting...")
+	}
+
+	vwap := m.theme.Value.Render(FormatQuoted(m.data.Symbol, m.data.VWAP))
+	switch {
+	case m.data.Price > m.data.VWAP:
+		vwap += " " + m.theme.Up.Render("(above)")
+	case m.data.Price < m.data.VWAP:
+		vwap += " " + m.theme.Down.Render("(below)")
+	}
+	return m.theme.Label.Render("VWAP:") + " " + vwap
+}
+
+// renderFromRange shows how far the price is off a high ("-") or low ("+")
+func (m model) renderFromRange(sign string, percent float64) string {
+	if percent == 0 {
+		return m.theme.Label.Render("(0.00%)")
+	}
+	return m.theme.Label.Render(fmt.Sprintf("(%s%.2f%%)", sign, percent))
+}
+
+// renderSetAt tells when a session extreme was set, empty if unknown
+func (m model) renderSetAt(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return " " + m.theme.Label.Render("at "+time.UnixMilli(ms).Local().Format("15:04"))
+}
+
+// render24h shows the rolling 24h high, low and change
+func (m model) render24h() string {
+	if m.data.High24h == 0 {
+		return m.theme.Label.Render("24h:") + " " + m.theme.Label.Render("collecting...")
+	}
+
+	changeStyle := m.theme.Up
+	sign := "+"
+	if m.data.Change24h < 0 {
+		changeStyle = m.theme.Down
+		sign = ""
+	}
+	return fmt.Sprintf("%s %s %s  %s %s %s\n%s %s",
+		m.theme.Label.Render("24h High:"),
+		m.theme.Up.Render(FormatQuoted(m.data.Symbol, m.data.High24h)),
+		m.renderFromRange("-", m.data.FromHigh24h),
+		m.theme.Label.Render("Low:"),
+		m.theme.Down.Render(FormatQuoted(m.data.Symbol, m.data.Low24h)),
+		m.renderFromRange("+", m.data.FromLow24h),
+		m.theme.Label.Render("24h Change:"),
+		changeStyle.Render(fmt.Sprintf("%s%.2f%%", sign, m.data.Change24h)),
+	)
+}
+
+// Order book levels shown per side and the widest depth bar, in cells
+const (
+	bookLevels   = 5
+	bookBarWidth = 20
+)
+
+// renderOrderBook draws asks above bids, each level with a bar scaled to
+// the largest quantity shown
+func (m model) renderOrderBook() string {
+	book := m.data.OrderBook
+	title := m.theme.Label.Render("Order Book:")
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return title + " " + m.theme.Label.Render("no depth data")
+	}
+
+	maxQty := 0.0
+	for _, l := range append(append([]BookLevel{}, book.Bids...), book.Asks...) {
+		maxQty = math.Max(maxQty, l.Quantity)
+	}
+	row := func(l BookLevel, style lipgloss.Style) string {
+		cells := int(math.Round(l.Quantity / maxQty * bookBarWidth))
+		if cells < 1 {
+			cells = 1
+		}
+		return fmt.Sprintf("%s %s %s",
+			style.Render(fmt.Sprintf("%14s", formatUnitPrice(m.data.Symbol, l.Price))),
+			style.Render(fmt.Sprintf("%-*s", bookBarWidth, strings.Repeat("█", cells))),
+			m.theme.Label.Render(fmt.Sprintf("%.4f", l.Quantity)))
+	}
+
+	lines := []string{title}
+	for i := len(book.Asks) - 1; i >= 0; i-- {
+		lines = append(lines, row(book.Asks[i], m.theme.Down))
+	}
+	lines = append(lines, m.theme.Label.Render(fmt.Sprintf("%14s spread %s (%.3f%%)", "", formatQuotedDelta(m.data.Symbol, book.Spread, book.Bids[0].Price), book.SpreadP)))
+	for _, l := range book.Bids {
+		lines = append(lines, row(l, m.theme.Up))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Fraction of the band width within which price counts as riding a band
+const bandRideTolerance = 0.05
+
+// renderBollinger shows the bands and flags price riding either of them
+func (m model) renderBollinger() string {
+	bb := m.data.Bollinger
+	if bb == nil {
+		return m.theme.Label.Render("Bollinger:") + " " + m.theme.Label.Render("collecting...")
+	}
+
+	label := m.theme.Label.Render(fmt.Sprintf("Bollinger (%d, %gσ):", bb.Period, bb.K))
+	bands := fmt.Sprintf("%s %s %s",
+		m.theme.Up.Render(FormatQuoted(m.data.Symbol, bb.Upper)),
+		m.theme.Label.Render("/"),
+		m.theme.Down.Render(FormatQuoted(m.data.Symbol, bb.Lower)),
+	)
+
+	tolerance := (bb.Upper - bb.Lower) * bandRideTolerance
+	switch {
+	case bb.Upper > bb.Lower && m.data.Price >= bb.Upper-tolerance:
+		bands += " " + m.theme.Up.Render("▲ riding upper band")
+	case bb.Upper > bb.Lower && m.data.Price <= bb.Lower+tolerance:
+		bands += " " + m.theme.Down.Render("▼ riding lower band")
+	}
+	return label + " " + bands
+}
+
+// renderATR shows the average true range, also as a percent of the price
+// for sizing positions across coins
+func (m model) renderATR() string {
+	atr := m.data.ATR
+	if atr == nil {
+		return m.theme.Label.Render("ATR:") + " " + m.theme.Label.Render("collecting candles...")
+	}
+
+	str := m.theme.Value.Render(formatQuotedDelta(m.data.Symbol, atr.Value, m.data.Price))
+	if m.data.Price > 0 {
+		str += " " + m.theme.Label.Render(fmt.Sprintf("(%.3f%%)", atr.Value/m.data.Price*100))
+	}
+	return m.theme.Label.Render(fmt.Sprintf("ATR (%d × %s):", atr.Period, atr.Interval)) + " " + str
+}
+
+// Widest MACD histogram bar, in cells
+const macdBarWidth = 10
+
+// renderMACD shows MACD and signal with the histogram as a bar scaled to
+// the largest recent histogram value; green while positive and rising
+func (m model) renderMACD() string {
+	macd := m.data.MACD
+	if macd == nil {
+		return m.theme.Label.Render("collecting...")
+	}
+
+	maxAbs := math.Abs(macd.Histogram)
+	for _, h := range m.macdHist {
+		maxAbs = math.Max(maxAbs, math.Abs(h))
+	}
+	cells := 0
+	if maxAbs > 0 {
+		cells = int(math.Round(math.Abs(macd.Histogram) / maxAbs * macdBarWidth))
+	}
+	if cells == 0 {
+		cells = 1
+	}
+
+	rising := len(m.macdHist) < 2 || macd.Histogram >= m.macdHist[len(m.macdHist)-2]
+	style := m.theme.Down
+	if macd.Histogram > 0 && rising {
+		style = m.theme.Up
+	}
+
+	return fmt.Sprintf("%s %s %s %s",
+		m.theme.Value.Render(fmt.Sprintf("%.4g", macd.MACD)),
+		m.theme.Label.Render(fmt.Sprintf("signal %.4g", macd.Signal)),
+		style.Render(strings.Repeat("█", cells)),
+		style.Render(fmt.Sprintf("%+.4g", macd.Histogram)),
+	)
+}
+
+// Time without an exchange message after which the feed counts as lagging
+const feedLagThreshold = 5 * time.Second
+
+// renderFeedStatus is the footer status line: the primary symbol's feed
+// state, message rate, time since the last exchange message and ping round
+// trip, then the API address
+func (m model) renderFeedStatus() string {
+	var state string
+	switch m.data.FeedState {
+	case "connected":
+		state = m.theme.Up.Render("● connected")
+	case "reconnecting":
+		state = m.theme.Price.Render("◌ reconnecting")
+	case "down":
+		state = m.theme.Down.Render("○ down")
+	case "unavailable":
+		// Silent across reconnects while the network is fine: likely a
+		// delisted or halted pair rather than an outage
+		state = m.theme.Down.Render("⊘ pair may be delisted or unavailable") + m.theme.Label.Render(" (press 'c' to switch coins)")
+	case "failed":
+		// Ingestion ran out of MAX_RECONNECTS and either exited or stopped
+		// retrying
+		state = m.theme.Error.Render("✖ gave up reconnecting")
+	default:
+		state = m.theme.Label.Render("? unknown")
+	}
+	parts := []string{m.theme.Label.Render(feedLabel(m.data.Exchange)) + " " + state}
+
+	if m.data.FeedState == "connected" {
+		parts = append(parts, m.theme.Value.Render(fmt.Sprintf("%.1f msg/s", m.data.FeedRate)))
+		if m.data.FeedLast > 0 {
+			since := time.Since(time.UnixMilli(m.data.FeedLast))
+			style := m.theme.Value
+			if since > feedLagThreshold {
+				style = m.theme.Down
+			}
+			parts = append(parts, m.theme.Label.Render("last")+" "+style.Render(since.Round(100*time.Millisecond).String()+" ago"))
+		}
+		if m.data.FeedRTTMs > 0 {
+			parts = append(parts, m.theme.Label.Render("ping")+" "+m.theme.Value.Render(fmt.Sprintf("%.0fms", m.data.FeedRTTMs)))
+		}
+	}
+
+	line := strings.Join(parts, m.theme.Label.Render(" • "))
+	if m.data.APIPort > 0 {
+		line += "  " + m.theme.Label.Render("API:") + " " + m.theme.Value.Render(fmt.Sprintf("localhost:%d", m.data.APIPort))
+	}
+	return line
+}
+
+// renderTicks shows how long ago the symbol last traded and how often it
+// has lately, telling an illiquid pair apart from a dead feed
+func (m model) renderTicks() string {
+	label := m.theme.Label.Render("Ticks:")
+	if m.data.AgeMs < 0 {
+		return label + " " + m.theme.Label.Render("none yet")
+	}
+	since := (time.Duration(m.data.AgeMs) * time.Millisecond).Round(100 * time.Millisecond)
+	style := m.theme.Value
+	if isStale(m.data.Price, m.data.AgeMs) {
+		style = m.theme.Down
+	}
+	return label + " " + m.theme.Label.Render("last") + " " + style.Render(since.String()+" ago") +
+		m.theme.Label.Render(" • ") + m.theme.Value.Render(fmt.Sprintf("%d/min", m.data.TicksPerMin))
+}
+
+// feedLabel names the feed after the exchange the ingestion service uses
+func feedLabel(exchange string) string {
+	if exchange == "" {
+		return "Feed:"
+	}
+	return strings.ToUpper(exchange[:1]) + exchange[1:] + " Feed:"
+}
+
+// renderRSI colors RSI red when overbought and green when oversold
+func (m model) renderRSI(rsi float64) string {
+	if rsi < 0 {
+		return m.theme.Label.Render("warming up...")
+	}
+
+	str := fmt.Sprintf("%.1f", rsi)
+	switch {
+	case rsi > 70:
+		return m.theme.Down.Render(str + " overbought")
+	case rsi < 30:
+		return m.theme.Up.Render(str + " oversold")
+	default:
+		return m.theme.Value.Render(str)
+	}
+}
+
+// Polls a MACD cross stays flagged for, so it is on screen long enough to
+// be seen
+const macdCrossPolls = 5
+
+// renderSignals sums up RSI and MACD as colored cells: RSI oversold,
+// neutral or overbought, MACD bullish or bearish, flagging a fresh cross
+func (m model) renderSignals() string {
+	cell := func(style lipgloss.Style, text string) string {
+		return style.Reverse(true).Render(" " + text + " ")
+	}
+
+	var rsi string
+	switch r := m.data.RSI; {
+	case r < 0:
+		rsi = cell(m.theme.Label, "RSI warming up")
+	case r > 70:
+		rsi = cell(m.theme.Down, "RSI overbought")
+	case r < 30:
+		rsi = cell(m.theme.Up, "RSI oversold")
+	default:
+		rsi = cell(m.theme.Value, "RSI neutral")
+	}
+
+	var macd string
+	if m.data.MACD == nil {
+		macd = cell(m.theme.Label, "MACD collecting")
+	} else {
+		h := m.data.MACD.Histogram
+		crossed := false
+		for i := max(0, len(m.macdHist)-1-macdCrossPolls); i < len(m.macdHist)-1; i++ {
+			crossed = crossed || (m.macdHist[i] > 0) != (h > 0)
+		}
+		text, style := "MACD bearish", m.theme.Down
+		if h > 0 {
+			text, style = "MACD bullish", m.theme.Up
+		}
+		if crossed {
+			text += " cross"
+		}
+		macd = cell(style, text)
+	}
+
+	return m.theme.Label.Render("Signals:") + " " + rsi + " " + macd
+}
+
+// renderHelp shows the key help, preceded by the last export result, or
+// the session reset prompt while it waits for an answer
+func (m model) renderHelp(help string) string {
+	if m.confirmReset {
+		help = "Reset session high/low and VWAP for every coin? 'y': reset • any other key: cancel"
+	}
+	var status []string
+	for _, s := range []string{m.paperStatus, m.exportStatus, m.resetStatus, m.menuStatus} {
+		if s != "" {
+			status = append(status, m.theme.Label.Render(s))
+		}
+	}
+	if len(status) == 0 {
+		return m.theme.Help.Render(help)
+	}
+	return strings.Join(status, "\n") + "\n" + m.theme.Help.Render(help)
+}
+
+// box wraps content in the bordered box, stretched to the terminal width
+// once it is known
+func (m model) box(content string) string {
+	if *asciiOnly {
+		content = asciify(content)
+	}
+	if m.width > 2 {
+		return m.theme.Box.Width(m.width - 2).Render(content)
+	}
+	return m.theme.Box.Render(content)
+}
+
+// sparkWidth returns how many points a sparkline row shows: -spark-points,
+// or as many as fit
+func (m model) sparkWidth() int {
+	n := defaultSparkWidth
+	if m.width > 0 {
+		n = min(max(m.width-boxChrome, minSparkWidth), maxSparkWidth)
+	}
+	if *sparkPoints > 0 {
+		n = min(n, *sparkPoints)
+	}
+	return n
+}
+
+// renderSparkline draws the recent history rows lines tall, one column per point
+func (m model) renderSparkline(rows int) string {
+	return m.renderSeries(m.history, m.volatility, rows)
+}
+
+// renderSeries draws the newest points that fit as a sparkline rows lines
+// tall, shading moves against volatility
+func (m model) renderSeries(points []float64, volatility float64, rows int) string {
+	if len(points) < 2 {
+		return m.theme.Label.Render("waiting for data...")
+	}
+	if rows < 1 {
+		rows = 1
+	}
+
+	if n := m.sparkWidth(); len(points) > n {
+		points = points[len(points)-n:]
+	}
+
+	min, max := points[0], points[0]
+	for _, v := range points {
 		if v < min {
 			min = v
 		}
@@ -558,37 +2245,281 @@ func (m model) renderSparkline() string {
 	}
 
 	chars := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+	levels := rows * len(chars)
 
-	var spark string
 	rang := max - min
 	if rang == 0 {
 		rang = 1
 	}
 
-	for i, v := range m.history {
-		normalized := (v - min) / rang
-		idx := int(normalized * float64(len(chars)-1))
-		if idx >= len(chars) {
-			idx = len(chars) - 1
+	// Bar height of each point in eighths of a row, at least one eighth
+	heights := make([]int, len(points))
+	for i, v := range points {
+		heights[i] = 1 + int((v-min)/rang*float64(levels-1))
+		if heights[i] > levels {
+			heights[i] = levels
 		}
+	}
 
-		char := string(chars[idx])
-		if i > 0 && v > m.history[i-1] {
-			spark += upStyle.Render(char)
-		} else if i > 0 && v < m.history[i-1] {
-			spark += downStyle.Render(char)
-		} else {
-			spark += valueStyle.Render(char)
+	styles := m.sparkStyles(points, volatility)
+	lines := make([]string, rows)
+	for row := 0; row < rows; row++ {
+		base := (rows - 1 - row) * len(chars) // eighths below this row
+		var line strings.Builder
+		for i, h := range heights {
+			char := " "
+			if fill := h - base; fill >= len(chars) {
+				char = string(chars[len(chars)-1])
+			} else if fill > 0 {
+				char = string(chars[fill-1])
+			}
+
+			line.WriteString(styles[i].Render(char))
 		}
+		lines[row] = line.String()
 	}
 
-	return spark
+	return strings.Join(lines, "\n")
+}
+
+// sparkStyles picks a style per point: green/red by direction, shaded by
+// the size of the move relative to volatility in volatility mode
+func (m model) sparkStyles(points []float64, volatility float64) []lipgloss.Style {
+	styles := make([]lipgloss.Style, len(points))
+	for i := range points {
+		switch {
+		case i == 0 || points[i] == points[i-1]:
+			styles[i] = m.theme.Value
+		case *sparkColor != "volatility" || volatility == 0:
+			if points[i] > points[i-1] {
+				styles[i] = m.theme.Up
+			} else {
+				styles[i] = m.theme.Down
+			}
+		default:
+			ret := (points[i] - points[i-1]) / points[i-1]
+			bucket := volatilityBucket(math.Abs(ret) / volatility)
+			if ret > 0 {
+				styles[i] = m.theme.UpShades[bucket]
+			} else {
+				styles[i] = m.theme.DownShades[bucket]
+			}
+		}
+	}
+	return styles
+}
+
+// volatilityBucket maps a move measured in standard deviations to a shade:
+// under 0.5σ, under 1σ, under 2σ, and 2σ or more
+func volatilityBucket(sigmas float64) int {
+	switch {
+	case sigmas < 0.5:
+		return 0
+	case sigmas < 1:
+		return 1
+	case sigmas < 2:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// returnVolatility returns the standard deviation of tick-to-tick returns
+func returnVolatility(prices []float64) float64 {
+	var returns []float64
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] > 0 {
+			returns = append(returns, (prices[i]-prices[i-1])/prices[i-1])
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)))
 }
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	flag.Parse()
+
+	cmdline := commandLineFlags()
+	path, required := *configPath, true
+	if path == "" {
+		path, required = defaultConfigPath(), false
+	}
+	settingsPath = path
+	settings := func() error {
+		if err := loadSettings(path, required, cmdline); err != nil {
+			return err
+		}
+		if *quiet && !cmdline["log-level"] {
+			*logLevel = "error"
+		}
+		return nil
+	}
+	if err := settings(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: config: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *refresh < minRefresh {
+		fmt.Fprintf(os.Stderr, "Error: -refresh must be at least %v\n", minRefresh)
+		os.Exit(2)
+	}
+	if *onceTimeout <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -once-timeout must be positive\n")
+		os.Exit(2)
+	}
+	if *sparkPoints < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -spark-points must not be negative\n")
+		os.Exit(2)
+	}
+	if *historyPoints < 2 {
+		fmt.Fprintf(os.Stderr, "Error: -history must be at least 2\n")
+		os.Exit(2)
+	}
+	if *staleAfter < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -stale must not be negative\n")
+		os.Exit(2)
+	}
+	if *sparkColor != "volatility" && *sparkColor != "direction" {
+		fmt.Fprintf(os.Stderr, "Error: -spark-colors must be volatility or direction\n")
+		os.Exit(2)
+	}
+	if *apiPort < 1 || *apiPort > 65535 {
+		fmt.Fprintf(os.Stderr, "Error: -port must be 1 to 65535\n")
+		os.Exit(2)
+	}
+	serverURL = fmt.Sprintf("http://localhost:%d", *apiPort)
+	if !(*tradeQty > 0) {
+		fmt.Fprintf(os.Stderr, "Error: -trade-qty must be positive\n")
+		os.Exit(2)
+	}
+	theme, err := themeByName(*themeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -theme: %v\n", err)
+		os.Exit(2)
+	}
+	if !cmdline["ascii"] && !*asciiOnly {
+		*asciiOnly = !unicodeTerminal()
+	}
+	if *asciiOnly {
+		theme.Box = theme.Box.Border(asciiBorder)
+	}
+	if err := validateNotify(*notifyBackends, *notifyWebhook, *notifySlack, *telegramToken, *telegramChat); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -notify: %v\n", err)
+		os.Exit(2)
+	}
+	if _, ok := numberFormats[*locale]; !ok {
+		fmt.Fprintf(os.Stderr, "Error: -locale must be plain, en, de, fr or ch\n")
+		os.Exit(2)
+	}
+	if _, err := parseColumns(*columnList); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -columns: %v\n", err)
+		os.Exit(2)
+	}
+	*displayUnit = strings.ToLower(*displayUnit)
+	if !validUnit(*displayUnit) {
+		fmt.Fprintf(os.Stderr, "Error: -unit must be sats or a symbol like btcusdt\n")
+		os.Exit(2)
+	}
+
+	// Logs written to the terminal would draw over the dashboard
+	interactive := !*headless && *exportPath == "" && !*once && !*listCoinsFlag && !*selectOnly && isatty.IsTerminal(os.Stdout.Fd())
+	if err := setupLogging(*logLevel, *logFile, interactive && isatty.IsTerminal(os.Stderr.Fd())); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *apiToken != "" {
+		http.DefaultClient.Transport = tokenTransport{token: *apiToken, base: http.DefaultTransport}
+	}
+
+	symbols := parseSymbols(*symbolFlag)
+	if *listCoinsFlag {
+		if err := listCoins(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *selectOnly {
+		if len(symbols) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: -select needs -symbol")
+			os.Exit(2)
+		}
+		if err := postSymbols(symbols); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		inform("Tracking %s", strings.Join(symbols, ", "))
+		return
+	}
+	if *exportPath != "" {
+		symbol := ""
+		if len(symbols) > 0 {
+			symbol = symbols[0]
+		}
+		rows, err := exportHistory(symbol, *exportPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		inform("Exported %d trades to %s", rows, *exportPath)
+		return
+	}
+	if *once {
+		if err := runOnce(symbols); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !interactive {
+		if len(symbols) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: -symbol is required in headless mode")
+			os.Exit(2)
+		}
+		if err := runHeadless(symbols, settings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	m := initialModel(theme)
+	if len(symbols) > 0 {
+		if err := postSymbols(symbols); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		m.mode = dashboardView
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// inform prints a confirmation line to stdout, or with -quiet logs it at
+// debug level instead
+func inform(format string, args ...any) {
+	if *quiet {
+		slog.Debug(fmt.Sprintf(format, args...))
+		return
+	}
+	fmt.Printf(format+"\n", args...)
+}
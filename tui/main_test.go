@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestStepWindowStartsAtPrimary(t *testing.T) {
+	defer func(w int) { *maWindow = w }(*maWindow)
+	*maWindow = 0
+
+	tests := []struct {
+		name string
+		data DashboardData
+		grow bool
+		want int
+	}{
+		{
+			name: "primary by moving average",
+			data: DashboardData{HistoryLen: 500, MovingAverage: 101, MovingAverages: map[string]float64{"10": 102, "50": 101, "200": 99}},
+			grow: true,
+			want: 55,
+		},
+		{
+			name: "primary by Bollinger period",
+			data: DashboardData{HistoryLen: 500, MovingAverage: 100, MovingAverages: map[string]float64{"10": 100, "50": 100}, Bollinger: &BollingerInfo{Period: 50}},
+			grow: false,
+			want: 45,
+		},
+		{
+			name: "default window",
+			data: DashboardData{HistoryLen: 500},
+			grow: true,
+			want: 22,
+		},
+		{
+			name: "capped at the history held",
+			data: DashboardData{HistoryLen: 52, MovingAverage: 101, MovingAverages: map[string]float64{"10": 102, "50": 101}},
+			grow: true,
+			want: 52,
+		},
+		{
+			name: "no history yet",
+			data: DashboardData{MovingAverage: 101, MovingAverages: map[string]float64{"50": 101}},
+			grow: true,
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{data: tt.data}
+			if got := m.stepWindow(tt.grow); got != tt.want {
+				t.Errorf("stepWindow(%v) = %d, want %d", tt.grow, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gen2brain/beeep"
+)
+
+var (
+	notifyBackends = flag.String("notify", "", "comma-separated alert backends: desktop, webhook, slack, telegram (default desktop, none when headless)")
+	notifyWebhook  = flag.String("notify-webhook", "", "URL the webhook backend POSTs each fired alert to as JSON")
+	notifySlack    = flag.String("notify-slack", "", "Slack incoming webhook URL for the slack backend")
+	telegramToken  = flag.String("notify-telegram-token", "", "bot token for the telegram backend")
+	telegramChat   = flag.String("notify-telegram-chat", "", "chat ID the telegram backend messages")
+)
+
+// Backends -notify accepts
+var notifyNames = []string{"desktop", "webhook", "slack", "telegram"}
+
+// Telegram Bot API base URL
+var telegramAPI = "https://api.telegram.org"
+
+// Notifications are tried notifyAttempts times, waiting notifyBackoff
+// before the first retry and doubling it after each; notifyDeadline bounds
+// one backend's delivery, retries included
+const (
+	notifyAttempts = 3
+	notifyBackoff  = 500 * time.Millisecond
+	notifyDeadline = time.Minute
+)
+
+// notifyClient is shared by the HTTP backends
+var notifyClient = &http.Client{Timeout: 10 * time.Second}
+
+// alertNotice is a fired alert as the backends deliver it, and the JSON
+// the webhook backend posts. A feed notice leaves the alert's fields zero.
+type alertNotice struct {
+	Kind      string  `json:"kind"` // "price" for a fired alert, "feed" when ingestion gave up reconnecting
+	ID        int     `json:"id"`
+	Symbol    string  `json:"symbol"`
+	Direction string  `json:"direction"`
+	Threshold float64 `json:"threshold"`
+	Price     float64 `json:"price"`
+	Fires     int     `json:"fires"`
+	Message   string  `json:"message"`
+	Time      int64   `json:"time"` // unix ms the client saw the fire
+}
+
+func newAlertNotice(a AlertInfo) alertNotice {
+	return alertNotice{
+		Kind:      "price",
+		ID:        a.ID,
+		Symbol:    a.Symbol,
+		Direction: a.Direction,
+		Threshold: a.Threshold,
+		Price:     a.TriggerPrice,
+		Fires:     a.Fires,
+		Message:   fmt.Sprintf("%s is %s %s (now %s)", strings.ToUpper(a.Symbol), a.Direction, FormatPrice(a.Symbol, a.Threshold), FormatPrice(a.Symbol, a.TriggerPrice)),
+		Time:      time.Now().UnixMilli(),
+	}
+}
+
+// newFeedNotice reports ingestion giving up on symbol's exchange feed after
+// its reconnects ran out
+func newFeedNotice(symbol, exchange string) alertNotice {
+	return alertNotice{
+		Kind:    "feed",
+		Symbol:  symbol,
+		Message: fmt.Sprintf("%s feed for %s gave up reconnecting", feedLabel(exchange), strings.ToUpper(symbol)),
+		Time:    time.Now().UnixMilli(),
+	}
+}
+
+// title heads the notice in the backends that show one
+func (n alertNotice) title() string {
+	if n.Kind == "feed" {
+		return "Feed alert"
+	}
+	return "Price alert"
+}
+
+// notifier delivers fired alerts somewhere a user will see them
+type notifier interface {
+	name() string
+	notify(ctx context.Context, n alertNotice) error
+}
+
+// desktopNotifier raises a desktop notification
+type desktopNotifier struct{}
+
+func (desktopNotifier) name() string { return "desktop" }
+
+func (desktopNotifier) notify(ctx context.Context, n alertNotice) error {
+	return beeep.Notify(n.title()+": "+coinShort(n.Symbol), n.Message, "")
+}
+
+// webhookNotifier POSTs the alertNotice as JSON
+type webhookNotifier struct {
+	url string
+}
+
+func (webhookNotifier) name() string { return "webhook" }
+
+func (w webhookNotifier) notify(ctx context.Context, n alertNotice) error {
+	return postJSON(ctx, w.url, n)
+}
+
+// slackNotifier posts to a Slack incoming webhook
+type slackNotifier struct {
+	url string
+}
+
+func (slackNotifier) name() string { return "slack" }
+
+func (s slackNotifier) notify(ctx context.Context, n alertNotice) error {
+	return postJSON(ctx, s.url, map[string]string{"text": ":rotating_light: " + n.title() + ": " + n.Message})
+}
+
+// telegramNotifier messages a chat through a Telegram bot
+type telegramNotifier struct {
+	token, chat string
+}
+
+func (telegramNotifier) name() string { return "telegram" }
+
+func (t telegramNotifier) notify(ctx context.Context, n alertNotice) error {
+	err := postJSON(ctx, telegramAPI+"/bot"+t.token+"/sendMessage", map[string]string{
+		"chat_id": t.chat,
+		"text":    n.title() + ": " + n.Message,
+	})
+	// The token is part of the URL, which transport errors quote
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = telegramAPI + "/bot(hidden)/sendMessage"
+	}
+	return err
+}
+
+// notifiers builds the -notify backends, read afresh for each alert so a
+// headless config reload applies at once. Without -notify the dashboard
+// notifies the desktop and headless mode notifies nothing.
+func notifiers() []notifier {
+	names := *notifyBackends
+	if names == "" {
+		if *headless {
+			return nil
+		}
+		names = "desktop"
+	}
+	var list []notifier
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "desktop":
+			list = append(list, desktopNotifier{})
+		case "webhook":
+			list = append(list, webhookNotifier{url: *notifyWebhook})
+		case "slack":
+			list = append(list, slackNotifier{url: *notifySlack})
+		case "telegram":
+			list = append(list, telegramNotifier{token: *telegramToken, chat: *telegramChat})
+		}
+	}
+	return list
+}
+
+// validateNotify checks every backend in names is known and has the
+// settings it needs, given as (webhook, slack, telegram token, chat)
+func validateNotify(names, webhook, slack, token, chat string) error {
+	if names == "" {
+		return nil
+	}
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "desktop":
+		case "webhook":
+			if webhook == "" {
+				return fmt.Errorf("the webhook backend needs a webhook URL")
+			}
+		case "slack":
+			if slack == "" {
+				return fmt.Errorf("the slack backend needs a Slack webhook URL")
+			}
+		case "telegram":
+			if token == "" || chat == "" {
+				return fmt.Errorf("the telegram backend needs a bot token and chat ID")
+			}
+		default:
+			return fmt.Errorf("unknown backend %q (%s)", name, strings.Join(notifyNames, ", "))
+		}
+	}
+	return nil
+}
+
+// dispatchAlert delivers a to every backend at once and waits for them
+func dispatchAlert(backends []notifier, a AlertInfo) {
+	dispatchNotice(backends, newAlertNotice(a))
+}
+
+// dispatchNotice delivers n to every backend at once and waits for them,
+// logging any that still fail after their retries
+func dispatchNotice(backends []notifier, n alertNotice) {
+	var wg sync.WaitGroup
+	for _, b := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), notifyDeadline)
+			defer cancel()
+			if err := b.notify(ctx, n); err != nil {
+				slog.Warn("Alert notification failed", "backend", b.name(), "kind", n.Kind, "symbol", n.Symbol, "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// postJSON POSTs body as JSON to target, retrying network errors, 5xx and
+// 429 responses
+func postJSON(ctx context.Context, target string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	backoff := notifyBackoff
+	for attempt := 1; ; attempt++ {
+		err = postOnce(ctx, target, payload)
+		if err == nil || attempt == notifyAttempts || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// statusError is a response postJSON treats as a failure
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e statusError) Error() string { return e.status }
+
+// isRetryable reports whether a post failing with err may succeed if
+// repeated: network errors, 429 and 5xx
+func isRetryable(err error) bool {
+	se, ok := err.(statusError)
+	return !ok || se.code == http.StatusTooManyRequests || se.code >= 500
+}
+
+func postOnce(ctx context.Context, target string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// hookServer records the JSON bodies posted to it, answering with
+// statuses in turn and 200 after them
+type hookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   []map[string]any
+	paths    []string
+	statuses []int
+}
+
+func newHookServer(t *testing.T, statuses ...int) *hookServer {
+	t.Helper()
+	h := &hookServer{statuses: statuses}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.bodies = append(h.bodies, body)
+		h.paths = append(h.paths, r.URL.Path)
+		if n := len(h.bodies); n <= len(h.statuses) {
+			w.WriteHeader(h.statuses[n-1])
+		}
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func (h *hookServer) calls() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.bodies)
+}
+
+func testNotice() alertNotice {
+	return alertNotice{Kind: "price", ID: 3, Symbol: "btcusdt", Direction: "above", Threshold: 70000, Price: 70012.5, Fires: 1, Message: "BTCUSDT is above 70000.00", Time: 1700000000000}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		calls    int
+		ok       bool
+	}{
+		{"delivered", nil, 1, true},
+		{"retried after a 5xx", []int{http.StatusServiceUnavailable}, 2, true},
+		{"retried when rate limited", []int{http.StatusTooManyRequests, http.StatusBadGateway}, 3, true},
+		{"retries run out", []int{500, 500, 500, 500}, notifyAttempts, false},
+		{"rejected", []int{http.StatusBadRequest}, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHookServer(t, tt.statuses...)
+			n := testNotice()
+			err := webhookNotifier{url: h.URL}.notify(context.Background(), n)
+			if (err == nil) != tt.ok || h.calls() != tt.calls {
+				t.Fatalf("err %v after %d posts, want ok %v after %d", err, h.calls(), tt.ok, tt.calls)
+			}
+
+			// Every attempt carries the whole notice
+			for i, body := range h.bodies {
+				data, _ := json.Marshal(body)
+				var got alertNotice
+				if err := json.Unmarshal(data, &got); err != nil || got != n {
+					t.Errorf("post %d = %s, want %+v", i, data, n)
+				}
+			}
+		})
+	}
+}
+
+func TestChatNotifiers(t *testing.T) {
+	h := newHookServer(t)
+	defer func(api string) { telegramAPI = api }(telegramAPI)
+	telegramAPI = h.URL
+
+	n := testNotice()
+	dispatchNotice([]notifier{slackNotifier{url: h.URL + "/slack"}, telegramNotifier{token: "123:abc", chat: "42"}}, n)
+	if h.calls() != 2 {
+		t.Fatalf("%d posts, want one per backend", h.calls())
+	}
+	for i, path := range h.paths {
+		body := h.bodies[i]
+		switch path {
+		case "/slack":
+			if text, _ := body["text"].(string); !strings.Contains(text, n.Message) {
+				t.Errorf("slack text %q", text)
+			}
+		case "/bot123:abc/sendMessage":
+			if text, _ := body["text"].(string); body["chat_id"] != "42" || !strings.Contains(text, n.Message) {
+				t.Errorf("telegram body %v", body)
+			}
+		default:
+			t.Errorf("post to %s", path)
+		}
+	}
+}
+
+func TestTelegramErrorHidesToken(t *testing.T) {
+	h := newHookServer(t)
+	h.Close()
+	defer func(api string) { telegramAPI = api }(telegramAPI)
+	telegramAPI = h.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := telegramNotifier{token: "123:secret", chat: "42"}.notify(ctx, testNotice())
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("error %v, want one without the bot token", err)
+	}
+}
+
+func TestValidateNotify(t *testing.T) {
+	tests := []struct {
+		names, webhook, slack, token, chat string
+		ok                                 bool
+	}{
+		{"", "", "", "", "", true},
+		{"desktop", "", "", "", "", true},
+		{"webhook", "", "", "", "", false},
+		{"desktop, webhook", "http://hook", "", "", "", true},
+		{"slack", "", "", "", "", false},
+		{"telegram", "", "", "token", "", false},
+		{"telegram", "", "", "token", "42", true},
+		{"pager", "", "", "", "", false},
+	}
+	for _, tt := range tests {
+		if err := validateNotify(tt.names, tt.webhook, tt.slack, tt.token, tt.chat); (err == nil) != tt.ok {
+			t.Errorf("validateNotify(%q) = %v, want ok %v", tt.names, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runOnce waits for a price for each of symbols (the primary one when
+// empty) and prints its /api/stats as one JSON line apiece to stdout. It
+// fails when a symbol isn't tracked or has no price within -once-timeout.
+// The API's tracked symbols are left alone.
+func runOnce(symbols []string) error {
+	deadline := time.Now().Add(*onceTimeout)
+
+	tracked, err := trackedSymbols()
+	if err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		if len(tracked) == 0 {
+			return fmt.Errorf("the API is not tracking any symbol")
+		}
+		symbols = tracked[:1]
+	}
+	for _, symbol := range symbols {
+		if !slices.Contains(tracked, symbol) {
+			return fmt.Errorf("%s is not tracked by the API", symbol)
+		}
+	}
+
+	for _, symbol := range symbols {
+		stats, err := waitForStats(symbol, deadline)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(stats))
+	}
+	return nil
+}
+
+// trackedSymbols returns the symbols the API tracks, the primary first
+func trackedSymbols() ([]string, error) {
+	resp, err := http.Get(serverURL + "/api/symbol")
+	if err != nil {
+		return nil, fmt.Errorf("server not running on port %d", *apiPort)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("API requires a token, pass -api-token")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("symbol request failed: %s", resp.Status)
+	}
+
+	var info SymbolResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("decoding symbol: %w", err)
+	}
+	if len(info.Symbols) == 0 && info.Symbol != "" {
+		return []string{info.Symbol}, nil
+	}
+	return info.Symbols, nil
+}
+
+// waitForStats polls symbol's stats every -refresh until they carry a
+// price, returning them compacted, or fails at deadline
+func waitForStats(symbol string, deadline time.Time) ([]byte, error) {
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	if *maWindow > 0 {
+		query.Set("ma_window", strconv.Itoa(*maWindow))
+	}
+
+	for {
+		raw, price, err := fetchStats(query)
+		if err != nil {
+			return nil, err
+		}
+		if price > 0 {
+			var out bytes.Buffer
+			json.Compact(&out, raw)
+			return out.Bytes(), nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("no price for %s within %v", symbol, *onceTimeout)
+		}
+		time.Sleep(min(*refresh, remaining))
+	}
+}
+
+// fetchStats returns the raw /api/stats response for query with its price
+func fetchStats(query url.Values) (json.RawMessage, float64, error) {
+	resp, err := http.Get(serverURL + "/api/stats?" + query.Encode())
+	if err != nil {
+		return nil, 0, fmt.Errorf("server not running on port %d", *apiPort)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("stats request failed: %s", resp.Status)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, 0, fmt.Errorf("decoding stats: %w", err)
+	}
+	var stats struct {
+		Price float64 `json:"price"`
+	}
+	json.Unmarshal(raw, &stats)
+	return raw, stats.Price, nil
+}
+
+// listCoins prints the API's selectable coins as a JSON array to stdout
+func listCoins() error {
+	resp, err := http.Get(serverURL + "/api/coins")
+	if err != nil {
+		return fmt.Errorf("server not running on port %d", *apiPort)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("coins request failed: %s", resp.Status)
+	}
+
+	var coins []CoinInfo
+	if err := json.NewDecoder(resp.Body).Decode(&coins); err != nil {
+		return fmt.Errorf("decoding coins: %w", err)
+	}
+	for i, c := range coins {
+		// Older servers don't send it
+		if c.Short == "" {
+			coins[i].Short = coinShort(c.Symbol)
+		}
+		if c.Base == "" {
+			base, quote := splitSymbol(c.Symbol)
+			coins[i].Base, coins[i].Quote = strings.ToUpper(base), strings.ToUpper(quote)
+		}
+	}
+	return json.NewEncoder(os.Stdout).Encode(coins)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// paperTradedMsg describes the result of a paper trade
+type paperTradedMsg string
+
+// paperTrade buys or sells quantity of symbol at the live price on the
+// API's simulated portfolio
+func paperTrade(symbol, side string, quantity float64) tea.Cmd {
+	return func() tea.Msg {
+		body, _ := json.Marshal(map[string]interface{}{"symbol": symbol, "side": side, "quantity": quantity})
+		resp, err := http.Post(serverURL+"/api/portfolio", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return paperTradedMsg("Paper trade failed: server not running")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			reason, _ := io.ReadAll(resp.Body)
+			return paperTradedMsg("Paper trade failed: " + strings.TrimSpace(string(reason)))
+		}
+
+		var result struct {
+			Trade struct {
+				Price float64 `json:"price"`
+			} `json:"trade"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return paperTradedMsg(fmt.Sprintf("Paper %s %g %s at %s", side, quantity, coinShort(symbol), FormatQuoted(symbol, result.Trade.Price)))
+	}
+}
+
+// renderPnL colors a profit or loss
+func (m model) renderPnL(symbol string, pnl, ref float64) string {
+	str := formatQuotedDelta(symbol, pnl, ref)
+	switch {
+	case pnl > 0:
+		return m.theme.Up.Render("+" + str)
+	case pnl < 0:
+		return m.theme.Down.Render(str)
+	default:
+		return m.theme.Value.Render(str)
+	}
+}
+
+// renderPaper shows the simulated position in symbol with its PnL
+func (m model) renderPaper(symbol string) string {
+	label := m.theme.Label.Render("Paper:")
+	paper := m.data.Paper
+	if paper == nil {
+		return label + " " + m.theme.Label.Render("unavailable")
+	}
+
+	var pos PaperPosition
+	for _, p := range paper.Positions {
+		if p.Symbol == symbol {
+			pos = p
+		}
+	}
+	if pos.Quantity == 0 && pos.RealizedPnL == 0 {
+		return label + " " + m.theme.Label.Render(fmt.Sprintf("flat ('b'/'s' trades %g)", *tradeQty))
+	}
+
+	str := m.theme.Label.Render("flat")
+	if pos.Quantity != 0 {
+		side := "long"
+		if pos.Quantity < 0 {
+			side = "short"
+		}
+		str = m.theme.Value.Render(fmt.Sprintf("%s %g @ %s", side, math.Abs(pos.Quantity), FormatQuoted(symbol, pos.AvgEntry))) +
+			"  " + m.theme.Label.Render("unrealized") + " " + m.renderPnL(symbol, pos.UnrealizedPnL, pos.AvgEntry)
+	}
+	return label + " " + str + "  " + m.theme.Label.Render("realized") + " " + m.renderPnL(symbol, pos.RealizedPnL, pos.AvgEntry)
+}
+
+// renderPaperTotals sums PnL across every simulated position
+func (m model) renderPaperTotals() string {
+	paper := m.data.Paper
+	if paper == nil || len(paper.Positions) == 0 {
+		return m.theme.Label.Render("Paper:") + " " + m.theme.Label.Render(fmt.Sprintf("no positions ('b'/'s' trades %g of the first coin)", *tradeQty))
+	}
+	total := func(pnl float64) string {
+		str := fmt.Sprintf("$%.2f", pnl)
+		switch {
+		case pnl > 0:
+			return m.theme.Up.Render("+" + str)
+		case pnl < 0:
+			return m.theme.Down.Render(str)
+		}
+		return m.theme.Value.Render(str)
+	}
+	return fmt.Sprintf("%s %s %s  %s %s  %s %s",
+		m.theme.Label.Render("Paper PnL:"),
+		m.theme.Label.Render("unrealized"), total(paper.UnrealizedPnL),
+		m.theme.Label.Render("realized"), total(paper.RealizedPnL),
+		m.theme.Label.Render("total"), total(paper.TotalPnL))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Binance price tick sizes by symbol, as reported by the API's /api/symbol,
+// and the decimals the API rounds each symbol's indicators to
+var (
+	tickSizes   = make(map[string]float64)
+	apiDecimals = make(map[string]int)
+	tickMu      sync.RWMutex
+)
+
+// setTickSizes caches tick sizes learned from the API
+func setTickSizes(ticks map[string]float64) {
+	tickMu.Lock()
+	defer tickMu.Unlock()
+	for symbol, tick := range ticks {
+		if tick > 0 {
+			tickSizes[symbol] = tick
+		}
+	}
+}
+
+// setPriceDecimals caches the decimals the API reports for symbol
+func setPriceDecimals(symbol string, decimals *int) {
+	if decimals == nil || *decimals < 0 {
+		return
+	}
+	tickMu.Lock()
+	apiDecimals[symbol] = *decimals
+	tickMu.Unlock()
+}
+
+// priceDecimals picks the decimal places for prices of symbol around ref:
+// as many as its tick size has, or until that is known the API's, so every
+// stat of a coin shares one precision, or failing both a magnitude-based
+// guess
+func priceDecimals(symbol string, ref float64) int {
+	tickMu.RLock()
+	tick, ok := tickSizes[symbol]
+	decimals, hinted := apiDecimals[symbol]
+	tickMu.RUnlock()
+	if ok {
+		return tickDecimals(tick)
+	}
+	if hinted {
+		return decimals
+	}
+	return magnitudeDecimals(ref)
+}
+
+// tickDecimals counts the decimal places of a tick size, e.g. 5 for 0.00001
+func tickDecimals(tick float64) int {
+	s := strconv.FormatFloat(tick, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// magnitudeDecimals keeps about six significant digits, between 2 and 8
+// decimal places
+func magnitudeDecimals(price float64) int {
+	if !(price > 0) {
+		return 2
+	}
+	d := 5 - int(math.Floor(math.Log10(price)))
+	return min(max(d, 2), 8)
+}
+
+// numberFormat is how a locale groups thousands and marks decimals
+type numberFormat struct {
+	group, decimal string
+}
+
+// Locales -locale accepts. plain is strconv's format: no grouping and a
+// decimal point.
+var numberFormats = map[string]numberFormat{
+	"plain": {"", "."},
+	"en":    {",", "."},
+	"de":    {".", ","},
+	"fr":    {" ", ","},
+	"ch":    {"'", "."},
+}
+
+// apply regroups s, a number as strconv formats it, in this format.
+// Anything but digits and a decimal point (NaN, Inf) is left alone.
+func (f numberFormat) apply(s string) string {
+	if f.group == "" && (f.decimal == "." || f.decimal == "") {
+		return s
+	}
+	sign, digits := "", s
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(digits, ".")
+	if whole == "" || strings.Trim(whole, "0123456789") != "" {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i := range len(whole) {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteByte(whole[i])
+	}
+	if hasFrac {
+		b.WriteString(f.decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// FormatPrice renders price with the precision Binance quotes symbol in,
+// in the -locale format
+func FormatPrice(symbol string, price float64) string {
+	return numberFormats[*locale].apply(strconv.FormatFloat(price, 'f', priceDecimals(symbol, price), 64))
+}
+
+// formatUnitPrice is FormatPrice converted to -unit, for the dashboard;
+// logs and notifications stay in the pair's quote like the alert rules
+func formatUnitPrice(symbol string, price float64) string {
+	factor, _ := unitFactor(symbol)
+	return numberFormats[*locale].apply(strconv.FormatFloat(price*factor, 'f', unitDecimals(symbol, price, factor), 64))
+}
+
+// Quote assets priced in dollars, and the signs of other currencies. Any
+// other quote asset, like ETHBTC's BTC, follows the amount instead.
+var (
+	dollarQuotes = map[string]bool{"usdt": true, "usdc": true, "fdusd": true, "busd": true}
+	quoteSigns   = map[string]string{"eur": "€", "try": "₺"}
+)
+
+// quoted marks an amount of symbol's quote asset: $65000.12, €60000.00 or
+// 0.05123 BTC, or of -unit once formatUnitPrice converts to it. Symbols with no
+// known quote are taken to be in dollars, and -ascii spells out the
+// currencies that have signs.
+func quoted(symbol, amount string) string {
+	if _, label := unitFactor(symbol); label != "" {
+		return amount + " " + label
+	}
+	_, quote := splitSymbol(symbol)
+	if dollarQuotes[quote] || quote == "" {
+		return "$" + amount
+	}
+	if sign, ok := quoteSigns[quote]; ok && !*asciiOnly {
+		return sign + amount
+	}
+	return amount + " " + strings.ToUpper(quote)
+}
+
+// FormatQuoted is formatUnitPrice with the quote currency or unit marked
+func FormatQuoted(symbol string, price float64) string {
+	return quoted(symbol, formatUnitPrice(symbol, price))
+}
+
+// formatQuotedDelta is formatPriceDelta with the quote currency marked
+func formatQuotedDelta(symbol string, delta, ref float64) string {
+	return quoted(symbol, formatPriceDelta(symbol, delta, ref))
+}
+
+// formatPriceDelta renders a price difference at the precision of prices
+// around ref, so small moves of large prices don't gain digits, converted
+// to -unit like formatUnitPrice
+func formatPriceDelta(symbol string, delta, ref float64) string {
+	factor, _ := unitFactor(symbol)
+	return numberFormats[*locale].apply(strconv.FormatFloat(delta*factor, 'f', unitDecimals(symbol, ref, factor), 64))
+}
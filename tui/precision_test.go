@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+func TestNumberFormats(t *testing.T) {
+	tests := []struct {
+		in                    string
+		plain, en, de, fr, ch string
+	}{
+		{"68432.50", "68432.50", "68,432.50", "68.432,50", "68 432,50", "68'432.50"},
+		{"-1234567.891", "-1234567.891", "-1,234,567.891", "-1.234.567,891", "-1 234 567,891", "-1'234'567.891"},
+		{"999.99", "999.99", "999.99", "999,99", "999,99", "999.99"},
+		{"100000", "100000", "100,000", "100.000", "100 000", "100'000"},
+		{"0.00001234", "0.00001234", "0.00001234", "0,00001234", "0,00001234", "0.00001234"},
+		{"NaN", "NaN", "NaN", "NaN", "NaN", "NaN"},
+		{"+Inf", "+Inf", "+Inf", "+Inf", "+Inf", "+Inf"},
+	}
+	for _, tt := range tests {
+		for name, want := range map[string]string{"plain": tt.plain, "en": tt.en, "de": tt.de, "fr": tt.fr, "ch": tt.ch} {
+			if got := numberFormats[name].apply(tt.in); got != want {
+				t.Errorf("%s: %q = %q, want %q", name, tt.in, got, want)
+			}
+		}
+	}
+}
+
+func TestFormatPriceLocale(t *testing.T) {
+	defer func(l string) { *locale = l }(*locale)
+	setTickSizes(map[string]float64{"LOCALEUSDT": 0.01})
+	t.Cleanup(func() {
+		tickMu.Lock()
+		delete(tickSizes, "LOCALEUSDT")
+		tickMu.Unlock()
+	})
+
+	for l, want := range map[string]string{"plain": "68432.50", "en": "68,432.50", "de": "68.432,50"} {
+		*locale = l
+		if got := FormatPrice("LOCALEUSDT", 68432.5); got != want {
+			t.Errorf("-locale %s: %q, want %q", l, got, want)
+		}
+		if got := FormatQuoted("LOCALEUSDT", 68432.5); got != "$"+want {
+			t.Errorf("-locale %s quoted: %q, want %q", l, got, "$"+want)
+		}
+	}
+}
+
+func TestLowPricedFormatting(t *testing.T) {
+	defer func(l string) { *locale = l }(*locale)
+	*locale = "plain"
+	t.Cleanup(func() {
+		tickMu.Lock()
+		delete(apiDecimals, "PEPEUSDT")
+		tickMu.Unlock()
+	})
+
+	// Before the API says, the magnitude keeps the digits that matter
+	if got := FormatPrice("PEPEUSDT", 0.00001234); got != "0.00001234" {
+		t.Errorf("unhinted: %q", got)
+	}
+
+	// Once it does, every stat of the coin shares its precision, even a
+	// moving average or a move smaller than the price
+	decimals := 8
+	setPriceDecimals("PEPEUSDT", &decimals)
+	for _, tt := range []struct {
+		got, want string
+	}{
+		{FormatPrice("PEPEUSDT", 0.0000123449), "0.00001234"},
+		{FormatPrice("PEPEUSDT", 0.000012), "0.00001200"},
+		{formatPriceDelta("PEPEUSDT", -0.00000031, 0.00001234), "-0.00000031"},
+		{FormatQuoted("PEPEUSDT", 0.00001234), "$0.00001234"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resize feeds m a terminal size change the way bubbletea delivers one
+func resize(t *testing.T, m model, width, height int) model {
+	t.Helper()
+	next, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	return next.(model)
+}
+
+func TestResizeReflowsSparkline(t *testing.T) {
+	defer func(n int) { *sparkPoints = n }(*sparkPoints)
+	*sparkPoints = 0
+
+	m := initialModel(darkTheme())
+	if got := m.sparkWidth(); got != defaultSparkWidth {
+		t.Fatalf("sparkWidth before any size = %d, want %d", got, defaultSparkWidth)
+	}
+	for i := range 1000 {
+		m.history = append(m.history, 100+float64(i%7))
+	}
+
+	tests := []struct {
+		name          string
+		width, height int
+		want          int
+	}{
+		{"narrow", 8, 10, minSparkWidth},
+		{"normal", 80, 24, 80 - boxChrome},
+		{"wide", 1000, 50, maxSparkWidth},
+		{"shrunk again", 40, 24, 40 - boxChrome},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m = resize(t, m, tt.width, tt.height)
+			if m.width != tt.width || m.height != tt.height {
+				t.Fatalf("size = %dx%d, want %dx%d", m.width, m.height, tt.width, tt.height)
+			}
+			if got := m.sparkWidth(); got != tt.want {
+				t.Fatalf("sparkWidth = %d, want %d", got, tt.want)
+			}
+			for _, line := range strings.Split(m.renderSparkline(2), "\n") {
+				if w := lipgloss.Width(line); w != tt.want {
+					t.Fatalf("sparkline row is %d columns, want %d", w, tt.want)
+				}
+			}
+			if tt.width > 2 {
+				if w := lipgloss.Width(m.box("price")); w != tt.width {
+					t.Fatalf("box is %d columns on a %d column terminal", w, tt.width)
+				}
+			}
+		})
+	}
+}
+
+func TestSparkPointsCapsResize(t *testing.T) {
+	defer func(n int) { *sparkPoints = n }(*sparkPoints)
+	*sparkPoints = 30
+
+	m := resize(t, initialModel(darkTheme()), 200, 40)
+	if got := m.sparkWidth(); got != 30 {
+		t.Fatalf("sparkWidth = %d, want -spark-points 30", got)
+	}
+	m = resize(t, m, 20, 40)
+	if got := m.sparkWidth(); got != 20-boxChrome {
+		t.Fatalf("sparkWidth = %d, want %d on a narrow terminal", got, 20-boxChrome)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// MarketSummary is the /api/ticker24h response, Binance's rolling 24 hours
+type MarketSummary struct {
+	Symbol        string  `json:"symbol"`
+	High          float64 `json:"high"`
+	Low           float64 `json:"low"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// summaryMsg carries the market summary fetched at startup, nil when the
+// lookup failed
+type summaryMsg *MarketSummary
+
+// fetchSummary asks the API for symbol's (or the primary symbol's) 24-hour
+// ticker once, giving the dashboard context before live ticks arrive
+func fetchSummary(symbol string) tea.Cmd {
+	return func() tea.Msg {
+		query := ""
+		if symbol != "" {
+			query = "?symbol=" + url.QueryEscape(symbol)
+		}
+		resp, err := http.Get(serverURL + "/api/ticker24h" + query)
+		if err != nil {
+			return summaryMsg(nil)
+		}
+		defer resp.Body.Close()
+		var s MarketSummary
+		if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&s) != nil {
+			return summaryMsg(nil)
+		}
+		return summaryMsg(&s)
+	}
+}
+
+// renderSummaryBanner is the one-line market summary fetched at startup,
+// or "" when it failed or belongs to another coin
+func (m model) renderSummaryBanner() string {
+	s := m.summary
+	if s == nil || s.Symbol != m.data.Symbol {
+		return ""
+	}
+	change := m.theme.Label.Render(fmt.Sprintf("%+.2f%%", s.ChangePercent))
+	if s.ChangePercent > 0 {
+		change = m.theme.Up.Render(fmt.Sprintf("▲ %+.2f%%", s.ChangePercent))
+	} else if s.ChangePercent < 0 {
+		change = m.theme.Down.Render(fmt.Sprintf("▼ %+.2f%%", s.ChangePercent))
+	}
+	return fmt.Sprintf("%s %s  %s %s  %s %s",
+		m.theme.Label.Render("Binance 24h at start:"), change,
+		m.theme.Label.Render("H"), m.theme.Up.Render(FormatQuoted(s.Symbol, s.High)),
+		m.theme.Label.Render("L"), m.theme.Down.Render(FormatQuoted(s.Symbol, s.Low)))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trades the tape panel shows, newest on top
+const tapeRows = 10
+
+// renderTape lists the latest live trades colored by aggressor side: green
+// when a buyer took an ask, red when a seller hit a bid
+func (m model) renderTape() string {
+	title := m.theme.Label.Render("Trade Tape:")
+	if m.tickerOnly() {
+		return title + " " + m.theme.Label.Render(needsTrades)
+	}
+	if len(m.data.Tape) == 0 {
+		return title + " " + m.theme.Label.Render("no live trades yet")
+	}
+
+	lines := []string{title}
+	for _, t := range m.data.Tape {
+		style, side := m.theme.Label, "?"
+		switch t.Side {
+		case "buy":
+			style, side = m.theme.Up, "BUY"
+		case "sell":
+			style, side = m.theme.Down, "SELL"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s %s",
+			m.theme.Time.Render(t.Timestamp.Local().Format("15:04:05")),
+			style.Render(fmt.Sprintf("%-4s", side)),
+			style.Render(fmt.Sprintf("%14s", formatUnitPrice(m.data.Symbol, t.Price))),
+			m.theme.Label.Render(fmt.Sprintf("%.4f", t.Quantity))))
+	}
+	return strings.Join(lines, "\n")
+}
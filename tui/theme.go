@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme bundles every style the TUI draws with
+type Theme struct {
+	Box      lipgloss.Style
+	Price    lipgloss.Style
+	Up       lipgloss.Style
+	Down     lipgloss.Style
+	Label    lipgloss.Style
+	Value    lipgloss.Style
+	Header   lipgloss.Style
+	Help     lipgloss.Style
+	Error    lipgloss.Style
+	Selected lipgloss.Style
+	Item     lipgloss.Style
+	Time     lipgloss.Style
+
+	// Sparkline shades from faint to bright, indexed by volatilityBucket
+	UpShades   []lipgloss.Style
+	DownShades []lipgloss.Style
+}
+
+// themeByName returns the named theme
+func themeByName(name string) (Theme, error) {
+	switch name {
+	case "dark":
+		return darkTheme(), nil
+	case "light":
+		return lightTheme(), nil
+	case "mono":
+		return monoTheme(), nil
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (dark, light or mono)", name)
+}
+
+// colored builds a theme from a palette of ANSI 256 colors
+func colored(accent, text, up, down, muted, info string, upShades, downShades []string) Theme {
+	fg := func(color string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+	}
+	shades := func(colors []string) []lipgloss.Style {
+		styles := make([]lipgloss.Style, len(colors))
+		for i, c := range colors {
+			styles[i] = fg(c)
+		}
+		return styles
+	}
+
+	return Theme{
+		Box: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color(accent)).
+			Padding(1, 2),
+		Price:      fg(text).Bold(true),
+		Up:         fg(up),
+		Down:       fg(down),
+		Label:      fg(muted),
+		Value:      fg(text),
+		Header:     fg(accent).Bold(true).MarginBottom(1),
+		Help:       fg(muted).MarginTop(1),
+		Error:      fg(down),
+		Selected:   fg(accent).Bold(true),
+		Item:       fg(muted),
+		Time:       fg(info),
+		UpShades:   shades(upShades),
+		DownShades: shades(downShades),
+	}
+}
+
+// darkTheme is the original palette, bright text on a dark background
+func darkTheme() Theme {
+	return colored("10", "15", "10", "9", "8", "6",
+		[]string{"22", "28", "34", "46"},
+		[]string{"52", "88", "160", "196"})
+}
+
+// lightTheme uses darker colors that stay readable on a light background;
+// its sparkline shades get darker, not brighter, as moves grow
+func lightTheme() Theme {
+	return colored("28", "235", "28", "124", "242", "25",
+		[]string{"151", "114", "34", "22"},
+		[]string{"217", "174", "160", "88"})
+}
+
+// monoTheme draws without any color, relying on weight, underline and
+// reverse video to stand things apart
+func monoTheme() Theme {
+	plain := lipgloss.NewStyle()
+	flat := []lipgloss.Style{plain, plain, plain, plain}
+	return Theme{
+		Box: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			Padding(1, 2),
+		Price:      plain.Bold(true),
+		Up:         plain.Bold(true),
+		Down:       plain.Underline(true),
+		Label:      plain,
+		Value:      plain,
+		Header:     plain.Bold(true).MarginBottom(1),
+		Help:       plain.MarginTop(1),
+		Error:      plain.Bold(true),
+		Selected:   plain.Reverse(true),
+		Item:       plain,
+		Time:       plain,
+		UpShades:   flat,
+		DownShades: flat,
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"sync"
+)
+
+// Satoshis in one bitcoin
+const satsPerBTC = 1e8
+
+// Latest prices of the tracked coins, which -unit divides others by
+var (
+	unitPrices = make(map[string]float64)
+	unitMu     sync.RWMutex
+)
+
+// setUnitPrices replaces the known prices with those of coins; a single
+// tracked coin leaves none, as there's nothing to convert through
+func setUnitPrices(coins []CoinRow) {
+	unitMu.Lock()
+	defer unitMu.Unlock()
+	clear(unitPrices)
+	for _, coin := range coins {
+		if coin.Price > 0 {
+			unitPrices[coin.Symbol] = coin.Price
+		}
+	}
+}
+
+// unitReference returns the symbol whose price symbol's prices are divided
+// by for -unit and what one of it is multiplied into: sats goes through
+// BTC in symbol's quote (no symbol at all once that is BTC), and a symbol
+// names its own base. ok is false when symbol stays in its own quote: no
+// -unit, the reference itself, or a reference in another quote.
+func unitReference(symbol string) (ref string, scale float64, label string, ok bool) {
+	_, quote := splitSymbol(symbol)
+	switch *displayUnit {
+	case "":
+		return "", 0, "", false
+	case "sats":
+		if quote == "btc" {
+			return "", satsPerBTC, "sats", true
+		}
+		ref, scale, label = "btc"+quote, satsPerBTC, "sats"
+	default:
+		ref, scale, label = *displayUnit, 1, coinShort(*displayUnit)
+	}
+	_, refQuote := splitSymbol(ref)
+	if symbol == ref || quote == "" || refQuote != quote {
+		return "", 0, "", false
+	}
+	return ref, scale, label, true
+}
+
+// unitFactor returns what symbol's prices are multiplied by to show them in
+// -unit and the unit's label, or 1 and "" while they stay in the quote,
+// including while the reference's price isn't known
+func unitFactor(symbol string) (float64, string) {
+	ref, scale, label, ok := unitReference(symbol)
+	if !ok {
+		return 1, ""
+	}
+	if ref == "" {
+		return scale, label
+	}
+	unitMu.RLock()
+	price := unitPrices[ref]
+	unitMu.RUnlock()
+	if !(price > 0) {
+		return 1, ""
+	}
+	return scale / price, label
+}
+
+// unitDecimals shifts symbol's decimal places around ref by the magnitude
+// of factor, so a converted price keeps about as many digits
+func unitDecimals(symbol string, ref, factor float64) int {
+	decimals := priceDecimals(symbol, ref)
+	if factor == 1 {
+		return decimals
+	}
+	return min(max(decimals-int(math.Round(math.Log10(factor))), 0), 8)
+}
+
+// validUnit reports whether a lowercased -unit is empty, sats or a symbol
+func validUnit(unit string) bool {
+	return strings.Trim(unit, "abcdefghijklmnopqrstuvwxyz0123456789") == ""
+}
+
+// unitTag names the unit prices are shown in for the header: for symbol,
+// or for every coin when symbol is "". A coin that can't be converted
+// without a reference's price says which.
+func (m model) unitTag(symbol string) string {
+	if *displayUnit == "" {
+		return ""
+	}
+	name := *displayUnit
+	if name != "sats" {
+		name = coinShort(name)
+	}
+	if symbol == "" {
+		return "  in " + name
+	}
+	ref, _, _, ok := unitReference(symbol)
+	if !ok {
+		return ""
+	}
+	if _, label := unitFactor(symbol); label == "" {
+		return "  in " + name + " (needs " + strings.ToUpper(ref) + " tracked)"
+	}
+	return "  in " + name
+}
@@ -0,0 +1,80 @@
+package main
+
+import "strings"
+
+// appendHistory records a polled price with the quantity traded since the
+// previous poll, keeping history and volumes the same length
+func (m *model) appendHistory(price, totalVolume float64) {
+	var traded float64
+	// The API's total only falls when it restarted
+	if m.lastVolume >= 0 && totalVolume >= m.lastVolume {
+		traded = totalVolume - m.lastVolume
+	}
+	m.lastVolume = totalVolume
+
+	m.history = append(m.history, price)
+	m.volumes = append(m.volumes, traded)
+	m.trimHistory()
+}
+
+// trimHistory drops the oldest points beyond -history
+func (m *model) trimHistory() {
+	if n := *historyPoints; len(m.history) > n {
+		m.history = m.history[len(m.history)-n:]
+		m.volumes = m.volumes[len(m.volumes)-n:]
+	}
+}
+
+// clearHistory empties the price and volume history, forgetting the volume
+// baseline so the next poll doesn't count the gap as one interval
+func (m *model) clearHistory() {
+	m.history = make([]float64, 0, m.sparkWidth())
+	m.volumes = make([]float64, 0, m.sparkWidth())
+	m.lastVolume = -1
+}
+
+// renderVolume draws the volume traded over each sparkline point as one
+// row of bars under it, colored by whether the price closed the interval
+// up or down
+func (m model) renderVolume() string {
+	if len(m.history) < 2 {
+		return ""
+	}
+	if m.tickerOnly() {
+		return m.theme.Label.Render("volume " + needsTrades)
+	}
+	volumes, prices := m.volumes, m.history
+	if n := m.sparkWidth(); len(volumes) > n {
+		volumes = volumes[len(volumes)-n:]
+		prices = prices[len(prices)-n-1:]
+	} else {
+		prices = append([]float64{prices[0]}, prices...)
+	}
+
+	var peak float64
+	for _, v := range volumes {
+		peak = max(peak, v)
+	}
+	if peak == 0 {
+		return m.theme.Label.Render("no volume yet")
+	}
+
+	chars := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+	var b strings.Builder
+	for i, v := range volumes {
+		if v <= 0 {
+			b.WriteByte(' ')
+			continue
+		}
+		bar := string(chars[min(int(v/peak*float64(len(chars))), len(chars)-1)])
+		switch {
+		case prices[i+1] > prices[i]:
+			b.WriteString(m.theme.Up.Render(bar))
+		case prices[i+1] < prices[i]:
+			b.WriteString(m.theme.Down.Render(bar))
+		default:
+			b.WriteString(m.theme.Value.Render(bar))
+		}
+	}
+	return b.String()
+}